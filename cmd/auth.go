@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"time"
@@ -97,6 +98,12 @@ func (server *Server) authenticate(w http.ResponseWriter, r *http.Request) (Comm
 
 	loggingConsent := user.LoggingConsent.Valid && user.LoggingConsent.Time.After(time.Now())
 
+	// Not worth failing the request over
+	unseenNotifications, countErr := server.Queries.CountUnseenNotifications(ctx, user.ID)
+	if countErr != nil {
+		log.Printf("counting notifications for user %d: %v", user.ID, countErr)
+	}
+
 	userData := UserData{
 		AppuserID:       user.ID,
 		DisplayName:     user.DisplayName,
@@ -109,11 +116,14 @@ func (server *Server) authenticate(w http.ResponseWriter, r *http.Request) (Comm
 		Homes:           homes,
 		LoggingConsent:  loggingConsent,
 		AccessLevel:     AccessLevel(user.AccessLevel),
+
+		UnseenNotifications: unseenNotifications,
 	}
 
 	commonData := CommonData{
-		User:     userData,
-		BuildKey: server.BuildKey,
+		User:              userData,
+		BuildKey:          server.BuildKey,
+		GDriveUnavailable: server.GDriveWorker != nil && !server.GDriveWorker.Health().OK(),
 	}
 
 	return commonData, err
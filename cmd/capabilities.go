@@ -28,6 +28,11 @@ import "slices"
 // Debug,
 // UploadFile,
 // EditWiki,
+// ManageSearchIndex,
+// ManageJournalTemplates,
+// ManageOrphanJournals,
+// ManageGDriveSettings,
+// ManageSettings,
 // )
 type Capability int32
 
@@ -54,11 +59,18 @@ var RequiredAccessLevel = map[Capability]AccessLevel{
 	CapUseImportTool:  AccessLevelCoordinator,
 	CapEditWiki:       AccessLevelCoordinator,
 
+	CapManageJournalTemplates: AccessLevelCoordinator,
+	CapManageOrphanJournals:   AccessLevelCoordinator,
+
 	CapManageUsers:    AccessLevelAdmin,
 	CapDeleteUsers:    AccessLevelAdmin,
 	CapInviteToGDrive: AccessLevelAdmin,
 	CapInviteToBino:   AccessLevelAdmin,
 	CapDebug:          AccessLevelAdmin,
+
+	CapManageSearchIndex:    AccessLevelAdmin,
+	CapManageGDriveSettings: AccessLevelAdmin,
+	CapManageSettings:       AccessLevelAdmin,
 }
 
 var AccessLevelToCapabilities = func() (out struct {
@@ -59,37 +59,52 @@ const (
 	CapUploadFile Capability = 22
 	// CapEditWiki is a Capability of type EditWiki.
 	CapEditWiki Capability = 23
+	// CapManageSearchIndex is a Capability of type ManageSearchIndex.
+	CapManageSearchIndex Capability = 24
+	// CapManageJournalTemplates is a Capability of type ManageJournalTemplates.
+	CapManageJournalTemplates Capability = 25
+	// CapManageOrphanJournals is a Capability of type ManageOrphanJournals.
+	CapManageOrphanJournals Capability = 26
+	// CapManageGDriveSettings is a Capability of type ManageGDriveSettings.
+	CapManageGDriveSettings Capability = 27
+	// CapManageSettings is a Capability of type ManageSettings.
+	CapManageSettings Capability = 28
 )
 
 var ErrInvalidCapability = errors.New("not a valid Capability")
 
-const _CapabilityName = "ViewAllActivePatientsViewAllFormerPatientsViewAllHomesViewAllUsersViewCalendarSearchSetOwnPreferencesCheckInPatientManageOwnPatientsManageAllPatientsManageOwnHomesManageAllHomesCreatePatientJournalManageSpeciesManageUsersDeleteUsersViewAdminToolsViewGDriveSettingsInviteToGDriveInviteToBinoUseImportToolDebugUploadFileEditWiki"
+const _CapabilityName = "ViewAllActivePatientsViewAllFormerPatientsViewAllHomesViewAllUsersViewCalendarSearchSetOwnPreferencesCheckInPatientManageOwnPatientsManageAllPatientsManageOwnHomesManageAllHomesCreatePatientJournalManageSpeciesManageUsersDeleteUsersViewAdminToolsViewGDriveSettingsInviteToGDriveInviteToBinoUseImportToolDebugUploadFileEditWikiManageSearchIndexManageJournalTemplatesManageOrphanJournalsManageGDriveSettingsManageSettings"
 
 var _CapabilityMap = map[Capability]string{
-	CapViewAllActivePatients: _CapabilityName[0:21],
-	CapViewAllFormerPatients: _CapabilityName[21:42],
-	CapViewAllHomes:          _CapabilityName[42:54],
-	CapViewAllUsers:          _CapabilityName[54:66],
-	CapViewCalendar:          _CapabilityName[66:78],
-	CapSearch:                _CapabilityName[78:84],
-	CapSetOwnPreferences:     _CapabilityName[84:101],
-	CapCheckInPatient:        _CapabilityName[101:115],
-	CapManageOwnPatients:     _CapabilityName[115:132],
-	CapManageAllPatients:     _CapabilityName[132:149],
-	CapManageOwnHomes:        _CapabilityName[149:163],
-	CapManageAllHomes:        _CapabilityName[163:177],
-	CapCreatePatientJournal:  _CapabilityName[177:197],
-	CapManageSpecies:         _CapabilityName[197:210],
-	CapManageUsers:           _CapabilityName[210:221],
-	CapDeleteUsers:           _CapabilityName[221:232],
-	CapViewAdminTools:        _CapabilityName[232:246],
-	CapViewGDriveSettings:    _CapabilityName[246:264],
-	CapInviteToGDrive:        _CapabilityName[264:278],
-	CapInviteToBino:          _CapabilityName[278:290],
-	CapUseImportTool:         _CapabilityName[290:303],
-	CapDebug:                 _CapabilityName[303:308],
-	CapUploadFile:            _CapabilityName[308:318],
-	CapEditWiki:              _CapabilityName[318:326],
+	CapViewAllActivePatients:  _CapabilityName[0:21],
+	CapViewAllFormerPatients:  _CapabilityName[21:42],
+	CapViewAllHomes:           _CapabilityName[42:54],
+	CapViewAllUsers:           _CapabilityName[54:66],
+	CapViewCalendar:           _CapabilityName[66:78],
+	CapSearch:                 _CapabilityName[78:84],
+	CapSetOwnPreferences:      _CapabilityName[84:101],
+	CapCheckInPatient:         _CapabilityName[101:115],
+	CapManageOwnPatients:      _CapabilityName[115:132],
+	CapManageAllPatients:      _CapabilityName[132:149],
+	CapManageOwnHomes:         _CapabilityName[149:163],
+	CapManageAllHomes:         _CapabilityName[163:177],
+	CapCreatePatientJournal:   _CapabilityName[177:197],
+	CapManageSpecies:          _CapabilityName[197:210],
+	CapManageUsers:            _CapabilityName[210:221],
+	CapDeleteUsers:            _CapabilityName[221:232],
+	CapViewAdminTools:         _CapabilityName[232:246],
+	CapViewGDriveSettings:     _CapabilityName[246:264],
+	CapInviteToGDrive:         _CapabilityName[264:278],
+	CapInviteToBino:           _CapabilityName[278:290],
+	CapUseImportTool:          _CapabilityName[290:303],
+	CapDebug:                  _CapabilityName[303:308],
+	CapUploadFile:             _CapabilityName[308:318],
+	CapEditWiki:               _CapabilityName[318:326],
+	CapManageSearchIndex:      _CapabilityName[326:343],
+	CapManageJournalTemplates: _CapabilityName[343:365],
+	CapManageOrphanJournals:   _CapabilityName[365:385],
+	CapManageGDriveSettings:   _CapabilityName[385:405],
+	CapManageSettings:         _CapabilityName[405:419],
 }
 
 // String implements the Stringer interface.
@@ -132,6 +147,11 @@ var _CapabilityValue = map[string]Capability{
 	_CapabilityName[303:308]: CapDebug,
 	_CapabilityName[308:318]: CapUploadFile,
 	_CapabilityName[318:326]: CapEditWiki,
+	_CapabilityName[326:343]: CapManageSearchIndex,
+	_CapabilityName[343:365]: CapManageJournalTemplates,
+	_CapabilityName[365:385]: CapManageOrphanJournals,
+	_CapabilityName[385:405]: CapManageGDriveSettings,
+	_CapabilityName[405:419]: CapManageSettings,
 }
 
 // ParseCapability attempts to convert a string to a Capability.
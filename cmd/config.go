@@ -13,6 +13,8 @@ type Config struct {
 	Auth           AuthConfig
 	HTTP           HTTPConfig
 	GoogleDrive    GDriveConfig
+	Journal        JournalConfig
+	Email          EmailConfig
 	SystemLanguage LanguageID
 	SystemBaseURL  string
 }
@@ -30,6 +32,6 @@ func loadConfig(file string) (Config, error) {
 	return config, nil
 }
 
-func (config *Config) BinoURLForPatient(patient int32) string {
+func (config Config) BinoURLForPatient(patient int32) string {
 	return fmt.Sprintf("%s/patient/%d", config.SystemBaseURL, patient)
 }
@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
@@ -105,6 +107,8 @@ func (server *Server) dashboardHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	})
 
+	server.addJournalTaskStatus(ctx, SliceToSlice(homeViews, func(hv HomeView) []PatientView { return hv.Patients })...)
+
 	var preferredHomeView HomeView
 	if preferredHomeIdx := Find(homes, func(h Home) bool {
 		return h.ID == commonData.User.PreferredHome.ID
@@ -152,23 +156,20 @@ func (server *Server) postCheckinHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	systemSpeciesName, err := server.Queries.GetNameOfSpecies(ctx, GetNameOfSpeciesParams{
-		SpeciesID:  fields["species"],
-		LanguageID: int32(server.Config.SystemLanguage),
-	})
-	if err != nil {
-		server.renderError(w, r, commonData, err)
-		return
-	}
+	// Optional
+	finder, _ := server.getFormValue(r, "finder")
+	intakeCause, _ := server.getFormValue(r, "intake-cause")
 
 	var patientID int32
 	if err := server.Transaction(ctx, func(ctx context.Context, q *Queries) error {
 		var err error
 		patientID, err = q.AddPatient(ctx, AddPatientParams{
-			SpeciesID:  fields["species"],
-			CurrHomeID: pgtype.Int4{Int32: fields["home"], Valid: true},
-			Name:       name,
-			Status:     int32(StatusAdmitted),
+			SpeciesID:   fields["species"],
+			CurrHomeID:  pgtype.Int4{Int32: fields["home"], Valid: true},
+			Name:        name,
+			Status:      int32(StatusAdmitted),
+			Finder:      strings.TrimSpace(finder),
+			IntakeCause: strings.TrimSpace(intakeCause),
 		})
 		if err != nil {
 			return err
@@ -191,20 +192,20 @@ func (server *Server) postCheckinHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	if createJournal {
-		if item, err := server.GDriveWorker.CreateJournal(GDriveTemplateVars{
-			Time:    time.Now(),
-			Name:    name,
-			Species: systemSpeciesName,
-			BinoURL: server.Config.BinoURLForPatient(patientID),
-		}); err != nil {
+		patient, err := server.Queries.GetPatientWithSpecies(ctx, GetPatientWithSpeciesParams{
+			ID:         patientID,
+			LanguageID: int32(server.Config().SystemLanguage),
+		})
+		if err == nil {
+			_, err = server.createPatientJournal(ctx, payloadCreatePatientJournal{
+				PatientID: patientID,
+				HomeID:    fields["home"],
+				AppuserID: commonData.User.AppuserID,
+				Vars:      server.journalVars(ctx, patient),
+			})
+		}
+		if err != nil {
 			commonData.Warning(commonData.User.Language.GDriveCreateJournalFailed, err)
-		} else {
-			if tag, err := server.Queries.SetPatientJournal(ctx, SetPatientJournalParams{
-				ID:         patientID,
-				JournalUrl: pgtype.Text{String: item.DocumentURL(), Valid: true},
-			}); err != nil || tag.RowsAffected() == 0 {
-				commonData.Warning(commonData.User.Language.GDriveCreateJournalFailed, err)
-			}
 		}
 	}
 
@@ -344,6 +345,87 @@ func (server *Server) postCheckoutHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if err := server.updatePatientJournal(ctx, patient); err != nil {
+		commonData.Warning(commonData.User.Language.JournalUpdateFailed, err)
+	}
+
+	// Patients deleted by mistake have no journal worth keeping
+	if status != int32(StatusDeleted) {
+		if err := server.archivePatientJournal(ctx, patientData, commonData.User.AppuserID); err != nil {
+			commonData.Warning(commonData.User.Language.JournalArchiveFailed, err)
+		}
+	}
+
+	server.redirectToReferer(w, r)
+}
+
+func (server *Server) postReadmitHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	patient, err := server.getPathID(r, "patient")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	homeID, err := server.getFormID(r, "home")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	if err := server.Transaction(ctx, func(ctx context.Context, q *Queries) error {
+		// Deleted patients were never real patients
+		patientData, err := q.GetPatient(ctx, patient)
+		if err != nil {
+			return err
+		}
+		if status := Status(patientData.Status); !IsCheckoutStatus[status] || status == StatusDeleted {
+			return errors.New(commonData.User.Language.PatientReadmitNotCheckedOut)
+		}
+
+		if err := q.CheckoutPatient(ctx, CheckoutPatientParams{
+			ID:           patient,
+			TimeCheckout: pgtype.Timestamptz{},
+		}); err != nil {
+			return err
+		}
+
+		if err := q.SetPatientStatus(ctx, SetPatientStatusParams{
+			ID:     patient,
+			Status: int32(StatusAdmitted),
+		}); err != nil {
+			return err
+		}
+
+		if err := q.MovePatient(ctx, MovePatientParams{
+			ID:         patient,
+			CurrHomeID: pgtype.Int4{Int32: homeID, Valid: true},
+		}); err != nil {
+			return err
+		}
+
+		if _, err := q.AddPatientEvent(ctx, AddPatientEventParams{
+			PatientID: patient,
+			AppuserID: commonData.User.AppuserID,
+			HomeID:    homeID,
+			EventID:   int32(EventReadmitted),
+			Time:      pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		}); err != nil {
+			return err
+		}
+
+		return nil
+	}); err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	if err := server.updatePatientJournal(ctx, patient); err != nil {
+		commonData.Warning(commonData.User.Language.JournalUpdateFailed, err)
+	}
+
 	server.redirectToReferer(w, r)
 }
 
@@ -399,6 +481,10 @@ func (server *Server) postSetNameHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if err := server.updatePatientJournal(ctx, patient); err != nil {
+		commonData.Warning(commonData.User.Language.JournalUpdateFailed, err)
+	}
+
 	server.redirectToReferer(w, r)
 }
 
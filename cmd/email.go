@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type EmailConfig struct {
+	// Email is disabled if no SMTP host is set
+	SMTPHost         string
+	SMTPPort         int
+	Username         string
+	PasswordLocation string
+	From             string
+}
+
+func (cfg EmailConfig) Enabled() bool {
+	return cfg.SMTPHost != ""
+}
+
+// Send a plain text email.
+func (cfg EmailConfig) Send(to, subject, body string) error {
+	if !cfg.Enabled() {
+		return fmt.Errorf("email is not configured")
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		password, err := os.ReadFile(cfg.PasswordLocation)
+		if err != nil {
+			return fmt.Errorf("reading SMTP password: %w", err)
+		}
+		auth = smtp.PlainAuth("", cfg.Username, strings.TrimSpace(string(password)), cfg.SMTPHost)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	addr := net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort))
+	return smtp.SendMail(addr, auth, cfg.From, []string{to}, msg.Bytes())
+}
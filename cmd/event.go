@@ -19,6 +19,7 @@ import "net/http"
 //	JournalCreated                 = 14,
 //	JournalAttached                = 15,
 //	JournalDetached                = 16,
+//	Readmitted                     = 17,
 //
 // )
 type Event int32
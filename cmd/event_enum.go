@@ -40,11 +40,13 @@ const (
 	EventJournalAttached Event = 15
 	// EventJournalDetached is a Event of type JournalDetached.
 	EventJournalDetached Event = 16
+	// EventReadmitted is a Event of type Readmitted.
+	EventReadmitted Event = 17
 )
 
 var ErrInvalidEvent = errors.New("not a valid Event")
 
-const _EventName = "UnknownRegisteredAdoptedReleasedTransferredToOtherHomeTransferredOutsideOrganizationDiedEuthanizedStatusChangedDeletedNameChangedJournalCreatedJournalAttachedJournalDetached"
+const _EventName = "UnknownRegisteredAdoptedReleasedTransferredToOtherHomeTransferredOutsideOrganizationDiedEuthanizedStatusChangedDeletedNameChangedJournalCreatedJournalAttachedJournalDetachedReadmitted"
 
 var _EventMap = map[Event]string{
 	EventUnknown:                        _EventName[0:7],
@@ -61,6 +63,7 @@ var _EventMap = map[Event]string{
 	EventJournalCreated:                 _EventName[129:143],
 	EventJournalAttached:                _EventName[143:158],
 	EventJournalDetached:                _EventName[158:173],
+	EventReadmitted:                     _EventName[173:183],
 }
 
 // String implements the Stringer interface.
@@ -93,6 +96,7 @@ var _EventValue = map[string]Event{
 	_EventName[129:143]: EventJournalCreated,
 	_EventName[143:158]: EventJournalAttached,
 	_EventName[158:173]: EventJournalDetached,
+	_EventName[173:183]: EventReadmitted,
 }
 
 // ParseEvent attempts to convert a string to a Event.
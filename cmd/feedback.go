@@ -118,7 +118,7 @@ func (server *Server) deleteCookie(w http.ResponseWriter, r *http.Request, key s
 func (server *Server) setFeedbackCookie(w http.ResponseWriter, r *http.Request) {
 	cd, err := LoadCommonData(r.Context())
 	if err != nil {
-		cd.Log("no common data: %w", err)
+		cd.Log("no common data: %v", err)
 		return
 	}
 
@@ -131,7 +131,7 @@ func (server *Server) setFeedbackCookie(w http.ResponseWriter, r *http.Request)
 func (server *Server) eatFeedbackCookie(w http.ResponseWriter, r *http.Request) {
 	cd, err := LoadCommonData(r.Context())
 	if err != nil {
-		cd.Log("no common data: %w", err)
+		cd.Log("no common data: %v", err)
 		return
 	}
 
@@ -58,6 +58,18 @@ func (server *Server) fileHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Archived journals are only shown to those who can read the journal
+	if archive, err := server.Queries.GetPatientJournalArchiveByFile(ctx, id); err == nil {
+		data, err := LoadCommonData(ctx)
+		if err != nil || !server.searchBodyAccess(&data.User).JournalVisible(archive.HomeID) {
+			ajaxError(w, r, err, http.StatusUnauthorized)
+			return
+		}
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		ajaxError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
 	rc, err := server.FileBackend.Open(ctx, fileView.UUID, fileView.FileInfo())
 	if err != nil {
 		ajaxError(w, r, err, http.StatusInternalServerError)
@@ -67,7 +79,7 @@ func (server *Server) fileHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", fileView.MIMEType)
 	w.Header().Set("Content-Length", strconv.Itoa(int(fileView.Size)))
 	if _, err := io.Copy(w, rc); err != nil {
-		LogCtx(ctx, "failed to write out file: %w", err)
+		LogCtx(ctx, "failed to write out file: %v", err)
 	}
 }
 
@@ -105,10 +117,11 @@ func (server *Server) filepondSubmit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var registered []FileView
 	if err := server.Transaction(ctx, func(ctx context.Context, q *Queries) error {
 		errs := []error{}
 		for uuid, fileInfo := range result.Commited {
-			_, err := server.Queries.RegisterFile(ctx, RegisterFileParams{
+			file := File{
 				Uuid:          uuid,
 				Creator:       data.User.AppuserID,
 				Created:       pgtype.Timestamptz{Time: time.Now(), Valid: true},
@@ -116,11 +129,23 @@ func (server *Server) filepondSubmit(w http.ResponseWriter, r *http.Request) {
 				Filename:      fileInfo.FileName,
 				Mimetype:      fileInfo.MIMEType,
 				Size:          fileInfo.Size,
+			}
+			id, err := server.Queries.RegisterFile(ctx, RegisterFileParams{
+				Uuid:          file.Uuid,
+				Creator:       file.Creator,
+				Created:       file.Created,
+				Accessibility: file.Accessibility,
+				Filename:      file.Filename,
+				Mimetype:      file.Mimetype,
+				Size:          file.Size,
 			})
 			if err != nil {
-				errs = append(errs, fmt.Errorf("committing %s: %w"))
+				errs = append(errs, fmt.Errorf("committing %s: %w", uuid, err))
 				data.Error(data.User.Language.GenericFailed, err)
+				continue
 			}
+			file.ID = id
+			registered = append(registered, file.ToFileView())
 		}
 		return errors.Join(errs...)
 	}); err != nil {
@@ -129,6 +154,12 @@ func (server *Server) filepondSubmit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for _, file := range registered {
+		if err := server.searchIndexUploadedFile(ctx, file); err != nil {
+			LogCtx(ctx, "indexing %s for search: %v", file.OriginalFileName, err)
+		}
+	}
+
 	server.redirect(w, r, "/file")
 }
 
@@ -233,12 +264,23 @@ func (server *Server) fileDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Archived journals are kept for good
+	if _, err := server.Queries.GetPatientJournalArchiveByFile(ctx, id); err == nil {
+		data.Error(data.User.Language.FileIsJournalArchive, nil)
+		server.redirectToReferer(w, r)
+		return
+	}
+
 	if err := server.Queries.DeregisterFile(ctx, id); err != nil {
 		data.Error(data.User.Language.GenericFailed, err)
 		server.redirectToReferer(w, r)
 		return
 	}
 
+	if err := server.searchDeleteUploadedFile(ctx, file.ToFileView()); err != nil {
+		LogCtx(ctx, "removing %s from search: %v", file.Filename, err)
+	}
+
 	if result := server.FileBackend.Delete(ctx, file.Uuid); result.Error != nil {
 		ajaxError(w, r, err, result.HTTPStatusCode)
 		return
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type SearchFileInfo struct {
+	FileName string
+	MIMEType string
+	Size     int64
+}
+
+func (sfi *SearchFileInfo) IndexableText() string {
+	return fmt.Sprintf(`
+
+FileName = %s
+
+`, sfi.FileName)
+}
+
+// Extract the text of an uploaded file and add it to the search index.
+// Personal files are only visible in search for their creator. Files whose
+// text can't be extracted get a skipped entry, so that they aren't tried again.
+func (server *Server) searchIndexUploadedFile(ctx context.Context, file FileView) error {
+	rc, err := server.FileBackend.Open(ctx, file.UUID, file.FileInfo())
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	defer rc.Close()
+
+	info := SearchFileInfo{
+		FileName: file.OriginalFileName,
+		MIMEType: file.MIMEType,
+		Size:     file.Size,
+	}
+	extraData, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("marshalling extra data: %w", err)
+	}
+	ownerID := pgtype.Int4{Int32: file.Creator, Valid: file.Accessibility == FileAccessibilityPersonal}
+
+	text, err := ExtractText(file.MIMEType, file.OriginalFileName, rc)
+	if err != nil {
+		if err := server.Queries.UpsertSkippedSearchEntry(ctx, UpsertSkippedSearchEntryParams{
+			Namespace:     string(MatchTypeFile),
+			AssociatedUrl: pgtype.Text{String: file.URL(), Valid: true},
+			Created:       pgtype.Timestamptz{Time: file.Created, Valid: true},
+			Updated:       pgtype.Timestamptz{Time: time.Now(), Valid: true},
+			Header:        pgtype.Text{String: file.OriginalFileName, Valid: true},
+			Body:          pgtype.Text{String: info.IndexableText(), Valid: true},
+			Lang:          server.Config().SystemLanguage.Regconfig(),
+			ExtraData:     pgtype.Text{String: string(extraData), Valid: true},
+			SkipReason:    pgtype.Text{String: err.Error(), Valid: true},
+			OwnerID:       ownerID,
+		}); err != nil {
+			return fmt.Errorf("creating skipped entry: %w", err)
+		}
+		return fmt.Errorf("extracting text: %w (created skipped entry)", err)
+	}
+
+	lang := server.Config().SystemLanguage
+	if detected, ok := DetectLanguage(text); ok {
+		lang = detected
+	}
+
+	return server.Queries.UpsertSearchEntry(ctx, UpsertSearchEntryParams{
+		Namespace:     string(MatchTypeFile),
+		AssociatedUrl: pgtype.Text{String: file.URL(), Valid: true},
+		Created:       pgtype.Timestamptz{Time: file.Created, Valid: true},
+		Updated:       pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Header:        pgtype.Text{String: file.OriginalFileName, Valid: true},
+		Body:          pgtype.Text{String: text + info.IndexableText(), Valid: true},
+		Lang:          lang.Regconfig(),
+		ExtraData:     pgtype.Text{String: string(extraData), Valid: true},
+		OwnerID:       ownerID,
+	})
+}
+
+func (server *Server) searchDeleteUploadedFile(ctx context.Context, file FileView) error {
+	return server.Queries.DeleteSearchEntry(ctx, DeleteSearchEntryParams{
+		Namespace:     string(MatchTypeFile),
+		AssociatedUrl: pgtype.Text{String: file.URL(), Valid: true},
+	})
+}
+
+var fileURLRegex = regexp.MustCompile(`^/file/(\d+)/`)
+
+// The file ID in the URL of an uploaded file.
+func parseFileURL(url string) (int32, bool) {
+	match := fileURLRegex.FindStringSubmatch(url)
+	if match == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(match[1], 10, 32)
+	return int32(id), err == nil
+}
+
+// The file or document is not one that is indexed in search.
+var errNotIndexed = errors.New("not indexed in search")
+
+// Index an uploaded file again, for example after it was skipped.
+func (server *Server) reindexUploadedFile(ctx context.Context, id int32) error {
+	file, err := server.Queries.GetFileByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errNotIndexed
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := server.Queries.GetPatientJournalArchiveByFile(ctx, id); err == nil {
+		return errNotIndexed
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	return server.searchIndexUploadedFile(ctx, file.ToFileView())
+}
+
+// Index files that were uploaded before file indexing existed, or that
+// couldn't be opened when they were indexed.
+func (server *Server) backgroundIndexFiles(ctx context.Context) {
+	server.indexFiles(ctx, false)
+}
+
+// Index uploaded files that are missing from search, or all of them if all is
+// set, including those that were skipped. Journal archives are removed from
+// search, since file entries would show their text regardless of the home of
+// the patient.
+func (server *Server) indexFiles(ctx context.Context, all bool) {
+	server.indexFilesMu.Lock()
+	defer server.indexFilesMu.Unlock()
+
+	archives, err := server.Queries.GetJournalArchiveFiles(ctx)
+	if err != nil {
+		log.Printf("error getting journal archive files: %v", err)
+		return
+	}
+	for _, file := range archives {
+		if err := server.searchDeleteUploadedFile(ctx, file.ToFileView()); err != nil {
+			log.Printf("ERROR (%s): removing journal archive from search: %v", file.Filename, err)
+		}
+	}
+
+	files, err := server.getFilesToIndex(ctx, all)
+	if err != nil {
+		log.Printf("error getting files to index: %v", err)
+		return
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	log.Printf("START: indexing %d files", len(files))
+	for _, file := range files {
+		if err := server.searchIndexUploadedFile(ctx, file); err != nil {
+			log.Printf("ERROR (%s): %v", file.OriginalFileName, err)
+		}
+	}
+	log.Printf("DONE: indexing files")
+}
+
+func (server *Server) getFilesToIndex(ctx context.Context, all bool) ([]FileView, error) {
+	files, err := server.Queries.GetUploadedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		var views []FileView
+		for _, file := range files {
+			views = append(views, file.ToFileView())
+		}
+		return views, nil
+	}
+	urls, err := server.Queries.GetSearchURLs(ctx, string(MatchTypeFile))
+	if err != nil {
+		return nil, err
+	}
+	indexed := make(map[string]struct{}, len(urls))
+	for _, url := range urls {
+		indexed[url] = struct{}{}
+	}
+	var missing []FileView
+	for _, file := range files {
+		view := file.ToFileView()
+		if _, ok := indexed[view.URL()]; !ok {
+			missing = append(missing, view)
+		}
+	}
+	return missing, nil
+}
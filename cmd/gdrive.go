@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"regexp"
 	"slices"
+	"strings"
 	"time"
 
 	"google.golang.org/api/docs/v1"
@@ -24,6 +27,20 @@ type GDrive struct {
 	Docs      *docs.Service
 	Queries   *Queries
 	DriveBase string
+	// The email of the service account, which must keep its access to the folders
+	ServiceAccountEmail string
+}
+
+// The parts of Google Drive used to keep the search index in sync. Implemented
+// by GDrive, and can be faked to exercise the sync logic without Drive.
+type GDriveClient interface {
+	GetFile(id string) (GDriveItem, error)
+	ReadDocument(id string) (GDriveJournal, error)
+	ListFiles(params ListFilesParams) (ListFilesResult, error)
+	ListFolders(parent string) ([]GDriveItem, error)
+	GetStartPageToken() (string, error)
+	ListChanges(pageToken string) (GDriveChanges, error)
+	ListRevisions(id string) ([]GDriveRevision, error)
 }
 
 type GDriveConfig struct {
@@ -32,6 +49,22 @@ type GDriveConfig struct {
 	JournalFolder             string
 	TemplateFile              string
 	ExtraJournalFolders       []string
+	// Language of the journals in a folder, by folder ID. Folders not listed
+	// here have the language detected per document.
+	FolderLanguages map[string]LanguageID
+	// Journals of checked out patients are moved here. Left in place if empty.
+	ArchiveFolder string
+	// Put archived journals in a folder per checkout year inside ArchiveFolder
+	ArchivePerYear bool
+	// Name of the journal once the patient is checked out. Defaults to the
+	// template name followed by the outcome and checkout date.
+	ArchivedNameTemplate string
+	// Apply the permission reconciliation every day, instead of only when an
+	// admin applies it from /gdrive/permissions
+	ReconcilePermissions bool
+	// Emails that keep their access to the journal folders even if they are not
+	// bino users
+	KeepPermissions []string
 }
 
 func NewGDriveWithServiceAccount(ctx context.Context, config GDriveConfig, queries *Queries) (*GDrive, error) {
@@ -45,14 +78,38 @@ func NewGDriveWithServiceAccount(ctx context.Context, config GDriveConfig, queri
 		return nil, fmt.Errorf("creating Docs service: %w", err)
 	}
 
+	email, err := serviceAccountEmail(config.ServiceAccountKeyLocation)
+	if err != nil {
+		return nil, err
+	}
+
 	return &GDrive{
-		Drive:     drive,
-		Docs:      docs,
-		Queries:   queries,
-		DriveBase: config.DriveBase,
+		Drive:               drive,
+		Docs:                docs,
+		Queries:             queries,
+		DriveBase:           config.DriveBase,
+		ServiceAccountEmail: email,
 	}, nil
 }
 
+// Read the email of the service account from its key file.
+func serviceAccountEmail(keyLocation string) (string, error) {
+	raw, err := os.ReadFile(keyLocation)
+	if err != nil {
+		return "", fmt.Errorf("reading service account key: %w", err)
+	}
+	var key struct {
+		ClientEmail string `json:"client_email"`
+	}
+	if err := json.Unmarshal(raw, &key); err != nil {
+		return "", fmt.Errorf("corrupted service account key: %w", err)
+	}
+	if key.ClientEmail == "" {
+		return "", errors.New("service account key has no client_email")
+	}
+	return key.ClientEmail, nil
+}
+
 type GDriveItem struct {
 	ID    string
 	Name  string
@@ -63,6 +120,7 @@ type GDriveItem struct {
 	ModifiedTime time.Time
 	Trashed      bool
 	CreatedTime  time.Time
+	Parents      []string
 }
 
 func GDriveFolderURL(id string) string {
@@ -73,8 +131,12 @@ func (item *GDriveItem) FolderURL() string {
 	return GDriveFolderURL(item.ID)
 }
 
+func GDriveDocumentURL(id string) string {
+	return "https://docs.google.com/document/d/" + id
+}
+
 func (item *GDriveItem) DocumentURL() string {
-	return "https://docs.google.com/document/d/" + item.ID
+	return GDriveDocumentURL(item.ID)
 }
 
 func (server *Server) LoggedInUserCanShare(ctx context.Context, item GDriveItem) bool {
@@ -96,10 +158,17 @@ func GDriveItemFromFile(f *drive.File, p *drive.PermissionList) GDriveItem {
 	var permissions []GDrivePermission
 	if p != nil {
 		permissions = SliceToSlice(p.Permissions, func(p *drive.Permission) GDrivePermission {
+			inherited := false
+			for _, details := range p.PermissionDetails {
+				inherited = inherited || details.Inherited
+			}
 			return GDrivePermission{
+				ID:          p.Id,
+				Type:        p.Type,
 				DisplayName: p.DisplayName,
 				Email:       p.EmailAddress,
 				Role:        p.Role,
+				Inherited:   inherited,
 			}
 		})
 	}
@@ -115,13 +184,18 @@ func GDriveItemFromFile(f *drive.File, p *drive.PermissionList) GDriveItem {
 		ModifiedTime: modifiedTime,
 		CreatedTime:  createdTime,
 		Trashed:      f.Trashed,
+		Parents:      f.Parents,
 	}
 }
 
 type GDrivePermission struct {
+	ID          string
+	Type        string
 	DisplayName string
 	Email       string
 	Role        string
+	// Given on a parent folder or the shared drive, and can't be removed here
+	Inherited bool
 }
 
 func (gdp GDrivePermission) CanWrite() bool {
@@ -129,7 +203,7 @@ func (gdp GDrivePermission) CanWrite() bool {
 }
 
 func (g *GDrive) fileToItem(file *drive.File) (GDriveItem, error) {
-	call := g.Drive.Permissions.List(file.Id).Fields("permissions(displayName, emailAddress, role)")
+	call := g.Drive.Permissions.List(file.Id).Fields("permissions(id, type, displayName, emailAddress, role, permissionDetails(inherited))")
 
 	if g.DriveBase != "" {
 		call = call.
@@ -147,7 +221,7 @@ func (g *GDrive) fileToItem(file *drive.File) (GDriveItem, error) {
 
 func (g *GDrive) GetFile(id string) (GDriveItem, error) {
 	call := g.Drive.Files.Get(id).
-		Fields("id, name, capabilities")
+		Fields("id, name, parents, modifiedTime, createdTime, trashed, capabilities")
 
 	if g.DriveBase != "" {
 		call = call.
@@ -188,11 +262,51 @@ func (g *GDrive) ReadDocument(id string) (GDriveJournal, error) {
 	}, nil
 }
 
-func (g *GDrive) CreateDocument(conf GDriveConfigInfo, vars GDriveTemplateVars) (GDriveItem, error) {
-	call := g.Drive.Files.Copy(conf.TemplateDoc.Item.ID, &drive.File{
-		Name:    vars.ApplyToString(conf.TemplateDoc.Item.Name),
-		Parents: []string{conf.JournalFolder.ID},
-	})
+// Export a Google Docs file in another format, such as PDF.
+func (g *GDrive) ExportFile(id, mimeType string) ([]byte, error) {
+	f, err := g.Drive.Files.Export(id, mimeType).Download()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Body.Close()
+	return io.ReadAll(f.Body)
+}
+
+// Keys in the appProperties of documents created by bino
+const (
+	gdriveAppPropertyKey = "binoKey"
+	// Set once the template variables have been filled in
+	gdriveAppPropertyFilled = "binoFilled"
+)
+
+// Copy the template into the folder and fill in the variables. If key is set,
+// it's stored on the copy, and a finished copy with the same key is returned
+// instead of making another one, so that retries don't make duplicates.
+func (g *GDrive) CreateDocument(template GDriveItem, folder string, vars GDriveTemplateVars, key string) (GDriveItem, error) {
+	if key != "" {
+		existing, err := g.findByAppProperty(gdriveAppPropertyKey, key)
+		if err != nil {
+			return GDriveItem{}, fmt.Errorf("looking for earlier copy: %w", err)
+		}
+		for _, f := range existing {
+			if f.AppProperties[gdriveAppPropertyFilled] == "true" {
+				return g.fileToItem(f)
+			}
+			// Copied, but the variables may not have been filled in
+			if err := g.deleteFile(f.Id); err != nil {
+				return GDriveItem{}, fmt.Errorf("deleting unfinished copy: %w", err)
+			}
+		}
+	}
+
+	file := &drive.File{
+		Name:    vars.ApplyToString(template.Name),
+		Parents: []string{folder},
+	}
+	if key != "" {
+		file.AppProperties = map[string]string{gdriveAppPropertyKey: key}
+	}
+	call := g.Drive.Files.Copy(template.ID, file)
 
 	if g.DriveBase != "" {
 		call = call.
@@ -207,20 +321,368 @@ func (g *GDrive) CreateDocument(conf GDriveConfigInfo, vars GDriveTemplateVars)
 	updateCall := g.Docs.Documents.BatchUpdate(f.Id, vars.ReplaceRequests())
 	_, err = updateCall.Do()
 	if err != nil {
-		deleteCall := g.Drive.Files.Delete(f.Id)
+		if deleteErr := g.deleteFile(f.Id); deleteErr != nil {
+			return GDriveItem{}, errors.Join(err, deleteErr)
+		}
+		return GDriveItem{}, err
+	}
 
+	if key != "" {
+		markCall := g.Drive.Files.Update(f.Id, &drive.File{
+			AppProperties: map[string]string{gdriveAppPropertyFilled: "true"},
+		})
 		if g.DriveBase != "" {
-			deleteCall = deleteCall.
+			markCall = markCall.
 				SupportsAllDrives(true)
 		}
-
-		deleteErr := deleteCall.Do()
-		if deleteErr != nil {
-			return GDriveItem{}, errors.Join(err, deleteErr)
+		if _, err := markCall.Do(); err != nil {
+			return GDriveItem{}, fmt.Errorf("marking copy as finished: %w", err)
 		}
+	}
+
+	return g.fileToItem(f)
+}
+
+func (g *GDrive) deleteFile(id string) error {
+	call := g.Drive.Files.Delete(id)
+
+	if g.DriveBase != "" {
+		call = call.
+			SupportsAllDrives(true)
+	}
+
+	return call.Do()
+}
+
+// Files that aren't trashed with the given appProperties value.
+func (g *GDrive) findByAppProperty(key, value string) ([]*drive.File, error) {
+	call := g.Drive.Files.List()
+
+	if g.DriveBase != "" {
+		call = call.
+			SupportsAllDrives(true)
+		call = call.DriveId(g.DriveBase)
+		call = call.IncludeItemsFromAllDrives(true)
+		call = call.Corpora("drive")
+	}
+
+	call = call.Q(fmt.Sprintf(
+		"appProperties has { key='%s' and value='%s' } and trashed = false",
+		key, strings.ReplaceAll(value, "'", `\'`),
+	))
+	call = call.Fields("files(id, name, parents, modifiedTime, createdTime, trashed, appProperties)")
+
+	fileList, err := call.Do()
+	if err != nil {
+		return nil, err
+	}
+	return fileList.Files, nil
+}
+
+type UpdateFileParams struct {
+	ID   string
+	Name string
+	// Folder to move the file to. The file stays where it is if empty.
+	Parent string
+}
+
+// Rename a file, and move it out of its current folders if a new parent is given.
+func (g *GDrive) UpdateFile(params UpdateFileParams) (GDriveItem, error) {
+	item, err := g.GetFile(params.ID)
+	if err != nil {
+		return GDriveItem{}, err
+	}
+
+	call := g.Drive.Files.Update(params.ID, &drive.File{
+		Name: params.Name,
+	}).Fields("id, name, parents, modifiedTime, createdTime, trashed")
+
+	if params.Parent != "" && !slices.Equal(item.Parents, []string{params.Parent}) {
+		call = call.
+			AddParents(params.Parent).
+			RemoveParents(strings.Join(FilterSlice(item.Parents, func(p string) bool { return p != params.Parent }), ","))
+	}
+
+	if g.DriveBase != "" {
+		call = call.
+			SupportsAllDrives(true)
+	}
 
+	f, err := call.Do()
+	if err != nil {
 		return GDriveItem{}, err
 	}
 
 	return g.fileToItem(f)
 }
+
+// The folder with the given name in the parent folder, which is created if it doesn't exist.
+func (g *GDrive) GetOrCreateFolder(parent, name string) (GDriveItem, error) {
+	call := g.Drive.Files.List()
+
+	if g.DriveBase != "" {
+		call = call.
+			SupportsAllDrives(true)
+		call = call.DriveId(g.DriveBase)
+		call = call.IncludeItemsFromAllDrives(true)
+		call = call.Corpora("drive")
+	}
+
+	call = call.Q(strings.Join([]string{
+		"mimeType = '" + mimeTypeGoogleFolder + "'",
+		fmt.Sprintf("'%s' in parents", parent),
+		fmt.Sprintf("name = '%s'", strings.ReplaceAll(name, "'", `\'`)),
+		"trashed = false",
+	}, " and "))
+	call = call.Fields("files(id, name, parents, modifiedTime, createdTime, trashed)")
+
+	fileList, err := call.Do()
+	if err != nil {
+		return GDriveItem{}, err
+	}
+	if len(fileList.Files) > 0 {
+		return GDriveItemFromFile(fileList.Files[0], nil), nil
+	}
+
+	createCall := g.Drive.Files.Create(&drive.File{
+		Name:     name,
+		MimeType: mimeTypeGoogleFolder,
+		Parents:  []string{parent},
+	}).Fields("id, name, parents, modifiedTime, createdTime, trashed")
+
+	if g.DriveBase != "" {
+		createCall = createCall.
+			SupportsAllDrives(true)
+	}
+
+	f, err := createCall.Do()
+	if err != nil {
+		return GDriveItem{}, err
+	}
+
+	return GDriveItemFromFile(f, nil), nil
+}
+
+type ListFilesParams struct {
+	Parent         string
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+	PageToken      string
+}
+
+type ListFilesResult struct {
+	Folder        GDriveItem
+	Files         []GDriveItem
+	NextPageToken string
+}
+
+func (g *GDrive) ListFiles(params ListFilesParams) (ListFilesResult, error) {
+	folderItem, err := g.GetFile(params.Parent)
+	if err != nil {
+		return ListFilesResult{}, err
+	}
+
+	call := g.Drive.Files.List()
+
+	if g.DriveBase != "" {
+		call = call.
+			SupportsAllDrives(true)
+		call = call.DriveId(g.DriveBase)
+		call = call.IncludeItemsFromAllDrives(true)
+		call = call.Corpora("drive")
+	}
+
+	rules := []string{
+		"mimeType = '" + mimeTypeGoogleDocument + "'",
+		fmt.Sprintf("'%s' in parents", params.Parent),
+	}
+
+	if !params.ModifiedAfter.IsZero() {
+		rules = append(rules, fmt.Sprintf("modifiedTime > '%s'", params.ModifiedAfter.Format(timeFormatDriveQ)))
+	}
+	if !params.ModifiedBefore.IsZero() {
+		rules = append(rules, fmt.Sprintf("modifiedTime < '%s'", params.ModifiedBefore.Format(timeFormatDriveQ)))
+	}
+
+	q := strings.Join(rules, " and ")
+	call = call.Q(q)
+
+	if params.PageToken != "" {
+		call = call.PageToken(params.PageToken)
+	}
+
+	call = call.OrderBy("modifiedTime desc")
+	call = call.Fields("files(id, name, modifiedTime, createdTime, trashed), nextPageToken")
+
+	fileList, err := call.Do()
+	if err != nil {
+		return ListFilesResult{}, err
+	}
+
+	return ListFilesResult{
+		Folder: folderItem,
+		Files: SliceToSlice(fileList.Files, func(in *drive.File) GDriveItem {
+			return GDriveItemFromFile(in, nil)
+		}),
+		NextPageToken: fileList.NextPageToken,
+	}, nil
+}
+
+// The folders directly in a folder, such as the year folders of the archive.
+func (g *GDrive) ListFolders(parent string) ([]GDriveItem, error) {
+	var out []GDriveItem
+	pageToken := ""
+	for {
+		call := g.Drive.Files.List()
+
+		if g.DriveBase != "" {
+			call = call.
+				SupportsAllDrives(true)
+			call = call.DriveId(g.DriveBase)
+			call = call.IncludeItemsFromAllDrives(true)
+			call = call.Corpora("drive")
+		}
+
+		call = call.Q(strings.Join([]string{
+			"mimeType = '" + mimeTypeGoogleFolder + "'",
+			fmt.Sprintf("'%s' in parents", parent),
+			"trashed = false",
+		}, " and "))
+		call = call.Fields("files(id, name, parents, modifiedTime, createdTime, trashed), nextPageToken")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		fileList, err := call.Do()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fileList.Files {
+			out = append(out, GDriveItemFromFile(f, nil))
+		}
+		if fileList.NextPageToken == "" {
+			return out, nil
+		}
+		pageToken = fileList.NextPageToken
+	}
+}
+
+const (
+	mimeTypeGoogleDocument = "application/vnd.google-apps.document"
+	mimeTypeGoogleFolder   = "application/vnd.google-apps.folder"
+	mimeTypePDF            = "application/pdf"
+)
+
+type GDriveChange struct {
+	FileID string
+	// The file was deleted or access to it was lost
+	Removed bool
+	// Empty if Removed
+	Item       GDriveItem
+	IsDocument bool
+}
+
+type GDriveChanges struct {
+	Changes []GDriveChange
+	// Set if there are more changes to fetch
+	NextPageToken string
+	// Set on the last page, to be used for the next round of changes
+	NewStartPageToken string
+}
+
+func (g *GDrive) GetStartPageToken() (string, error) {
+	call := g.Drive.Changes.GetStartPageToken()
+
+	if g.DriveBase != "" {
+		call = call.
+			SupportsAllDrives(true).
+			DriveId(g.DriveBase)
+	}
+
+	res, err := call.Do()
+	if err != nil {
+		return "", err
+	}
+	return res.StartPageToken, nil
+}
+
+func (g *GDrive) ListChanges(pageToken string) (GDriveChanges, error) {
+	call := g.Drive.Changes.List(pageToken).
+		IncludeRemoved(true).
+		Fields("nextPageToken, newStartPageToken, changes(fileId, removed, file(id, name, mimeType, parents, modifiedTime, createdTime, trashed))")
+
+	if g.DriveBase != "" {
+		call = call.
+			SupportsAllDrives(true).
+			IncludeItemsFromAllDrives(true).
+			DriveId(g.DriveBase)
+	}
+
+	res, err := call.Do()
+	if err != nil {
+		return GDriveChanges{}, err
+	}
+
+	return GDriveChanges{
+		Changes: SliceToSlice(res.Changes, func(in *drive.Change) GDriveChange {
+			change := GDriveChange{
+				FileID:  in.FileId,
+				Removed: in.Removed || in.File == nil,
+			}
+			if in.File != nil {
+				change.Item = GDriveItemFromFile(in.File, nil)
+				change.IsDocument = in.File.MimeType == mimeTypeGoogleDocument
+			}
+			return change
+		}),
+		NextPageToken:     res.NextPageToken,
+		NewStartPageToken: res.NewStartPageToken,
+	}, nil
+}
+
+// A saved version of a file and who made it.
+type GDriveRevision struct {
+	ID           string
+	ModifiedTime time.Time
+	// Display name in Google, empty if the account is anonymous
+	Name string
+	// Only set if the editor shares their email address
+	Email string
+}
+
+// All revisions of a file, oldest first. Google Docs merge edits made close
+// together into one revision, and may drop old revisions.
+func (g *GDrive) ListRevisions(id string) ([]GDriveRevision, error) {
+	var out []GDriveRevision
+	pageToken := ""
+	for {
+		call := g.Drive.Revisions.List(id).
+			PageSize(1000).
+			Fields("nextPageToken, revisions(id, modifiedTime, lastModifyingUser(displayName, emailAddress))")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		res, err := call.Do()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rev := range res.Revisions {
+			revision := GDriveRevision{ID: rev.Id}
+			if t, err := time.Parse(time.RFC3339, rev.ModifiedTime); err == nil {
+				revision.ModifiedTime = t
+			}
+			if rev.LastModifyingUser != nil {
+				revision.Name = rev.LastModifyingUser.DisplayName
+				revision.Email = rev.LastModifyingUser.EmailAddress
+			}
+			out = append(out, revision)
+		}
+
+		if res.NextPageToken == "" {
+			return out, nil
+		}
+		pageToken = res.NextPageToken
+	}
+}
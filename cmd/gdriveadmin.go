@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"maps"
 	"net/http"
@@ -11,8 +12,40 @@ func (s *Server) getGDriveHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	commonData := MustLoadCommonData(ctx)
 
-	info := s.GDriveWorker.GetGDriveConfigInfo()
-	s.GDrivePage(ctx, commonData, info).Render(ctx, w)
+	if s.GDriveWorker == nil {
+		s.renderError(w, r, commonData, errors.New(commonData.User.Language.GDriveNotInUse))
+		return
+	}
+
+	canManage := commonData.User.AccessLevel >= RequiredAccessLevel[CapManageGDriveSettings]
+
+	// Shown to those who can change them, so a broken setup can be fixed here
+	var settings []RuntimeSettingView
+	if canManage {
+		view, err := s.getRuntimeSettingsView(ctx)
+		if err != nil {
+			s.renderError(w, r, commonData, err)
+			return
+		}
+		for _, setting := range view.Settings {
+			if setting.Setting.IsGDrive() {
+				settings = append(settings, setting)
+			}
+		}
+	}
+
+	info, err := s.GDriveWorker.GetGDriveConfigInfo()
+	if err != nil {
+		_ = GDriveUnavailablePage(
+			commonData,
+			s.GDriveWorker.Health(),
+			s.GDriveWorker.Config(),
+			canManage,
+			settings,
+		).Render(ctx, w)
+		return
+	}
+	s.GDrivePage(ctx, commonData, info, settings).Render(ctx, w)
 }
 
 func (s *Server) getExtraBinoUsers(ctx context.Context, selectedDir GDriveItem) map[string]UserView {
@@ -41,7 +74,7 @@ func (s *Server) gdriveInviteUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := s.GDriveWorker.InviteUser(s.Config.GoogleDrive.JournalFolder, email, "writer"); err != nil {
+	if err := s.Journals.Share(ctx, email); err != nil {
 		s.renderError(w, r, commonData, fmt.Errorf("inviting user: %w", err))
 		return
 	}
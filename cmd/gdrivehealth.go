@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	gdriveHealthCheckInterval = time.Hour
+	// Retries after a failed check back off from the min to the max interval
+	gdriveHealthRetryMinInterval = 30 * time.Second
+	gdriveHealthRetryMaxInterval = 10 * time.Minute
+	// Transient errors keep the last good config info until they've happened
+	// this many times in a row
+	gdriveHealthMaxFailures = 3
+)
+
+var errGDriveUnavailable = errors.New("Google Drive is unavailable")
+
+// Whether the configured journal folder and template could be fetched.
+type GDriveHealth struct {
+	// Nil if Drive works, or hasn't been checked yet
+	Err     error
+	Checked time.Time
+}
+
+func (h GDriveHealth) OK() bool {
+	return h.Err == nil
+}
+
+func (w *GDriveWorker) Health() GDriveHealth {
+	w.cachedInfoMu.Lock()
+	defer w.cachedInfoMu.Unlock()
+	return GDriveHealth{
+		Err:     w.infoErr,
+		Checked: w.infoChecked,
+	}
+}
+
+// Fetch the config info from Drive and replace the cached info. If it fails
+// because of the config, or keeps failing, Drive features are disabled until
+// the next successful refresh. Until then the last good info is kept.
+func (w *GDriveWorker) RefreshConfigInfo() (GDriveConfigInfo, error) {
+	for {
+		cfg, generation := w.configAndGeneration()
+		info, err := w.fetchConfigInfo(cfg)
+		if info, stored, err := w.storeConfigInfo(generation, info, err); stored {
+			return info, err
+		}
+		// The config was replaced while fetching, so fetch again for the new one
+	}
+}
+
+// Cache the result of fetching the config info for the config of the given
+// generation. Returns false without caching it if the config has been replaced.
+func (w *GDriveWorker) storeConfigInfo(generation uint64, info GDriveConfigInfo, err error) (GDriveConfigInfo, bool, error) {
+	w.cachedInfoMu.Lock()
+	defer w.cachedInfoMu.Unlock()
+	if _, current := w.configAndGeneration(); current != generation {
+		return GDriveConfigInfo{}, false, nil
+	}
+	w.infoChecked = time.Now()
+	if err != nil {
+		w.infoFailures++
+		if retry, _ := gdriveTaskShouldRetry(err); retry && w.cachedInfo != nil && w.infoFailures < gdriveHealthMaxFailures {
+			log.Printf("ERROR: fetching GDrive config info failed %d time(s), keeping the last info: %v", w.infoFailures, err)
+			return *w.cachedInfo, true, err
+		}
+		w.infoErr = err
+		w.cachedInfo = nil
+		return GDriveConfigInfo{}, true, err
+	}
+	w.infoErr = nil
+	w.infoFailures = 0
+	w.cachedInfo = &info
+	log.Printf("Fetched GDrive Config info")
+	return info, true, nil
+}
+
+// Fetch the config info for cfg. Calls Drive directly rather than through
+// Exec, which refuses requests while Drive is unavailable.
+func (w *GDriveWorker) fetchConfigInfo(cfg GDriveConfig) (GDriveConfigInfo, error) {
+	var info GDriveConfigInfo
+
+	item, err := w.g.GetFile(cfg.JournalFolder)
+	if err != nil {
+		return info, fmt.Errorf("getting journal folder %s: %w", cfg.JournalFolder, err)
+	}
+	info.JournalFolder = item
+
+	doc, err := w.g.ReadDocument(cfg.TemplateFile)
+	if err != nil {
+		return info, fmt.Errorf("reading template %s: %w", cfg.TemplateFile, err)
+	}
+	if err := doc.Validate(); err != nil {
+		return info, fmt.Errorf("%w: template %s: %w", errGDriveTaskPermanent, cfg.TemplateFile, err)
+	}
+	info.TemplateDoc = doc
+
+	for _, id := range cfg.ExtraJournalFolders {
+		folder, err := w.g.GetFile(id)
+		if err != nil {
+			log.Printf("error getting extra folder %s: %v", id, err)
+			continue
+		}
+		info.ExtraFolders = append(info.ExtraFolders, folder)
+	}
+
+	return info, nil
+}
+
+// Check that Drive config can be used before it's applied. Unlike when
+// fetching the config info, missing extra folders and archive folder are errors.
+func (w *GDriveWorker) ValidateConfig(cfg GDriveConfig) error {
+	if _, err := w.fetchConfigInfo(cfg); err != nil {
+		return err
+	}
+	for _, id := range cfg.ExtraJournalFolders {
+		if _, err := w.g.GetFile(id); err != nil {
+			return fmt.Errorf("getting extra folder %s: %w", id, err)
+		}
+	}
+	if cfg.ArchiveFolder != "" {
+		if _, err := w.g.GetFile(cfg.ArchiveFolder); err != nil {
+			return fmt.Errorf("getting archive folder %s: %w", cfg.ArchiveFolder, err)
+		}
+	}
+	return nil
+}
+
+// Replace the Drive config, and fetch the config info for it.
+func (w *GDriveWorker) SetConfig(ctx context.Context, cfg GDriveConfig) error {
+	w.cfgMu.Lock()
+	old := w.cfg
+	w.cfg = cfg
+	w.cfgGeneration++
+	w.cfgMu.Unlock()
+
+	// The info for the old config is no use even if the refresh fails. A
+	// refresh for the old config that is still running sees the new generation
+	// and doesn't cache its result.
+	w.cachedInfoMu.Lock()
+	w.cachedInfo = nil
+	w.infoFailures = 0
+	w.cachedInfoMu.Unlock()
+
+	// Documents already in a new folder don't show up in the changes feed, so
+	// the next round of indexing goes through all the folders
+	if old.JournalFolder != cfg.JournalFolder || !slices.Equal(old.ExtraJournalFolders, cfg.ExtraJournalFolders) {
+		if err := w.queries.ResetSearchSyncPageToken(ctx, cfg.DriveBase); err != nil {
+			log.Printf("ERROR: resetting search sync page token: %v", err)
+		}
+	}
+
+	_, err := w.RefreshConfigInfo()
+	return err
+}
+
+// Check the config at startup and every gdriveHealthCheckInterval, and keep
+// retrying while it fails.
+func (w *GDriveWorker) configHealthWorker(ctx context.Context) {
+	retry := gdriveHealthRetryMinInterval
+	for {
+		delay := gdriveHealthCheckInterval
+		if _, err := w.RefreshConfigInfo(); err != nil {
+			log.Printf("ERROR: checking Google Drive failed, checking again in %s: %v", retry, err)
+			delay = retry
+			retry = min(retry*2, gdriveHealthRetryMaxInterval)
+		} else {
+			retry = gdriveHealthRetryMinInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (server *Server) postGDriveRecheckHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	if server.GDriveWorker == nil {
+		server.renderError(w, r, commonData, errors.New(commonData.User.Language.GDriveNotInUse))
+		return
+	}
+
+	if _, err := server.GDriveWorker.RefreshConfigInfo(); err != nil {
+		commonData.Error(commonData.User.Language.GDriveStillUnavailable, err)
+	} else {
+		commonData.Success(commonData.User.Language.GDriveAvailableAgain)
+	}
+
+	server.redirect(w, r, "/gdrive")
+}
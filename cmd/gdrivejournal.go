@@ -4,6 +4,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -18,30 +19,103 @@ import (
 //	Name,
 //	Species,
 //	BinoURL,
+//	Outcome,
+//	CheckoutDate,
+//	Home,
+//	Responsible,
+//	ScientificName,
+//	CaseNumber,
+//	Finder,
+//	IntakeCause,
+//	CheckinTime,
 //
 // )
 type Template string
 
+// Written in place of variables with no value, unless they fall back to another variable
+const templateMissingValue = "-"
+
+// Longer variables are replaced first, so that variables containing other
+// variables (like {{ScientificName}} and Name) are replaced whole.
+var templatesLongestFirst = func() []Template {
+	out := TemplateValues()
+	slices.SortStableFunc(out, func(a, b Template) int {
+		return len(b.Placeholder()) - len(a.Placeholder())
+	})
+	return out
+}()
+
+// The text in a template that is replaced with the value of the variable.
+// Existing templates write the first variables as they are, but the later
+// ones, like Home and Finder, are words that are also used as text in
+// journals, so they are written in braces, like {{Home}}.
+func (t Template) Placeholder() string {
+	switch t {
+	case TemplateYYYY, TemplateMM, TemplateDD, TemplateName, TemplateSpecies, TemplateBinoURL, TemplateOutcome, TemplateCheckoutDate:
+		return t.String()
+	default:
+		return "{{" + t.String() + "}}"
+	}
+}
+
 type GDriveJournal struct {
 	Item    GDriveItem
 	Content string
 }
 
+// Values for the template variables. Build them with patientJournalVars, so
+// that all journal backends see the same values.
 type GDriveTemplateVars struct {
+	// Check-in time
 	Time    time.Time
 	Name    string
 	Species string
 	BinoURL string
+	// Only known once the patient is checked out
+	Outcome  string
+	Checkout time.Time
+	// Current home, and the users in it
+	Home           string
+	Responsible    string
+	ScientificName string
+	CaseNumber     string
+	Finder         string
+	IntakeCause    string
 }
 
+// Whether the variable has a value when the journal is created. Only these are
+// replaced in the document body.
+func (t Template) AvailableAtCreation() bool {
+	return t != TemplateOutcome && t != TemplateCheckoutDate
+}
+
+// Replace the variables in one pass, so that values aren't searched for
+// variables in turn.
 func (vars *GDriveTemplateVars) ApplyToString(s string) string {
-	for _, t := range TemplateValues() {
-		s = strings.ReplaceAll(s, t.String(), vars.Replacement(t))
+	var oldnew []string
+	for _, t := range templatesLongestFirst {
+		oldnew = append(oldnew, t.Placeholder(), vars.Replacement(t))
 	}
-	return s
+	return strings.NewReplacer(oldnew...).Replace(s)
 }
 
+// The value of the variable, or its fallback if it has none.
 func (vars *GDriveTemplateVars) Replacement(template Template) string {
+	if value := vars.value(template); value != "" {
+		return value
+	}
+	switch template {
+	case TemplateScientificName:
+		return vars.Species
+	case TemplateHome, TemplateResponsible, TemplateFinder, TemplateIntakeCause, TemplateCaseNumber:
+		return templateMissingValue
+	default:
+		// Outcome and CheckoutDate are empty until checkout, and the rest are always set
+		return ""
+	}
+}
+
+func (vars *GDriveTemplateVars) value(template Template) string {
 	switch template {
 	case TemplateYYYY:
 		return fmt.Sprintf("%d", vars.Time.Year())
@@ -55,33 +129,83 @@ func (vars *GDriveTemplateVars) Replacement(template Template) string {
 		return vars.Species
 	case TemplateBinoURL:
 		return vars.BinoURL
+	case TemplateOutcome:
+		return vars.Outcome
+	case TemplateCheckoutDate:
+		if vars.Checkout.IsZero() {
+			return ""
+		}
+		return vars.Checkout.Format(time.DateOnly)
+	case TemplateHome:
+		return vars.Home
+	case TemplateResponsible:
+		return vars.Responsible
+	case TemplateScientificName:
+		return vars.ScientificName
+	case TemplateCaseNumber:
+		return vars.CaseNumber
+	case TemplateFinder:
+		return vars.Finder
+	case TemplateIntakeCause:
+		return vars.IntakeCause
+	case TemplateCheckinTime:
+		return vars.Time.Format("15:04")
 	default:
 		return template.String()
 	}
 }
 
+// The requests run one after another, so the variables are first replaced
+// with markers that can't be part of any variable, and then the markers with
+// the values. Otherwise a value like "Name withheld" would have Name replaced
+// by a later request.
 func (vars *GDriveTemplateVars) ReplaceRequests() *docs.BatchUpdateDocumentRequest {
-	return &docs.BatchUpdateDocumentRequest{
-		Requests: SliceToSlice(TemplateValues(), func(t Template) *docs.Request {
-			return &docs.Request{
-				ReplaceAllText: &docs.ReplaceAllTextRequest{
-					ContainsText: &docs.SubstringMatchCriteria{
-						MatchCase: true,
-						Text:      t.String(),
-					},
-					ReplaceText: vars.Replacement(t),
+	replace := func(old, new string) *docs.Request {
+		return &docs.Request{
+			ReplaceAllText: &docs.ReplaceAllTextRequest{
+				ContainsText: &docs.SubstringMatchCriteria{
+					MatchCase: true,
+					Text:      old,
 				},
-			}
-		}),
+				ReplaceText: new,
+			},
+		}
+	}
+	templates := FilterSlice(templatesLongestFirst, Template.AvailableAtCreation)
+	var requests []*docs.Request
+	for i, t := range templates {
+		requests = append(requests, replace(t.Placeholder(), templateMarker(i)))
+	}
+	for i, t := range templates {
+		requests = append(requests, replace(templateMarker(i), vars.Replacement(t)))
 	}
+	return &docs.BatchUpdateDocumentRequest{Requests: requests}
 }
 
+// Stands in for a variable while the variables are replaced. Uses characters
+// from the Unicode private use area, which aren't found in journals.
+func templateMarker(i int) string {
+	return fmt.Sprintf("\uE000%d\uE001", i)
+}
+
+// The template variables used in the name or content of the template. All of
+// them are optional.
+func (gdj *GDriveJournal) Variables() []Template {
+	return FilterSlice(TemplateValues(), func(t Template) bool {
+		return t.AvailableAtCreation() && (strings.Contains(gdj.Item.Name, t.Placeholder()) || strings.Contains(gdj.Content, t.Placeholder()))
+	})
+}
+
+// A template must use at least one variable in its name, or every journal
+// created from it gets the same name.
 func (gdj *GDriveJournal) Validate() error {
-	errs := []error{}
-	for _, k := range TemplateValues() {
-		if !strings.Contains(gdj.Content, k.String()) {
-			errs = append(errs, fmt.Errorf("template is missing variable '%s'", k))
+	if !gdj.Item.Valid {
+		return errors.New("template could not be read")
+	}
+	for _, t := range TemplateValues() {
+		if t.AvailableAtCreation() && strings.Contains(gdj.Item.Name, t.Placeholder()) {
+			return nil
 		}
 	}
-	return errors.Join(errs...)
+	return fmt.Errorf("the name of the template '%s' uses none of the variables %v", gdj.Item.Name, SliceToSlice(FilterSlice(TemplateValues(), Template.AvailableAtCreation), Template.Placeholder))
 }
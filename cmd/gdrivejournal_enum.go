@@ -23,6 +23,24 @@ const (
 	TemplateSpecies Template = "Species"
 	// TemplateBinoURL is a Template of type BinoURL.
 	TemplateBinoURL Template = "BinoURL"
+	// TemplateOutcome is a Template of type Outcome.
+	TemplateOutcome Template = "Outcome"
+	// TemplateCheckoutDate is a Template of type CheckoutDate.
+	TemplateCheckoutDate Template = "CheckoutDate"
+	// TemplateHome is a Template of type Home.
+	TemplateHome Template = "Home"
+	// TemplateResponsible is a Template of type Responsible.
+	TemplateResponsible Template = "Responsible"
+	// TemplateScientificName is a Template of type ScientificName.
+	TemplateScientificName Template = "ScientificName"
+	// TemplateCaseNumber is a Template of type CaseNumber.
+	TemplateCaseNumber Template = "CaseNumber"
+	// TemplateFinder is a Template of type Finder.
+	TemplateFinder Template = "Finder"
+	// TemplateIntakeCause is a Template of type IntakeCause.
+	TemplateIntakeCause Template = "IntakeCause"
+	// TemplateCheckinTime is a Template of type CheckinTime.
+	TemplateCheckinTime Template = "CheckinTime"
 )
 
 var ErrInvalidTemplate = errors.New("not a valid Template")
@@ -36,6 +54,15 @@ func TemplateValues() []Template {
 		TemplateName,
 		TemplateSpecies,
 		TemplateBinoURL,
+		TemplateOutcome,
+		TemplateCheckoutDate,
+		TemplateHome,
+		TemplateResponsible,
+		TemplateScientificName,
+		TemplateCaseNumber,
+		TemplateFinder,
+		TemplateIntakeCause,
+		TemplateCheckinTime,
 	}
 }
 
@@ -52,12 +79,21 @@ func (x Template) IsValid() bool {
 }
 
 var _TemplateValue = map[string]Template{
-	"YYYY":    TemplateYYYY,
-	"MM":      TemplateMM,
-	"DD":      TemplateDD,
-	"Name":    TemplateName,
-	"Species": TemplateSpecies,
-	"BinoURL": TemplateBinoURL,
+	"YYYY":           TemplateYYYY,
+	"MM":             TemplateMM,
+	"DD":             TemplateDD,
+	"Name":           TemplateName,
+	"Species":        TemplateSpecies,
+	"BinoURL":        TemplateBinoURL,
+	"Outcome":        TemplateOutcome,
+	"CheckoutDate":   TemplateCheckoutDate,
+	"Home":           TemplateHome,
+	"Responsible":    TemplateResponsible,
+	"ScientificName": TemplateScientificName,
+	"CaseNumber":     TemplateCaseNumber,
+	"Finder":         TemplateFinder,
+	"IntakeCause":    TemplateIntakeCause,
+	"CheckinTime":    TemplateCheckinTime,
 }
 
 // ParseTemplate attempts to convert a string to a Template.
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateReplacement(t *testing.T) {
+	vars := &GDriveTemplateVars{
+		Time:           time.Date(2025, 1, 2, 8, 30, 0, 0, time.UTC),
+		Name:           "Kråka",
+		Species:        "Kråke",
+		ScientificName: "Corvus cornix",
+		Finder:         "Name withheld, found MM",
+		Home:           "Species home",
+	}
+	for in, want := range map[string]string{
+		"YYYY-MM-DD Name (Species)":          "2025-01-02 Kråka (Kråke)",
+		"{{ScientificName}}":                 "Corvus cornix",
+		"Finder: {{Finder}}":                 "Finder: Name withheld, found MM",
+		"{{Home}} Home {{Responsible}}":      "Species home Home -",
+		"Name {{Finder}} Name":               "Kråka Name withheld, found MM Kråka",
+		"{{IntakeCause}}, checked in at MM.": "-, checked in at 01.",
+	} {
+		if got := vars.ApplyToString(in); got != want {
+			t.Errorf("ApplyToString(%q) = %q, want %q", in, got, want)
+		}
+
+		// Google Docs runs the requests one after another
+		got := in
+		for _, req := range vars.ReplaceRequests().Requests {
+			got = strings.ReplaceAll(got, req.ReplaceAllText.ContainsText.Text, req.ReplaceAllText.ReplaceText)
+		}
+		if got != want {
+			t.Errorf("ReplaceRequests on %q = %q, want %q", in, got, want)
+		}
+	}
+}
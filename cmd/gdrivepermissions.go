@@ -0,0 +1,235 @@
+//go:generate go tool go-enum --no-iota --values
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ENUM(Grant = 0, Revoke)
+type GDrivePermissionAction int32
+
+const gdrivePermissionReconcileInterval = 24 * time.Hour
+
+// Roles that reconciliation leaves alone, so that nobody loses control of the folders
+var gdrivePermissionProtectedRoles = []string{"owner", "organizer", "fileOrganizer"}
+
+// A change that brings the permissions of a journal folder in line with bino users.
+type GDrivePermissionChange struct {
+	Folder GDriveItem
+	Action GDrivePermissionAction
+	Email  string
+	// The role to grant, or the role being revoked
+	Role string
+	// Set when revoking
+	PermissionID string
+	// Zero if the email doesn't belong to a bino user
+	User UserView
+}
+
+// Compare the permissions of the folders with the bino users. Users with at
+// least rehabber access should be able to write, and everyone else should have
+// no access, apart from the emails in keep and the protected roles.
+func gdrivePermissionDiff(folders []GDriveItem, users []UserView, keep []string) []GDrivePermissionChange {
+	wanted := map[string]UserView{}
+	known := map[string]UserView{}
+	for _, user := range users {
+		email := strings.ToLower(user.Email)
+		if email == "" {
+			continue
+		}
+		known[email] = user
+		if user.AccessLevel >= AccessLevelRehabber {
+			wanted[email] = user
+		}
+	}
+	keep = SliceToSlice(keep, strings.ToLower)
+
+	var out []GDrivePermissionChange
+	for _, folder := range folders {
+		canWrite := map[string]bool{}
+		for _, perm := range folder.Permissions {
+			email := strings.ToLower(perm.Email)
+			if perm.CanWrite() {
+				canWrite[email] = true
+			}
+			if _, ok := wanted[email]; ok ||
+				perm.Type != "user" ||
+				perm.Inherited ||
+				slices.Contains(gdrivePermissionProtectedRoles, perm.Role) ||
+				slices.Contains(keep, email) {
+				continue
+			}
+			out = append(out, GDrivePermissionChange{
+				Folder:       folder,
+				Action:       GDrivePermissionActionRevoke,
+				Email:        perm.Email,
+				Role:         perm.Role,
+				PermissionID: perm.ID,
+				User:         known[email],
+			})
+		}
+
+		for email, user := range wanted {
+			if canWrite[email] {
+				continue
+			}
+			out = append(out, GDrivePermissionChange{
+				Folder: folder,
+				Action: GDrivePermissionActionGrant,
+				Email:  user.Email,
+				Role:   "writer",
+				User:   user,
+			})
+		}
+	}
+
+	slices.SortStableFunc(out, func(a, b GDrivePermissionChange) int {
+		if c := strings.Compare(a.Folder.Name, b.Folder.Name); c != 0 {
+			return c
+		}
+		if a.Action != b.Action {
+			return int(a.Action - b.Action)
+		}
+		return strings.Compare(a.Email, b.Email)
+	})
+	return out
+}
+
+// The changes needed to bring the journal folders in line with bino users,
+// based on the current permissions in Drive.
+func (w *GDriveWorker) PermissionDiff(ctx context.Context) ([]GDrivePermissionChange, error) {
+	ids := append([]string{w.Config().JournalFolder}, w.Config().ExtraJournalFolders...)
+	folders := make([]GDriveItem, 0, len(ids))
+	for _, id := range ids {
+		folder, err := w.GetFile(id)
+		if err != nil {
+			return nil, fmt.Errorf("getting folder %s: %w", id, err)
+		}
+		folders = append(folders, folder)
+	}
+
+	users, err := w.queries.GetAppusers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting users: %w", err)
+	}
+
+	// bino would lock itself out of the folders if the service account lost access
+	keep := append(slices.Clone(w.Config().KeepPermissions), w.g.ServiceAccountEmail)
+
+	return gdrivePermissionDiff(folders, SliceToSlice(users, GetAppusersRow.ToUserView), keep), nil
+}
+
+// Queue the changes in the task queue. Returns the number of changes queued.
+func (w *GDriveWorker) ApplyPermissionDiff(ctx context.Context, changes []GDrivePermissionChange) (int, error) {
+	var errs []error
+	n := 0
+	for _, change := range changes {
+		var err error
+		switch change.Action {
+		case GDrivePermissionActionGrant:
+			_, err = w.EnqueueInviteUser(ctx, change.Folder.ID, change.Email, change.Role)
+		case GDrivePermissionActionRevoke:
+			_, err = w.EnqueueRevokePermission(ctx, change.Folder.ID, change.PermissionID)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s on %s: %w", change.Action, change.Email, change.Folder.Name, err))
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
+
+// Queue removing the access of a single email, such as a user who was just deleted.
+func (w *GDriveWorker) RevokeRemovedUser(ctx context.Context, email string) error {
+	changes, err := w.PermissionDiff(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = w.ApplyPermissionDiff(ctx, FilterSlice(changes, func(change GDrivePermissionChange) bool {
+		return change.Action == GDrivePermissionActionRevoke && strings.EqualFold(change.Email, email)
+	}))
+	return err
+}
+
+func (w *GDriveWorker) permissionReconcileWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(gdrivePermissionReconcileInterval):
+		}
+		if !w.Health().OK() {
+			log.Printf("Skipping Drive permission check, Google Drive is unavailable")
+			continue
+		}
+
+		changes, err := w.PermissionDiff(ctx)
+		if err != nil {
+			log.Printf("ERROR: comparing Drive permissions with bino users: %v", err)
+			continue
+		}
+		if !w.Config().ReconcilePermissions {
+			log.Printf("Drive permissions differ from bino users in %d places, see /gdrive/permissions", len(changes))
+			continue
+		}
+		n, err := w.ApplyPermissionDiff(ctx, changes)
+		if err != nil {
+			log.Printf("ERROR: applying Drive permission changes: %v", err)
+		}
+		log.Printf("Queued %d Drive permission changes", n)
+	}
+}
+
+func (server *Server) getGDrivePermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	if server.GDriveWorker == nil {
+		server.renderError(w, r, commonData, errors.New(commonData.User.Language.GDriveNotInUse))
+		return
+	}
+
+	changes, err := server.GDriveWorker.PermissionDiff(ctx)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	_ = GDrivePermissionsPage(commonData, changes, server.GDriveWorker.Config().ReconcilePermissions).Render(ctx, w)
+}
+
+func (server *Server) postGDrivePermissionsApplyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	if server.GDriveWorker == nil {
+		server.renderError(w, r, commonData, errors.New(commonData.User.Language.GDriveNotInUse))
+		return
+	}
+
+	// Permissions may have changed since the report was shown, so compare again
+	changes, err := server.GDriveWorker.PermissionDiff(ctx)
+	if err != nil {
+		commonData.Error(commonData.User.Language.GenericFailed, err)
+		server.redirectToReferer(w, r)
+		return
+	}
+
+	n, err := server.GDriveWorker.ApplyPermissionDiff(ctx, changes)
+	if err != nil {
+		commonData.Error(commonData.User.Language.GenericFailed, err)
+	}
+	if n > 0 {
+		commonData.Success(commonData.User.Language.GDrivePermissionChangesQueued(n))
+	}
+
+	server.redirect(w, r, "/gdrive/tasks")
+}
@@ -0,0 +1,63 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version: v0.9.1
+
+// Built By: go install
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// GDrivePermissionActionGrant is a GDrivePermissionAction of type Grant.
+	GDrivePermissionActionGrant GDrivePermissionAction = 0
+	// GDrivePermissionActionRevoke is a GDrivePermissionAction of type Revoke.
+	GDrivePermissionActionRevoke GDrivePermissionAction = 1
+)
+
+var ErrInvalidGDrivePermissionAction = errors.New("not a valid GDrivePermissionAction")
+
+const _GDrivePermissionActionName = "GrantRevoke"
+
+// GDrivePermissionActionValues returns a list of the values for GDrivePermissionAction
+func GDrivePermissionActionValues() []GDrivePermissionAction {
+	return []GDrivePermissionAction{
+		GDrivePermissionActionGrant,
+		GDrivePermissionActionRevoke,
+	}
+}
+
+var _GDrivePermissionActionMap = map[GDrivePermissionAction]string{
+	GDrivePermissionActionGrant:  _GDrivePermissionActionName[0:5],
+	GDrivePermissionActionRevoke: _GDrivePermissionActionName[5:11],
+}
+
+// String implements the Stringer interface.
+func (x GDrivePermissionAction) String() string {
+	if str, ok := _GDrivePermissionActionMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("GDrivePermissionAction(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x GDrivePermissionAction) IsValid() bool {
+	_, ok := _GDrivePermissionActionMap[x]
+	return ok
+}
+
+var _GDrivePermissionActionValue = map[string]GDrivePermissionAction{
+	_GDrivePermissionActionName[0:5]:  GDrivePermissionActionGrant,
+	_GDrivePermissionActionName[5:11]: GDrivePermissionActionRevoke,
+}
+
+// ParseGDrivePermissionAction attempts to convert a string to a GDrivePermissionAction.
+func ParseGDrivePermissionAction(name string) (GDrivePermissionAction, error) {
+	if x, ok := _GDrivePermissionActionValue[name]; ok {
+		return x, nil
+	}
+	return GDrivePermissionAction(0), fmt.Errorf("%s is %w", name, ErrInvalidGDrivePermissionAction)
+}
@@ -0,0 +1,615 @@
+//go:generate go tool go-enum --no-iota --values
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/api/googleapi"
+)
+
+// ENUM(Pending = 0, Running, Done, Failed)
+type GDriveTaskStatus int32
+
+const (
+	gdriveTaskPollInterval = 10 * time.Second
+	// Spacing between tasks, so that batches don't hit Drive's rate limits
+	gdriveTaskMinInterval = time.Second
+	gdriveTaskMaxAttempts = 8
+	gdriveTaskBaseBackoff = 30 * time.Second
+	gdriveTaskMaxBackoff  = 2 * time.Hour
+)
+
+// Tasks that can never succeed, such as ones with a corrupt payload
+var errGDriveTaskPermanent = errors.New("permanent failure")
+
+var archivedJournalFileNameRegex = regexp.MustCompile(`[^\p{L}\p{N} ._()-]+`)
+
+// Arguments for creating a journal and attaching it to a patient
+type payloadCreatePatientJournal struct {
+	PatientID int32
+	HomeID    int32
+	AppuserID int32
+	// Google Docs ID of the template. The configured template is used if empty.
+	TemplateFile string
+	Vars         GDriveTemplateVars
+}
+
+type resultCreatePatientJournal struct {
+	URL string
+}
+
+// Arguments for bringing the name and folder of a patient's journal up to date.
+// The rest is read from the patient when the task runs, so a queued task
+// always applies the latest changes.
+type payloadUpdatePatientJournal struct {
+	PatientID  int32
+	LanguageID LanguageID
+	BinoURL    string
+}
+
+type resultUpdatePatientJournal struct {
+	Name   string
+	Parent string
+}
+
+// Arguments for storing a PDF copy of a patient's journal in bino
+type payloadArchivePatientJournal struct {
+	PatientID int32
+	// Who checked out the patient, registered as the creator of the file
+	AppuserID int32
+	// Home of the patient before checkout
+	HomeID int32
+}
+
+type resultArchivePatientJournal struct {
+	FileID int32
+}
+
+func gdriveTaskKeyCreatePatientJournal(patientID int32) string {
+	return fmt.Sprintf("create-journal/patient/%d", patientID)
+}
+
+func gdriveTaskKeyUpdatePatientJournal(patientID int32) string {
+	return fmt.Sprintf("update-journal/patient/%d", patientID)
+}
+
+func gdriveTaskKeyArchivePatientJournal(patientID int32) string {
+	return fmt.Sprintf("archive-journal/patient/%d", patientID)
+}
+
+// Stored on journals created by a task, to find them again when it's retried
+func gdriveCreateJournalKey(taskID int32) string {
+	return fmt.Sprintf("gdrive-task/%d", taskID)
+}
+
+func gdriveTaskKeyInviteUser(id, email string) string {
+	return fmt.Sprintf("invite-user/%s/%s", id, email)
+}
+
+func gdriveTaskKeyRevokePermission(id, permissionID string) string {
+	return fmt.Sprintf("revoke-permission/%s/%s", id, permissionID)
+}
+
+// Queue creating a journal for a patient. Does nothing if one is already queued.
+func (w *GDriveWorker) EnqueueCreatePatientJournal(ctx context.Context, payload payloadCreatePatientJournal) (GdriveTask, error) {
+	return w.enqueue(ctx, GDriveTaskRequestIDCreateJournal, gdriveTaskKeyCreatePatientJournal(payload.PatientID), EnqueueGDriveTaskParams{
+		PatientID: pgtype.Int4{Int32: payload.PatientID, Valid: true},
+		AppuserID: pgtype.Int4{Int32: payload.AppuserID, Valid: true},
+	}, payload)
+}
+
+// Queue renaming and moving a patient's journal. Does nothing if an update is
+// already pending. If one is running, it runs again when it's done, since it
+// may have read the patient before the latest change.
+func (w *GDriveWorker) EnqueueUpdatePatientJournal(ctx context.Context, payload payloadUpdatePatientJournal) (GdriveTask, error) {
+	return w.enqueue(ctx, GDriveTaskRequestIDUpdatePatientJournal, gdriveTaskKeyUpdatePatientJournal(payload.PatientID), EnqueueGDriveTaskParams{
+		PatientID:    pgtype.Int4{Int32: payload.PatientID, Valid: true},
+		RerunRunning: true,
+	}, payload)
+}
+
+// Queue exporting a patient's journal as PDF. Does nothing if an export is already queued.
+func (w *GDriveWorker) EnqueueArchivePatientJournal(ctx context.Context, payload payloadArchivePatientJournal) (GdriveTask, error) {
+	return w.enqueue(ctx, GDriveTaskRequestIDArchivePatientJournal, gdriveTaskKeyArchivePatientJournal(payload.PatientID), EnqueueGDriveTaskParams{
+		PatientID: pgtype.Int4{Int32: payload.PatientID, Valid: true},
+		AppuserID: pgtype.Int4{Int32: payload.AppuserID, Valid: true},
+	}, payload)
+}
+
+// Queue giving a user access to a file or folder. Does nothing if the same invitation is already queued.
+func (w *GDriveWorker) EnqueueInviteUser(ctx context.Context, id, email, role string) (GdriveTask, error) {
+	return w.enqueue(ctx, GDriveTaskRequestIDInviteUser, gdriveTaskKeyInviteUser(id, email), EnqueueGDriveTaskParams{}, payloadInviteUser{
+		ID:    id,
+		Email: email,
+		Role:  role,
+	})
+}
+
+// Queue removing a permission from a file or folder. Does nothing if the same removal is already queued.
+func (w *GDriveWorker) EnqueueRevokePermission(ctx context.Context, id, permissionID string) (GdriveTask, error) {
+	return w.enqueue(ctx, GDriveTaskRequestIDRevokePermission, gdriveTaskKeyRevokePermission(id, permissionID), EnqueueGDriveTaskParams{}, payloadRevokePermission{
+		ID:           id,
+		PermissionID: permissionID,
+	})
+}
+
+func (w *GDriveWorker) enqueue(ctx context.Context, taskType GDriveTaskRequestID, key string, params EnqueueGDriveTaskParams, payload any) (GdriveTask, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return GdriveTask{}, err
+	}
+	params.Type = int32(taskType)
+	params.Payload = string(raw)
+	params.IdempotencyKey = key
+
+	task, err := w.queries.EnqueueGDriveTask(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Already queued or running
+		return w.queries.GetGDriveTaskByKey(ctx, key)
+	}
+	if err != nil {
+		return GdriveTask{}, err
+	}
+
+	select {
+	case w.taskWake <- struct{}{}:
+	default:
+	}
+	return task, nil
+}
+
+func (w *GDriveWorker) taskQueueWorker(ctx context.Context) {
+	// Tasks that were running when bino stopped are tried again
+	if err := w.queries.ResetRunningGDriveTasks(ctx); err != nil {
+		log.Printf("ERROR: resetting running GDrive tasks: %v", err)
+	}
+
+	for {
+		for w.runNextTask(ctx) {
+			select {
+			case <-time.After(gdriveTaskMinInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-w.taskWake:
+		case <-time.After(gdriveTaskPollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Run the next task that is due. Returns false if there was none.
+func (w *GDriveWorker) runNextTask(ctx context.Context) bool {
+	// Tasks wait in the queue while Drive is unavailable
+	if !w.Health().OK() {
+		return false
+	}
+
+	task, err := w.queries.ClaimGDriveTask(ctx)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("ERROR: claiming GDrive task: %v", err)
+		}
+		return false
+	}
+
+	result, err := w.runTask(ctx, task)
+	if err == nil {
+		raw, _ := json.Marshal(result)
+		if err := w.queries.SetGDriveTaskDone(ctx, SetGDriveTaskDoneParams{
+			ID:     task.ID,
+			Result: pgtype.Text{String: string(raw), Valid: true},
+		}); err != nil {
+			log.Printf("ERROR: marking GDrive task %d as done: %v", task.ID, err)
+		}
+		return true
+	}
+
+	lastError := pgtype.Text{String: err.Error(), Valid: true}
+	retry, retryAfter := gdriveTaskShouldRetry(err)
+	if !retry || task.Attempts >= gdriveTaskMaxAttempts {
+		log.Printf("ERROR: GDrive task %d (%s) failed after %d attempts: %v", task.ID, task.IdempotencyKey, task.Attempts, err)
+		if err := w.queries.SetGDriveTaskFailed(ctx, SetGDriveTaskFailedParams{
+			ID:        task.ID,
+			LastError: lastError,
+		}); err != nil {
+			log.Printf("ERROR: marking GDrive task %d as failed: %v", task.ID, err)
+		}
+		return true
+	}
+
+	delay := max(gdriveTaskBackoff(task.Attempts), retryAfter)
+	log.Printf("GDrive task %d (%s) failed, retrying in %s: %v", task.ID, task.IdempotencyKey, delay, err)
+	if err := w.queries.SetGDriveTaskRetry(ctx, SetGDriveTaskRetryParams{
+		ID:          task.ID,
+		NextAttempt: pgtype.Timestamptz{Time: time.Now().Add(delay), Valid: true},
+		LastError:   lastError,
+	}); err != nil {
+		log.Printf("ERROR: rescheduling GDrive task %d: %v", task.ID, err)
+	}
+	return true
+}
+
+func (w *GDriveWorker) runTask(ctx context.Context, task GdriveTask) (any, error) {
+	switch GDriveTaskRequestID(task.Type) {
+	case GDriveTaskRequestIDCreateJournal:
+		var payload payloadCreatePatientJournal
+		if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+			return nil, fmt.Errorf("%w: decoding payload: %w", errGDriveTaskPermanent, err)
+		}
+		return w.runTaskCreatePatientJournal(ctx, task.ID, payload)
+	case GDriveTaskRequestIDInviteUser:
+		var payload payloadInviteUser
+		if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+			return nil, fmt.Errorf("%w: decoding payload: %w", errGDriveTaskPermanent, err)
+		}
+		return nil, w.InviteUser(payload.ID, payload.Email, payload.Role)
+	case GDriveTaskRequestIDRevokePermission:
+		var payload payloadRevokePermission
+		if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+			return nil, fmt.Errorf("%w: decoding payload: %w", errGDriveTaskPermanent, err)
+		}
+		return nil, w.RevokePermission(payload.ID, payload.PermissionID)
+	case GDriveTaskRequestIDUpdatePatientJournal:
+		var payload payloadUpdatePatientJournal
+		if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+			return nil, fmt.Errorf("%w: decoding payload: %w", errGDriveTaskPermanent, err)
+		}
+		return w.runTaskUpdatePatientJournal(ctx, payload)
+	case GDriveTaskRequestIDArchivePatientJournal:
+		var payload payloadArchivePatientJournal
+		if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+			return nil, fmt.Errorf("%w: decoding payload: %w", errGDriveTaskPermanent, err)
+		}
+		return w.runTaskArchivePatientJournal(ctx, payload)
+	}
+	return nil, fmt.Errorf("%w: unknown task type %d", errGDriveTaskPermanent, task.Type)
+}
+
+func (w *GDriveWorker) runTaskCreatePatientJournal(ctx context.Context, taskID int32, payload payloadCreatePatientJournal) (resultCreatePatientJournal, error) {
+	// A previous attempt may have got as far as attaching the journal, or
+	// someone attached one by hand in the meantime
+	patient, err := w.queries.GetPatient(ctx, payload.PatientID)
+	if err != nil {
+		return resultCreatePatientJournal{}, fmt.Errorf("getting patient: %w", err)
+	}
+	if patient.JournalUrl.String != "" {
+		return resultCreatePatientJournal{URL: patient.JournalUrl.String}, nil
+	}
+
+	// Retries of the task find the journal made by an earlier attempt by the
+	// key, so a lost response or a failure to attach doesn't make another one
+	item, err := w.CreateJournal(payload.TemplateFile, payload.Vars, gdriveCreateJournalKey(taskID))
+	if err != nil {
+		return resultCreatePatientJournal{}, err
+	}
+	url := item.DocumentURL()
+
+	templateFile := payload.TemplateFile
+	if templateFile == "" {
+		templateFile = w.Config().TemplateFile
+	}
+	if _, err := w.queries.SetPatientJournalIfMissing(ctx, SetPatientJournalIfMissingParams{
+		ID:              payload.PatientID,
+		JournalUrl:      pgtype.Text{String: url, Valid: true},
+		JournalTemplate: pgtype.Text{String: templateFile, Valid: true},
+	}); err != nil {
+		return resultCreatePatientJournal{}, fmt.Errorf("created %s but failed to attach it: %w", url, err)
+	}
+
+	if _, err := w.queries.AddPatientEvent(ctx, AddPatientEventParams{
+		PatientID: payload.PatientID,
+		HomeID:    payload.HomeID,
+		EventID:   int32(EventJournalCreated),
+		AppuserID: payload.AppuserID,
+		Time:      pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}); err != nil {
+		log.Printf("ERROR: adding journal event for patient %d: %v", payload.PatientID, err)
+	}
+
+	return resultCreatePatientJournal{URL: url}, nil
+}
+
+func (w *GDriveWorker) runTaskUpdatePatientJournal(ctx context.Context, payload payloadUpdatePatientJournal) (resultUpdatePatientJournal, error) {
+	patient, err := w.queries.GetPatientWithSpecies(ctx, GetPatientWithSpeciesParams{
+		ID:         payload.PatientID,
+		LanguageID: int32(payload.LanguageID),
+	})
+	if err != nil {
+		return resultUpdatePatientJournal{}, fmt.Errorf("getting patient: %w", err)
+	}
+	id, ok := gdriveDocumentID(patient.JournalUrl.String)
+	if !ok {
+		// The journal was detached, or isn't in Drive
+		return resultUpdatePatientJournal{}, nil
+	}
+
+	item, err := w.GetFile(id)
+	if err != nil {
+		return resultUpdatePatientJournal{}, err
+	}
+
+	vars := patientJournalVars(ctx, w.queries, patient, GetLanguage(int32(payload.LanguageID)), payload.BinoURL)
+	checkedOut := IsCheckoutStatus[Status(patient.Status)]
+
+	// Named after the template the journal was created from. For journals
+	// from before that was recorded, it's the template for the species.
+	info, err := w.GetGDriveConfigInfo()
+	if err != nil {
+		return resultUpdatePatientJournal{}, err
+	}
+	template := info.TemplateDoc.Item
+	if patient.JournalTemplate.String != "" {
+		if template, err = w.GetFile(patient.JournalTemplate.String); err != nil {
+			return resultUpdatePatientJournal{}, fmt.Errorf("getting template: %w", err)
+		}
+	} else if speciesTemplate, err := w.queries.GetJournalTemplateForSpecies(ctx, patient.SpeciesID); err == nil {
+		if template, err = w.GetFile(speciesTemplate.GdriveFile); err != nil {
+			return resultUpdatePatientJournal{}, fmt.Errorf("getting template: %w", err)
+		}
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return resultUpdatePatientJournal{}, fmt.Errorf("getting template for species: %w", err)
+	}
+
+	params := UpdateFileParams{
+		ID:   id,
+		Name: vars.ApplyToString(template.Name),
+	}
+	if checkedOut {
+		nameTemplate := w.Config().ArchivedNameTemplate
+		if nameTemplate == "" {
+			nameTemplate = template.Name + archivedJournalNameSuffix
+		}
+		params.Name = vars.ApplyToString(nameTemplate)
+	}
+
+	// Only journals in the journal folder or the archive are moved, so that
+	// journals kept elsewhere on purpose stay there
+	inArchive, err := w.inJournalArchive(item)
+	if err != nil {
+		return resultUpdatePatientJournal{}, err
+	}
+	managed := inArchive || slices.Contains(item.Parents, w.Config().JournalFolder)
+	switch {
+	case managed && checkedOut && w.Config().ArchiveFolder != "":
+		params.Parent = w.Config().ArchiveFolder
+		if w.Config().ArchivePerYear && !vars.Checkout.IsZero() {
+			folder, err := w.GetOrCreateFolder(w.Config().ArchiveFolder, strconv.Itoa(vars.Checkout.Year()))
+			if err != nil {
+				return resultUpdatePatientJournal{}, fmt.Errorf("getting archive folder for %d: %w", vars.Checkout.Year(), err)
+			}
+			params.Parent = folder.ID
+		}
+	case inArchive && !checkedOut:
+		params.Parent = w.Config().JournalFolder
+	}
+
+	if params.Name == item.Name && (params.Parent == "" || slices.Equal(item.Parents, []string{params.Parent})) {
+		return resultUpdatePatientJournal{Name: item.Name}, nil
+	}
+
+	item, err = w.UpdateFile(params)
+	if err != nil {
+		return resultUpdatePatientJournal{}, err
+	}
+	return resultUpdatePatientJournal{Name: item.Name, Parent: params.Parent}, nil
+}
+
+func (w *GDriveWorker) runTaskArchivePatientJournal(ctx context.Context, payload payloadArchivePatientJournal) (resultArchivePatientJournal, error) {
+	patient, err := w.queries.GetPatient(ctx, payload.PatientID)
+	if err != nil {
+		return resultArchivePatientJournal{}, fmt.Errorf("getting patient: %w", err)
+	}
+	id, ok := gdriveDocumentID(patient.JournalUrl.String)
+	if !ok {
+		// The journal was detached, or isn't in Drive
+		return resultArchivePatientJournal{}, nil
+	}
+
+	item, err := w.GetFile(id)
+	if err != nil {
+		return resultArchivePatientJournal{}, err
+	}
+
+	data, err := w.ExportFile(id, mimeTypePDF)
+	if err != nil {
+		return resultArchivePatientJournal{}, fmt.Errorf("exporting journal: %w", err)
+	}
+
+	now := time.Now()
+	info := FileInfo{
+		FileName: archivedJournalFileName(item.Name),
+		MIMEType: mimeTypePDF,
+		Size:     int64(len(data)),
+		Created:  now,
+		Creator:  payload.AppuserID,
+	}
+	upload := w.files.Upload(ctx, bytes.NewReader(data), info)
+	if upload.Error != nil {
+		return resultArchivePatientJournal{}, fmt.Errorf("storing PDF: %w", upload.Error)
+	}
+	if commit := w.files.Commit(ctx, []string{upload.UniqueID}); commit.Error != nil {
+		return resultArchivePatientJournal{}, fmt.Errorf("storing PDF: %w", commit.Error)
+	}
+
+	fileID, err := w.queries.AddPatientJournalArchive(ctx, AddPatientJournalArchiveParams{
+		PatientID:     patient.ID,
+		JournalUrl:    patient.JournalUrl.String,
+		HomeID:        pgtype.Int4{Int32: payload.HomeID, Valid: payload.HomeID != 0},
+		Uuid:          upload.UniqueID,
+		Accessibility: int32(FileAccessibilityInternal),
+		Creator:       payload.AppuserID,
+		Created:       pgtype.Timestamptz{Time: now, Valid: true},
+		Filename:      info.FileName,
+		Mimetype:      info.MIMEType,
+		Size:          info.Size,
+	})
+	if err != nil {
+		// Don't leave a file behind that nothing refers to
+		if result := w.files.Delete(ctx, upload.UniqueID); result.Error != nil {
+			log.Printf("ERROR: deleting unregistered journal PDF %s: %v", upload.UniqueID, result.Error)
+		}
+		return resultArchivePatientJournal{}, fmt.Errorf("registering PDF: %w", err)
+	}
+
+	return resultArchivePatientJournal{FileID: fileID}, nil
+}
+
+// A file name for the PDF copy of a journal that is safe to use in paths and URLs.
+func archivedJournalFileName(title string) string {
+	name := strings.Trim(archivedJournalFileNameRegex.ReplaceAllString(title, "_"), " ._")
+	if name == "" {
+		name = "journal"
+	}
+	return name + ".pdf"
+}
+
+// Whether the item is in the archive folder, or in one of its folders per year.
+func (w *GDriveWorker) inJournalArchive(item GDriveItem) (bool, error) {
+	if w.Config().ArchiveFolder == "" {
+		return false, nil
+	}
+	for _, parent := range item.Parents {
+		if parent == w.Config().ArchiveFolder {
+			return true, nil
+		}
+		if w.Config().ArchivePerYear && parent != w.Config().JournalFolder {
+			folder, err := w.GetFile(parent)
+			if err != nil {
+				return false, fmt.Errorf("getting parent folder: %w", err)
+			}
+			if slices.Contains(folder.Parents, w.Config().ArchiveFolder) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Whether a failed task should be tried again, and how long Drive asked us to wait.
+// Rate limiting and server errors are retried, as are errors that didn't come from
+// Drive at all, such as network errors.
+func gdriveTaskShouldRetry(err error) (bool, time.Duration) {
+	if errors.Is(err, errGDriveTaskPermanent) {
+		return false, 0
+	}
+
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) {
+		return true, 0
+	}
+
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(gerr.Header.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	switch {
+	case gerr.Code == http.StatusTooManyRequests, gerr.Code >= 500:
+		return true, retryAfter
+	case gerr.Code == http.StatusForbidden:
+		// Drive reports some rate limits as 403
+		for _, item := range gerr.Errors {
+			if item.Reason == "rateLimitExceeded" || item.Reason == "userRateLimitExceeded" {
+				return true, retryAfter
+			}
+		}
+	}
+	return false, 0
+}
+
+// Exponential backoff after the given number of attempts.
+func gdriveTaskBackoff(attempts int32) time.Duration {
+	delay := gdriveTaskBaseBackoff
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= gdriveTaskMaxBackoff {
+			return gdriveTaskMaxBackoff
+		}
+	}
+	return delay
+}
+
+func (server *Server) getGDriveTasksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	tasks, err := server.Queries.GetGDriveTasks(ctx)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	_ = GDriveTasksPage(commonData, SliceToSlice(tasks, GetGDriveTasksRow.ToGDriveTaskView)).Render(ctx, w)
+}
+
+func (server *Server) postGDriveTaskRetryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	id, err := server.getPathID(r, "task")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	if n, err := server.Queries.RetryGDriveTask(ctx, id); err != nil {
+		commonData.Error(commonData.User.Language.GenericFailed, err)
+	} else if n == 0 {
+		commonData.Warning(commonData.User.Language.GenericNotFound, nil)
+	} else {
+		commonData.Success(commonData.User.Language.GDriveTaskRetried)
+	}
+
+	server.redirectToReferer(w, r)
+}
+
+// Mark patients that have a journal on the way, or whose journal couldn't be created.
+func (server *Server) addJournalTaskStatus(ctx context.Context, groups ...[]PatientView) {
+	var ids []int32
+	for _, patients := range groups {
+		for _, p := range patients {
+			ids = append(ids, p.ID)
+		}
+	}
+	tasks, err := server.Queries.GetUnfinishedGDriveTasksForPatients(ctx, ids)
+	if err != nil {
+		LogCtx(ctx, "getting GDrive tasks for patients: %v", err)
+		return
+	}
+	byPatient := map[int32]GetUnfinishedGDriveTasksForPatientsRow{}
+	for _, task := range tasks {
+		if GDriveTaskRequestID(task.Type) == GDriveTaskRequestIDCreateJournal {
+			byPatient[task.PatientID] = task
+		}
+	}
+	for _, patients := range groups {
+		for i := range patients {
+			task, ok := byPatient[patients[i].ID]
+			if !ok || patients[i].JournalURL != "" {
+				continue
+			}
+			if GDriveTaskStatus(task.Status) == GDriveTaskStatusFailed {
+				patients[i].JournalError = task.LastError.String
+			} else {
+				patients[i].JournalPending = true
+			}
+		}
+	}
+}
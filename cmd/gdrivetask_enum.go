@@ -0,0 +1,73 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version: v0.9.1
+
+// Built By: go install
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// GDriveTaskStatusPending is a GDriveTaskStatus of type Pending.
+	GDriveTaskStatusPending GDriveTaskStatus = 0
+	// GDriveTaskStatusRunning is a GDriveTaskStatus of type Running.
+	GDriveTaskStatusRunning GDriveTaskStatus = 1
+	// GDriveTaskStatusDone is a GDriveTaskStatus of type Done.
+	GDriveTaskStatusDone GDriveTaskStatus = 2
+	// GDriveTaskStatusFailed is a GDriveTaskStatus of type Failed.
+	GDriveTaskStatusFailed GDriveTaskStatus = 3
+)
+
+var ErrInvalidGDriveTaskStatus = errors.New("not a valid GDriveTaskStatus")
+
+const _GDriveTaskStatusName = "PendingRunningDoneFailed"
+
+// GDriveTaskStatusValues returns a list of the values for GDriveTaskStatus
+func GDriveTaskStatusValues() []GDriveTaskStatus {
+	return []GDriveTaskStatus{
+		GDriveTaskStatusPending,
+		GDriveTaskStatusRunning,
+		GDriveTaskStatusDone,
+		GDriveTaskStatusFailed,
+	}
+}
+
+var _GDriveTaskStatusMap = map[GDriveTaskStatus]string{
+	GDriveTaskStatusPending: _GDriveTaskStatusName[0:7],
+	GDriveTaskStatusRunning: _GDriveTaskStatusName[7:14],
+	GDriveTaskStatusDone:    _GDriveTaskStatusName[14:18],
+	GDriveTaskStatusFailed:  _GDriveTaskStatusName[18:24],
+}
+
+// String implements the Stringer interface.
+func (x GDriveTaskStatus) String() string {
+	if str, ok := _GDriveTaskStatusMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("GDriveTaskStatus(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x GDriveTaskStatus) IsValid() bool {
+	_, ok := _GDriveTaskStatusMap[x]
+	return ok
+}
+
+var _GDriveTaskStatusValue = map[string]GDriveTaskStatus{
+	_GDriveTaskStatusName[0:7]:   GDriveTaskStatusPending,
+	_GDriveTaskStatusName[7:14]:  GDriveTaskStatusRunning,
+	_GDriveTaskStatusName[14:18]: GDriveTaskStatusDone,
+	_GDriveTaskStatusName[18:24]: GDriveTaskStatusFailed,
+}
+
+// ParseGDriveTaskStatus attempts to convert a string to a GDriveTaskStatus.
+func ParseGDriveTaskStatus(name string) (GDriveTaskStatus, error) {
+	if x, ok := _GDriveTaskStatusValue[name]; ok {
+		return x, nil
+	}
+	return GDriveTaskStatus(0), fmt.Errorf("%s is %w", name, ErrInvalidGDriveTaskStatus)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -15,7 +16,10 @@ import (
 const (
 	maxNConcurrentGDriveTaskRequests = 100
 	nWorkers                         = 1
+	maxNQueuedSearchReindexRequests  = 16
 	timeFormatDriveQ                 = "2006-01-02T15:04:05"
+	// How long the revisions of a file are kept by ListRevisions
+	gdriveRevisionCacheTTL = 5 * time.Minute
 )
 
 // ENUM(
@@ -24,18 +28,59 @@ const (
 //	InviteUser,
 //	CreateJournal,
 //	ListFiles,
+//	ListChanges,
+//	UpdateFile,
+//	GetOrCreateFolder,
+//	UpdatePatientJournal,
+//	RevokePermission,
+//	ListRevisions,
+//	ExportFile,
+//	ArchivePatientJournal,
+//	GetStartPageToken,
+//	ReadDocument,
 //
 // )
 type GDriveTaskRequestID int
 
 type GDriveWorker struct {
-	cfg GDriveConfig
-	g   *GDrive
+	cfg   GDriveConfig
+	cfgMu *sync.RWMutex
+	// Increased by SetConfig, so that config info fetched for an older config
+	// isn't cached
+	cfgGeneration uint64
+	journalCfg    JournalConfig
+	g             *GDrive
+	client        GDriveClient
+	queries       *Queries
+	// The queries used by the search indexer
+	searchStore searchIndexStore
+	// Where exported journals are stored
+	files FileBackend
 
 	in chan GDriveTaskRequest
 
+	searchReindex chan searchReindexRequest
+
+	// Signalled when a task is added to the durable queue
+	taskWake chan struct{}
+
 	cachedInfo   *GDriveConfigInfo
 	cachedInfoMu *sync.Mutex
+	// Why the config info couldn't be fetched. Drive features are disabled while set.
+	infoErr error
+	// When the config info was last fetched, successfully or not
+	infoChecked time.Time
+	// How many times in a row the config info couldn't be fetched
+	infoFailures int
+
+	// Revisions by file ID, see ListRevisions
+	revisionCache   map[string]cachedGDriveRevisions
+	revisionCacheMu *sync.Mutex
+}
+
+type cachedGDriveRevisions struct {
+	Revisions []GDriveRevision
+	Fetched   time.Time
 }
 
 type GDriveTaskRequest struct {
@@ -78,17 +123,41 @@ func newGDriveTaskRequestInviteUser(id, email, role string) GDriveTaskRequest {
 	return req
 }
 
-type ListFilesParams struct {
-	Parent         string
-	ModifiedAfter  time.Time
-	ModifiedBefore time.Time
-	PageToken      string
+type payloadRevokePermission struct {
+	ID           string
+	PermissionID string
+}
+
+func newGDriveTaskRequestRevokePermission(id, permissionID string) GDriveTaskRequest {
+	req := newGDriveTaskRequest()
+	req.Type = GDriveTaskRequestIDRevokePermission
+	req.Payload = payloadRevokePermission{
+		ID:           id,
+		PermissionID: permissionID,
+	}
+	return req
+}
+
+func newGDriveTaskRequestListRevisions(id string) GDriveTaskRequest {
+	req := newGDriveTaskRequest()
+	req.Type = GDriveTaskRequestIDListRevisions
+	req.Payload = id
+	return req
 }
 
-type ListFilesResult struct {
-	Folder        GDriveItem
-	Files         []GDriveItem
-	NextPageToken string
+type payloadExportFile struct {
+	ID       string
+	MIMEType string
+}
+
+func newGDriveTaskRequestExportFile(id, mimeType string) GDriveTaskRequest {
+	req := newGDriveTaskRequest()
+	req.Type = GDriveTaskRequestIDExportFile
+	req.Payload = payloadExportFile{
+		ID:       id,
+		MIMEType: mimeType,
+	}
+	return req
 }
 
 func newGDriveTaskRequestListFiles(params ListFilesParams) GDriveTaskRequest {
@@ -98,10 +167,65 @@ func newGDriveTaskRequestListFiles(params ListFilesParams) GDriveTaskRequest {
 	return req
 }
 
-func newGDriveTaskRequestCreateJournal(vars GDriveTemplateVars) GDriveTaskRequest {
+func newGDriveTaskRequestListChanges(pageToken string) GDriveTaskRequest {
+	req := newGDriveTaskRequest()
+	req.Type = GDriveTaskRequestIDListChanges
+	req.Payload = pageToken
+	return req
+}
+
+func newGDriveTaskRequestGetStartPageToken() GDriveTaskRequest {
+	req := newGDriveTaskRequest()
+	req.Type = GDriveTaskRequestIDGetStartPageToken
+	return req
+}
+
+func newGDriveTaskRequestReadDocument(id string) GDriveTaskRequest {
+	req := newGDriveTaskRequest()
+	req.Type = GDriveTaskRequestIDReadDocument
+	req.Payload = id
+	return req
+}
+
+type payloadCreateJournal struct {
+	// Google Docs ID of the template. The configured template is used if empty.
+	TemplateFile string
+	Vars         GDriveTemplateVars
+	// If set, a journal already created with the same key is returned instead
+	// of creating another one
+	Key string
+}
+
+func newGDriveTaskRequestCreateJournal(templateFile string, vars GDriveTemplateVars, key string) GDriveTaskRequest {
 	req := newGDriveTaskRequest()
 	req.Type = GDriveTaskRequestIDCreateJournal
-	req.Payload = vars
+	req.Payload = payloadCreateJournal{
+		TemplateFile: templateFile,
+		Vars:         vars,
+		Key:          key,
+	}
+	return req
+}
+
+func newGDriveTaskRequestUpdateFile(params UpdateFileParams) GDriveTaskRequest {
+	req := newGDriveTaskRequest()
+	req.Type = GDriveTaskRequestIDUpdateFile
+	req.Payload = params
+	return req
+}
+
+type payloadGetOrCreateFolder struct {
+	Parent string
+	Name   string
+}
+
+func newGDriveTaskRequestGetOrCreateFolder(parent, name string) GDriveTaskRequest {
+	req := newGDriveTaskRequest()
+	req.Type = GDriveTaskRequestIDGetOrCreateFolder
+	req.Payload = payloadGetOrCreateFolder{
+		Parent: parent,
+		Name:   name,
+	}
 	return req
 }
 
@@ -121,12 +245,36 @@ func (req GDriveTaskRequest) decodeInviteUser() (payloadInviteUser, error) {
 	return inv, nil
 }
 
-func (req GDriveTaskRequest) decodeCreateJournal() (GDriveTemplateVars, error) {
-	vars, ok := req.Payload.(GDriveTemplateVars)
+func (req GDriveTaskRequest) decodeRevokePermission() (payloadRevokePermission, error) {
+	payload, ok := req.Payload.(payloadRevokePermission)
+	if !ok {
+		return payloadRevokePermission{}, fmt.Errorf("decodeRevokePermission called on request with payload of type %T", req.Payload)
+	}
+	return payload, nil
+}
+
+func (req GDriveTaskRequest) decodeListRevisions() (string, error) {
+	id, ok := req.Payload.(string)
 	if !ok {
-		return GDriveTemplateVars{}, fmt.Errorf("decodeCreateJournal called on request with payload of type %T", req.Payload)
+		return "", fmt.Errorf("decodeListRevisions called on request with payload of type %T", req.Payload)
 	}
-	return vars, nil
+	return id, nil
+}
+
+func (req GDriveTaskRequest) decodeExportFile() (payloadExportFile, error) {
+	payload, ok := req.Payload.(payloadExportFile)
+	if !ok {
+		return payloadExportFile{}, fmt.Errorf("decodeExportFile called on request with payload of type %T", req.Payload)
+	}
+	return payload, nil
+}
+
+func (req GDriveTaskRequest) decodeCreateJournal() (payloadCreateJournal, error) {
+	payload, ok := req.Payload.(payloadCreateJournal)
+	if !ok {
+		return payloadCreateJournal{}, fmt.Errorf("decodeCreateJournal called on request with payload of type %T", req.Payload)
+	}
+	return payload, nil
 }
 
 func (req GDriveTaskRequest) decodeListFiles() (ListFilesParams, error) {
@@ -137,6 +285,38 @@ func (req GDriveTaskRequest) decodeListFiles() (ListFilesParams, error) {
 	return payload, nil
 }
 
+func (req GDriveTaskRequest) decodeListChanges() (string, error) {
+	pageToken, ok := req.Payload.(string)
+	if !ok {
+		return "", fmt.Errorf("decodeListChanges called on request with payload of type %T", req.Payload)
+	}
+	return pageToken, nil
+}
+
+func (req GDriveTaskRequest) decodeReadDocument() (string, error) {
+	id, ok := req.Payload.(string)
+	if !ok {
+		return "", fmt.Errorf("decodeReadDocument called on request with payload of type %T", req.Payload)
+	}
+	return id, nil
+}
+
+func (req GDriveTaskRequest) decodeUpdateFile() (UpdateFileParams, error) {
+	params, ok := req.Payload.(UpdateFileParams)
+	if !ok {
+		return UpdateFileParams{}, fmt.Errorf("decodeUpdateFile called on request with payload of type %T", req.Payload)
+	}
+	return params, nil
+}
+
+func (req GDriveTaskRequest) decodeGetOrCreateFolder() (payloadGetOrCreateFolder, error) {
+	payload, ok := req.Payload.(payloadGetOrCreateFolder)
+	if !ok {
+		return payloadGetOrCreateFolder{}, fmt.Errorf("decodeGetOrCreateFolder called on request with payload of type %T", req.Payload)
+	}
+	return payload, nil
+}
+
 func (resp GDriveTaskResponse) decodeError() error {
 	if resp.Error != nil {
 		return resp.Error
@@ -168,6 +348,44 @@ func (resp GDriveTaskResponse) decodeInviteUser() error {
 	return nil
 }
 
+func (resp GDriveTaskResponse) decodeRevokePermission() error {
+	if err := resp.decodeError(); err != nil {
+		return err
+	}
+	if resp.Type != GDriveTaskRequestIDRevokePermission {
+		return fmt.Errorf("decodeRevokePermission called on response of type %s", resp.Type.String())
+	}
+	return nil
+}
+
+func (resp GDriveTaskResponse) decodeListRevisions() ([]GDriveRevision, error) {
+	if err := resp.decodeError(); err != nil {
+		return nil, err
+	}
+	if resp.Type != GDriveTaskRequestIDListRevisions {
+		return nil, fmt.Errorf("decodeListRevisions called on response of type %s", resp.Type.String())
+	}
+	revisions, ok := resp.Payload.([]GDriveRevision)
+	if !ok {
+		return nil, fmt.Errorf("decodeListRevisions called with bad payload type %T", resp.Payload)
+	}
+	return revisions, nil
+}
+
+func (resp GDriveTaskResponse) decodeExportFile() ([]byte, error) {
+	if err := resp.decodeError(); err != nil {
+		return nil, err
+	}
+	if resp.Type != GDriveTaskRequestIDExportFile {
+		return nil, fmt.Errorf("decodeExportFile called on response of type %s", resp.Type.String())
+	}
+	data, ok := resp.Payload.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("decodeExportFile called with bad payload type %T", resp.Payload)
+	}
+	return data, nil
+}
+
 func (resp GDriveTaskResponse) decodeCreateJournal() (GDriveItem, error) {
 	if err := resp.decodeError(); err != nil {
 		return GDriveItem{}, err
@@ -196,6 +414,76 @@ func (resp GDriveTaskResponse) decodeListFiles() (ListFilesResult, error) {
 	return result, nil
 }
 
+func (resp GDriveTaskResponse) decodeListChanges() (GDriveChanges, error) {
+	if err := resp.decodeError(); err != nil {
+		return GDriveChanges{}, err
+	}
+	if resp.Type != GDriveTaskRequestIDListChanges {
+		return GDriveChanges{}, fmt.Errorf("decodeListChanges called on response of type %s", resp.Type.String())
+	}
+	result, ok := resp.Payload.(GDriveChanges)
+	if !ok {
+		return GDriveChanges{}, fmt.Errorf("decodeListChanges called with bad payload type %T", resp.Payload)
+	}
+	return result, nil
+}
+
+func (resp GDriveTaskResponse) decodeGetStartPageToken() (string, error) {
+	if err := resp.decodeError(); err != nil {
+		return "", err
+	}
+	if resp.Type != GDriveTaskRequestIDGetStartPageToken {
+		return "", fmt.Errorf("decodeGetStartPageToken called on response of type %s", resp.Type.String())
+	}
+	pageToken, ok := resp.Payload.(string)
+	if !ok {
+		return "", fmt.Errorf("decodeGetStartPageToken called with bad payload type %T", resp.Payload)
+	}
+	return pageToken, nil
+}
+
+func (resp GDriveTaskResponse) decodeReadDocument() (GDriveJournal, error) {
+	if err := resp.decodeError(); err != nil {
+		return GDriveJournal{}, err
+	}
+	if resp.Type != GDriveTaskRequestIDReadDocument {
+		return GDriveJournal{}, fmt.Errorf("decodeReadDocument called on response of type %s", resp.Type.String())
+	}
+	doc, ok := resp.Payload.(GDriveJournal)
+	if !ok {
+		return GDriveJournal{}, fmt.Errorf("decodeReadDocument called with bad payload type %T", resp.Payload)
+	}
+	return doc, nil
+}
+
+func (resp GDriveTaskResponse) decodeUpdateFile() (GDriveItem, error) {
+	if err := resp.decodeError(); err != nil {
+		return GDriveItem{}, err
+	}
+	if resp.Type != GDriveTaskRequestIDUpdateFile {
+		return GDriveItem{}, fmt.Errorf("decodeUpdateFile called on response of type %s", resp.Type.String())
+	}
+	item, ok := resp.Payload.(GDriveItem)
+	if !ok {
+		return GDriveItem{}, fmt.Errorf("decodeUpdateFile called with bad payload type %T", resp.Payload)
+	}
+	return item, nil
+}
+
+func (resp GDriveTaskResponse) decodeGetOrCreateFolder() (GDriveItem, error) {
+	if err := resp.decodeError(); err != nil {
+		return GDriveItem{}, err
+	}
+	if resp.Type != GDriveTaskRequestIDGetOrCreateFolder {
+		return GDriveItem{}, fmt.Errorf("decodeGetOrCreateFolder called on response of type %s", resp.Type.String())
+	}
+	item, ok := resp.Payload.(GDriveItem)
+	if !ok {
+		return GDriveItem{}, fmt.Errorf("decodeGetOrCreateFolder called with bad payload type %T", resp.Payload)
+	}
+	return item, nil
+}
+
 type GDriveTaskResponse struct {
 	Type    GDriveTaskRequestID
 	Error   error
@@ -206,26 +494,40 @@ func (gdtr GDriveTaskResponse) String() string {
 	return fmt.Sprintf("<GDriveTaskResponse of type %s>", gdtr.Type)
 }
 
-func NewGDriveWorker(ctx context.Context, cfg GDriveConfig, g *GDrive) *GDriveWorker {
+func NewGDriveWorker(ctx context.Context, cfg GDriveConfig, journalCfg JournalConfig, g *GDrive, files FileBackend) *GDriveWorker {
 	w := &GDriveWorker{
-		cfg:          cfg,
-		g:            g,
-		in:           make(chan GDriveTaskRequest, maxNConcurrentGDriveTaskRequests),
-		cachedInfoMu: &sync.Mutex{},
+		cfg:           cfg,
+		cfgMu:         &sync.RWMutex{},
+		journalCfg:    journalCfg,
+		g:             g,
+		client:        g,
+		queries:       g.Queries,
+		searchStore:   g.Queries,
+		files:         files,
+		in:            make(chan GDriveTaskRequest, maxNConcurrentGDriveTaskRequests),
+		searchReindex: make(chan searchReindexRequest, maxNQueuedSearchReindexRequests),
+		taskWake:      make(chan struct{}, 1),
+		cachedInfoMu:  &sync.Mutex{},
+
+		revisionCache:   map[string]cachedGDriveRevisions{},
+		revisionCacheMu: &sync.Mutex{},
 	}
 
-	// Warm the cache on gdrive info, then start pollin
-	go func() {
-		_ = w.GetGDriveConfigInfo()
+	go w.configHealthWorker(ctx)
 
-		w.searchIndexWorker(ctx)
-	}()
+	go w.searchIndexWorker(ctx)
 
 	// Workers
 	for i := range nWorkers {
 		go w.worker(i)
 	}
 
+	go w.taskQueueWorker(ctx)
+
+	go w.permissionReconcileWorker(ctx)
+
+	go w.journalCheckWorker(ctx)
+
 	return w
 }
 
@@ -235,45 +537,43 @@ type GDriveConfigInfo struct {
 	ExtraFolders  []GDriveItem
 }
 
-func (w *GDriveWorker) GetGDriveConfigInfo() GDriveConfigInfo {
+// The cached config info. Fetched on first use, and then kept up to date by
+// configHealthWorker. Fails while Drive is unavailable.
+func (w *GDriveWorker) GetGDriveConfigInfo() (GDriveConfigInfo, error) {
 	w.cachedInfoMu.Lock()
-	defer w.cachedInfoMu.Unlock()
-	if w.cachedInfo == nil {
-		w.cachedInfo = new(GDriveConfigInfo)
-		item, err := w.g.GetFile(w.cfg.JournalFolder)
-		if err != nil {
-			panic(err)
-		}
-		w.cachedInfo.JournalFolder = item
-
-		doc, err := w.g.ReadDocument(w.cfg.TemplateFile)
-		if err != nil {
-			panic(err)
-		}
-		w.cachedInfo.TemplateDoc = doc
+	info, err := w.cachedInfo, w.infoErr
+	w.cachedInfoMu.Unlock()
+	if err != nil {
+		return GDriveConfigInfo{}, fmt.Errorf("%w: %w", errGDriveUnavailable, err)
+	}
+	if info != nil {
+		return *info, nil
+	}
 
-		if err := doc.Validate(); err != nil {
-			panic(err)
-		}
+	fresh, err := w.RefreshConfigInfo()
+	if err != nil {
+		return GDriveConfigInfo{}, fmt.Errorf("%w: %w", errGDriveUnavailable, err)
+	}
+	return fresh, nil
+}
 
-		for _, id := range w.cfg.ExtraJournalFolders {
-			folder, err := w.g.GetFile(id)
-			if err != nil {
-				log.Printf("error getting extra folder %s: %v", id, err)
-				continue
-			}
-			w.cachedInfo.ExtraFolders = append(
-				w.cachedInfo.ExtraFolders,
-				folder,
-			)
-		}
+// The current Drive config, including settings changed from the admin pages.
+func (w *GDriveWorker) Config() GDriveConfig {
+	w.cfgMu.RLock()
+	defer w.cfgMu.RUnlock()
+	return w.cfg
+}
 
-		log.Printf("Fetched GDrive Config info")
-	}
-	return *w.cachedInfo
+func (w *GDriveWorker) configAndGeneration() (GDriveConfig, uint64) {
+	w.cfgMu.RLock()
+	defer w.cfgMu.RUnlock()
+	return w.cfg, w.cfgGeneration
 }
 
 func (w *GDriveWorker) Exec(req GDriveTaskRequest) GDriveTaskResponse {
+	if err := w.Health().Err; err != nil {
+		return w.errorResponse(req, fmt.Errorf("%w: %w", errGDriveUnavailable, err))
+	}
 	w.in <- req
 	return <-req.Response
 }
@@ -286,14 +586,74 @@ func (w *GDriveWorker) InviteUser(id, email, role string) error {
 	return w.Exec(newGDriveTaskRequestInviteUser(id, email, role)).decodeInviteUser()
 }
 
-func (w *GDriveWorker) CreateJournal(vars GDriveTemplateVars) (GDriveItem, error) {
-	return w.Exec(newGDriveTaskRequestCreateJournal(vars)).decodeCreateJournal()
+func (w *GDriveWorker) RevokePermission(id, permissionID string) error {
+	return w.Exec(newGDriveTaskRequestRevokePermission(id, permissionID)).decodeRevokePermission()
+}
+
+// The revisions of a file. They are fetched each time a patient page is
+// opened, so they are cached for gdriveRevisionCacheTTL.
+func (w *GDriveWorker) ListRevisions(id string) ([]GDriveRevision, error) {
+	w.revisionCacheMu.Lock()
+	cached, ok := w.revisionCache[id]
+	w.revisionCacheMu.Unlock()
+	if ok && time.Since(cached.Fetched) < gdriveRevisionCacheTTL {
+		return slices.Clone(cached.Revisions), nil
+	}
+
+	revisions, err := w.Exec(newGDriveTaskRequestListRevisions(id)).decodeListRevisions()
+	if err != nil {
+		return nil, err
+	}
+
+	w.revisionCacheMu.Lock()
+	for key, entry := range w.revisionCache {
+		if time.Since(entry.Fetched) >= gdriveRevisionCacheTTL {
+			delete(w.revisionCache, key)
+		}
+	}
+	w.revisionCache[id] = cachedGDriveRevisions{
+		Revisions: revisions,
+		Fetched:   time.Now(),
+	}
+	w.revisionCacheMu.Unlock()
+
+	// Callers sort the revisions, so they each get their own copy
+	return slices.Clone(revisions), nil
+}
+
+func (w *GDriveWorker) ExportFile(id, mimeType string) ([]byte, error) {
+	return w.Exec(newGDriveTaskRequestExportFile(id, mimeType)).decodeExportFile()
+}
+
+func (w *GDriveWorker) CreateJournal(templateFile string, vars GDriveTemplateVars, key string) (GDriveItem, error) {
+	return w.Exec(newGDriveTaskRequestCreateJournal(templateFile, vars, key)).decodeCreateJournal()
+}
+
+// Read a journal template, to check that it can be used.
+func (w *GDriveWorker) ReadTemplate(id string) (GDriveJournal, error) {
+	return w.Exec(newGDriveTaskRequestReadDocument(id)).decodeReadDocument()
 }
 
 func (w *GDriveWorker) ListFiles(params ListFilesParams) (ListFilesResult, error) {
 	return w.Exec(newGDriveTaskRequestListFiles(params)).decodeListFiles()
 }
 
+func (w *GDriveWorker) ListChanges(pageToken string) (GDriveChanges, error) {
+	return w.Exec(newGDriveTaskRequestListChanges(pageToken)).decodeListChanges()
+}
+
+func (w *GDriveWorker) UpdateFile(params UpdateFileParams) (GDriveItem, error) {
+	return w.Exec(newGDriveTaskRequestUpdateFile(params)).decodeUpdateFile()
+}
+
+func (w *GDriveWorker) GetOrCreateFolder(parent, name string) (GDriveItem, error) {
+	return w.Exec(newGDriveTaskRequestGetOrCreateFolder(parent, name)).decodeGetOrCreateFolder()
+}
+
+func (w *GDriveWorker) GetStartPageToken() (string, error) {
+	return w.Exec(newGDriveTaskRequestGetStartPageToken()).decodeGetStartPageToken()
+}
+
 func (w *GDriveWorker) worker(workerID int) {
 	for {
 		req := <-w.in
@@ -310,10 +670,26 @@ func (w *GDriveWorker) handleRequest(req GDriveTaskRequest) GDriveTaskResponse {
 		return w.handleRequestGetFile(req)
 	case GDriveTaskRequestIDInviteUser:
 		return w.handleRequestInviteUser(req)
+	case GDriveTaskRequestIDRevokePermission:
+		return w.handleRequestRevokePermission(req)
+	case GDriveTaskRequestIDListRevisions:
+		return w.handleRequestListRevisions(req)
+	case GDriveTaskRequestIDExportFile:
+		return w.handleRequestExportFile(req)
 	case GDriveTaskRequestIDCreateJournal:
 		return w.handleRequestCreateJournal(req)
 	case GDriveTaskRequestIDListFiles:
 		return w.handleRequestListFiles(req)
+	case GDriveTaskRequestIDListChanges:
+		return w.handleRequestListChanges(req)
+	case GDriveTaskRequestIDGetStartPageToken:
+		return w.handleRequestGetStartPageToken(req)
+	case GDriveTaskRequestIDReadDocument:
+		return w.handleRequestReadDocument(req)
+	case GDriveTaskRequestIDUpdateFile:
+		return w.handleRequestUpdateFile(req)
+	case GDriveTaskRequestIDGetOrCreateFolder:
+		return w.handleRequestGetOrCreateFolder(req)
 	}
 	return w.errorResponse(req, fmt.Errorf("unknown request type"))
 }
@@ -324,7 +700,7 @@ func (w *GDriveWorker) handleRequestGetFile(req GDriveTaskRequest) GDriveTaskRes
 		return w.errorResponse(req, err)
 	}
 
-	item, err := w.g.GetFile(id)
+	item, err := w.client.GetFile(id)
 	if err != nil {
 		return w.errorResponse(req, err)
 	}
@@ -357,13 +733,83 @@ func (w *GDriveWorker) handleRequestInviteUser(req GDriveTaskRequest) GDriveTask
 	return w.successResponse(req, nil)
 }
 
+func (w *GDriveWorker) handleRequestRevokePermission(req GDriveTaskRequest) GDriveTaskResponse {
+	payload, err := req.decodeRevokePermission()
+	if err != nil {
+		return w.errorResponse(req, err)
+	}
+
+	getCall := w.g.Drive.Permissions.Get(payload.ID, payload.PermissionID).Fields("emailAddress")
+	if w.g.DriveBase != "" {
+		getCall = getCall.
+			SupportsAllDrives(true)
+	}
+	perm, err := getCall.Do()
+	if err != nil {
+		return w.errorResponse(req, err)
+	}
+	if strings.EqualFold(perm.EmailAddress, w.g.ServiceAccountEmail) {
+		return w.errorResponse(req, fmt.Errorf("%w: refusing to revoke the access of bino's service account", errGDriveTaskPermanent))
+	}
+
+	call := w.g.Drive.Permissions.Delete(payload.ID, payload.PermissionID)
+
+	if w.g.DriveBase != "" {
+		call = call.
+			SupportsAllDrives(true)
+	}
+
+	if err := call.Do(); err != nil {
+		return w.errorResponse(req, err)
+	}
+
+	return w.successResponse(req, nil)
+}
+
+func (w *GDriveWorker) handleRequestListRevisions(req GDriveTaskRequest) GDriveTaskResponse {
+	id, err := req.decodeListRevisions()
+	if err != nil {
+		return w.errorResponse(req, err)
+	}
+
+	revisions, err := w.client.ListRevisions(id)
+	if err != nil {
+		return w.errorResponse(req, err)
+	}
+
+	return w.successResponse(req, revisions)
+}
+
+func (w *GDriveWorker) handleRequestExportFile(req GDriveTaskRequest) GDriveTaskResponse {
+	payload, err := req.decodeExportFile()
+	if err != nil {
+		return w.errorResponse(req, err)
+	}
+
+	data, err := w.g.ExportFile(payload.ID, payload.MIMEType)
+	if err != nil {
+		return w.errorResponse(req, err)
+	}
+
+	return w.successResponse(req, data)
+}
+
 func (w *GDriveWorker) handleRequestCreateJournal(req GDriveTaskRequest) GDriveTaskResponse {
-	vars, err := req.decodeCreateJournal()
+	payload, err := req.decodeCreateJournal()
+	if err != nil {
+		return w.errorResponse(req, err)
+	}
+	info, err := w.GetGDriveConfigInfo()
 	if err != nil {
 		return w.errorResponse(req, err)
 	}
-	info := w.GetGDriveConfigInfo()
-	item, err := w.g.CreateDocument(info, vars)
+	template := info.TemplateDoc.Item
+	if payload.TemplateFile != "" && payload.TemplateFile != template.ID {
+		if template, err = w.client.GetFile(payload.TemplateFile); err != nil {
+			return w.errorResponse(req, fmt.Errorf("getting template: %w", err))
+		}
+	}
+	item, err := w.g.CreateDocument(template, info.JournalFolder.ID, payload.Vars, payload.Key)
 	if err != nil {
 		return w.errorResponse(req, err)
 	}
@@ -376,59 +822,77 @@ func (w *GDriveWorker) handleRequestListFiles(req GDriveTaskRequest) GDriveTaskR
 		return w.errorResponse(req, err)
 	}
 
-	folderItem, err := w.g.GetFile(params.Parent)
+	result, err := w.client.ListFiles(params)
 	if err != nil {
 		return w.errorResponse(req, err)
 	}
 
-	call := w.g.Drive.Files.List()
+	return w.successResponse(req, result)
+}
+
+func (w *GDriveWorker) handleRequestListChanges(req GDriveTaskRequest) GDriveTaskResponse {
+	pageToken, err := req.decodeListChanges()
+	if err != nil {
+		return w.errorResponse(req, err)
+	}
 
-	if w.g.DriveBase != "" {
-		call = call.
-			SupportsAllDrives(true)
-		call = call.DriveId(w.cfg.DriveBase)
-		call = call.IncludeItemsFromAllDrives(true)
-		call = call.Corpora("drive")
+	changes, err := w.client.ListChanges(pageToken)
+	if err != nil {
+		return w.errorResponse(req, err)
 	}
 
-	rules := []string{
-		"mimeType = 'application/vnd.google-apps.document'",
-		fmt.Sprintf("'%s' in parents", params.Parent),
+	return w.successResponse(req, changes)
+}
+
+func (w *GDriveWorker) handleRequestGetStartPageToken(req GDriveTaskRequest) GDriveTaskResponse {
+	pageToken, err := w.client.GetStartPageToken()
+	if err != nil {
+		return w.errorResponse(req, err)
 	}
 
-	if !params.ModifiedAfter.IsZero() {
-		rules = append(rules, fmt.Sprintf("modifiedTime > '%s'", params.ModifiedAfter.Format(timeFormatDriveQ)))
+	return w.successResponse(req, pageToken)
+}
+
+func (w *GDriveWorker) handleRequestReadDocument(req GDriveTaskRequest) GDriveTaskResponse {
+	id, err := req.decodeReadDocument()
+	if err != nil {
+		return w.errorResponse(req, err)
 	}
-	if !params.ModifiedBefore.IsZero() {
-		rules = append(rules, fmt.Sprintf("modifiedTime < '%s'", params.ModifiedBefore.Format(timeFormatDriveQ)))
+
+	doc, err := w.client.ReadDocument(id)
+	if err != nil {
+		return w.errorResponse(req, err)
 	}
 
-	q := strings.Join(rules, " and ")
-	call = call.Q(q)
+	return w.successResponse(req, doc)
+}
 
-	if params.PageToken != "" {
-		call = call.PageToken(params.PageToken)
+func (w *GDriveWorker) handleRequestUpdateFile(req GDriveTaskRequest) GDriveTaskResponse {
+	params, err := req.decodeUpdateFile()
+	if err != nil {
+		return w.errorResponse(req, err)
 	}
 
-	call = call.OrderBy("modifiedTime desc")
-	call = call.Fields("files(id, name, modifiedTime, createdTime, trashed), nextPageToken")
+	item, err := w.g.UpdateFile(params)
+	if err != nil {
+		return w.errorResponse(req, err)
+	}
 
-	fileList, err := call.Do()
+	return w.successResponse(req, item)
+}
+
+func (w *GDriveWorker) handleRequestGetOrCreateFolder(req GDriveTaskRequest) GDriveTaskResponse {
+	payload, err := req.decodeGetOrCreateFolder()
 	if err != nil {
 		return w.errorResponse(req, err)
 	}
 
-	return GDriveTaskResponse{
-		Type: GDriveTaskRequestIDListFiles,
-		Payload: ListFilesResult{
-			Folder: folderItem,
-			Files: SliceToSlice(fileList.Files, func(in *drive.File) GDriveItem {
-				return GDriveItemFromFile(in, nil)
-			}),
-			NextPageToken: fileList.NextPageToken,
-		},
-		Error: nil,
+	item, err := w.g.GetOrCreateFolder(payload.Parent, payload.Name)
+	if err != nil {
+		return w.errorResponse(req, err)
 	}
+
+	return w.successResponse(req, item)
 }
 
 func (w *GDriveWorker) errorResponse(req GDriveTaskRequest, err error) GDriveTaskResponse {
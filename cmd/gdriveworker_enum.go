@@ -19,11 +19,31 @@ const (
 	GDriveTaskRequestIDCreateJournal GDriveTaskRequestID = 2
 	// GDriveTaskRequestIDListFiles is a GDriveTaskRequestID of type ListFiles.
 	GDriveTaskRequestIDListFiles GDriveTaskRequestID = 3
+	// GDriveTaskRequestIDListChanges is a GDriveTaskRequestID of type ListChanges.
+	GDriveTaskRequestIDListChanges GDriveTaskRequestID = 4
+	// GDriveTaskRequestIDUpdateFile is a GDriveTaskRequestID of type UpdateFile.
+	GDriveTaskRequestIDUpdateFile GDriveTaskRequestID = 5
+	// GDriveTaskRequestIDGetOrCreateFolder is a GDriveTaskRequestID of type GetOrCreateFolder.
+	GDriveTaskRequestIDGetOrCreateFolder GDriveTaskRequestID = 6
+	// GDriveTaskRequestIDUpdatePatientJournal is a GDriveTaskRequestID of type UpdatePatientJournal.
+	GDriveTaskRequestIDUpdatePatientJournal GDriveTaskRequestID = 7
+	// GDriveTaskRequestIDRevokePermission is a GDriveTaskRequestID of type RevokePermission.
+	GDriveTaskRequestIDRevokePermission GDriveTaskRequestID = 8
+	// GDriveTaskRequestIDListRevisions is a GDriveTaskRequestID of type ListRevisions.
+	GDriveTaskRequestIDListRevisions GDriveTaskRequestID = 9
+	// GDriveTaskRequestIDExportFile is a GDriveTaskRequestID of type ExportFile.
+	GDriveTaskRequestIDExportFile GDriveTaskRequestID = 10
+	// GDriveTaskRequestIDArchivePatientJournal is a GDriveTaskRequestID of type ArchivePatientJournal.
+	GDriveTaskRequestIDArchivePatientJournal GDriveTaskRequestID = 11
+	// GDriveTaskRequestIDGetStartPageToken is a GDriveTaskRequestID of type GetStartPageToken.
+	GDriveTaskRequestIDGetStartPageToken GDriveTaskRequestID = 12
+	// GDriveTaskRequestIDReadDocument is a GDriveTaskRequestID of type ReadDocument.
+	GDriveTaskRequestIDReadDocument GDriveTaskRequestID = 13
 )
 
 var ErrInvalidGDriveTaskRequestID = errors.New("not a valid GDriveTaskRequestID")
 
-const _GDriveTaskRequestIDName = "GetFileInviteUserCreateJournalListFiles"
+const _GDriveTaskRequestIDName = "GetFileInviteUserCreateJournalListFilesListChangesUpdateFileGetOrCreateFolderUpdatePatientJournalRevokePermissionListRevisionsExportFileArchivePatientJournalGetStartPageTokenReadDocument"
 
 // GDriveTaskRequestIDValues returns a list of the values for GDriveTaskRequestID
 func GDriveTaskRequestIDValues() []GDriveTaskRequestID {
@@ -32,14 +52,34 @@ func GDriveTaskRequestIDValues() []GDriveTaskRequestID {
 		GDriveTaskRequestIDInviteUser,
 		GDriveTaskRequestIDCreateJournal,
 		GDriveTaskRequestIDListFiles,
+		GDriveTaskRequestIDListChanges,
+		GDriveTaskRequestIDUpdateFile,
+		GDriveTaskRequestIDGetOrCreateFolder,
+		GDriveTaskRequestIDUpdatePatientJournal,
+		GDriveTaskRequestIDRevokePermission,
+		GDriveTaskRequestIDListRevisions,
+		GDriveTaskRequestIDExportFile,
+		GDriveTaskRequestIDArchivePatientJournal,
+		GDriveTaskRequestIDGetStartPageToken,
+		GDriveTaskRequestIDReadDocument,
 	}
 }
 
 var _GDriveTaskRequestIDMap = map[GDriveTaskRequestID]string{
-	GDriveTaskRequestIDGetFile:       _GDriveTaskRequestIDName[0:7],
-	GDriveTaskRequestIDInviteUser:    _GDriveTaskRequestIDName[7:17],
-	GDriveTaskRequestIDCreateJournal: _GDriveTaskRequestIDName[17:30],
-	GDriveTaskRequestIDListFiles:     _GDriveTaskRequestIDName[30:39],
+	GDriveTaskRequestIDGetFile:               _GDriveTaskRequestIDName[0:7],
+	GDriveTaskRequestIDInviteUser:            _GDriveTaskRequestIDName[7:17],
+	GDriveTaskRequestIDCreateJournal:         _GDriveTaskRequestIDName[17:30],
+	GDriveTaskRequestIDListFiles:             _GDriveTaskRequestIDName[30:39],
+	GDriveTaskRequestIDListChanges:           _GDriveTaskRequestIDName[39:50],
+	GDriveTaskRequestIDUpdateFile:            _GDriveTaskRequestIDName[50:60],
+	GDriveTaskRequestIDGetOrCreateFolder:     _GDriveTaskRequestIDName[60:77],
+	GDriveTaskRequestIDUpdatePatientJournal:  _GDriveTaskRequestIDName[77:97],
+	GDriveTaskRequestIDRevokePermission:      _GDriveTaskRequestIDName[97:113],
+	GDriveTaskRequestIDListRevisions:         _GDriveTaskRequestIDName[113:126],
+	GDriveTaskRequestIDExportFile:            _GDriveTaskRequestIDName[126:136],
+	GDriveTaskRequestIDArchivePatientJournal: _GDriveTaskRequestIDName[136:157],
+	GDriveTaskRequestIDGetStartPageToken:     _GDriveTaskRequestIDName[157:174],
+	GDriveTaskRequestIDReadDocument:          _GDriveTaskRequestIDName[174:186],
 }
 
 // String implements the Stringer interface.
@@ -58,10 +98,20 @@ func (x GDriveTaskRequestID) IsValid() bool {
 }
 
 var _GDriveTaskRequestIDValue = map[string]GDriveTaskRequestID{
-	_GDriveTaskRequestIDName[0:7]:   GDriveTaskRequestIDGetFile,
-	_GDriveTaskRequestIDName[7:17]:  GDriveTaskRequestIDInviteUser,
-	_GDriveTaskRequestIDName[17:30]: GDriveTaskRequestIDCreateJournal,
-	_GDriveTaskRequestIDName[30:39]: GDriveTaskRequestIDListFiles,
+	_GDriveTaskRequestIDName[0:7]:     GDriveTaskRequestIDGetFile,
+	_GDriveTaskRequestIDName[7:17]:    GDriveTaskRequestIDInviteUser,
+	_GDriveTaskRequestIDName[17:30]:   GDriveTaskRequestIDCreateJournal,
+	_GDriveTaskRequestIDName[30:39]:   GDriveTaskRequestIDListFiles,
+	_GDriveTaskRequestIDName[39:50]:   GDriveTaskRequestIDListChanges,
+	_GDriveTaskRequestIDName[50:60]:   GDriveTaskRequestIDUpdateFile,
+	_GDriveTaskRequestIDName[60:77]:   GDriveTaskRequestIDGetOrCreateFolder,
+	_GDriveTaskRequestIDName[77:97]:   GDriveTaskRequestIDUpdatePatientJournal,
+	_GDriveTaskRequestIDName[97:113]:  GDriveTaskRequestIDRevokePermission,
+	_GDriveTaskRequestIDName[113:126]: GDriveTaskRequestIDListRevisions,
+	_GDriveTaskRequestIDName[126:136]: GDriveTaskRequestIDExportFile,
+	_GDriveTaskRequestIDName[136:157]: GDriveTaskRequestIDArchivePatientJournal,
+	_GDriveTaskRequestIDName[157:174]: GDriveTaskRequestIDGetStartPageToken,
+	_GDriveTaskRequestIDName[174:186]: GDriveTaskRequestIDReadDocument,
 }
 
 // ParseGDriveTaskRequestID attempts to convert a string to a GDriveTaskRequestID.
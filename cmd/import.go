@@ -15,6 +15,9 @@ type ImportRequest struct {
 	OK             bool
 	Notes          []string
 	Txt            string
+	// The batch of patients from the last import that needed a journal, to
+	// show progress for
+	JournalBatch int32
 }
 
 type ImportPatient struct {
@@ -31,7 +34,34 @@ func (server *Server) getImportHandler(w http.ResponseWriter, r *http.Request) {
 	var ir ImportRequest
 	server.getCookie(w, r, "import-request", &ir)
 
-	_ = ImportPage(commonData, ir).Render(ctx, w)
+	_ = ImportPage(commonData, ir, server.getJournalCreationProgress(r, ir.JournalBatch)).Render(ctx, w)
+}
+
+func (server *Server) ajaxImportProgressHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	var ir ImportRequest
+	server.getCookie(w, r, "import-request", &ir)
+
+	_ = ImportProgress(commonData, server.getJournalCreationProgress(r, ir.JournalBatch)).Render(ctx, w)
+}
+
+func (server *Server) getJournalCreationProgress(r *http.Request, batch int32) []JournalCreationProgressView {
+	if batch == 0 {
+		return nil
+	}
+	commonData := MustLoadCommonData(r.Context())
+	rows, err := server.Queries.GetJournalCreationProgress(r.Context(), GetJournalCreationProgressParams{
+		TaskType:  int32(GDriveTaskRequestIDCreateJournal),
+		BatchID:   batch,
+		AppuserID: commonData.User.AppuserID,
+	})
+	if err != nil {
+		LogR(r, "getting journal creation progress: %v", err)
+		return nil
+	}
+	return SliceToSlice(rows, GetJournalCreationProgressRow.ToJournalCreationProgressView)
 }
 
 func (server *Server) postImportHandler(w http.ResponseWriter, r *http.Request) {
@@ -41,6 +71,7 @@ func (server *Server) postImportHandler(w http.ResponseWriter, r *http.Request)
 	result := server.parseImportForm(r)
 	if result.OK {
 		var patientsRequiringJournal []int32
+		var batch int32
 
 		if err := server.Transaction(ctx, func(ctx context.Context, q *Queries) error {
 			addPatientParams := AddPatientsParams{}
@@ -75,23 +106,66 @@ func (server *Server) postImportHandler(w http.ResponseWriter, r *http.Request)
 					return err
 				}
 			}
+			if len(patientsRequiringJournal) > 0 {
+				if err := q.DeleteOldImportBatches(ctx); err != nil {
+					return err
+				}
+				var err error
+				if batch, err = q.AddImportBatch(ctx, commonData.User.AppuserID); err != nil {
+					result.Notes = []string{fmt.Sprintf("Error adding import batch: %v", err)}
+					return err
+				}
+				if err := q.AddImportBatchPatients(ctx, AddImportBatchPatientsParams{
+					BatchID:    batch,
+					PatientIds: patientsRequiringJournal,
+				}); err != nil {
+					result.Notes = []string{fmt.Sprintf("Error adding import batch: %v", err)}
+					return err
+				}
+			}
 			return nil
 		}); err == nil {
 			if len(patientsRequiringJournal) > 0 {
 				result.Notes = append(result.Notes, fmt.Sprintf("Creating %d journals in the background...", len(patientsRequiringJournal)))
-				go server.tryCreateJournals(patientsRequiringJournal)
+				result.Notes = append(result.Notes, server.tryCreateJournals(ctx, patientsRequiringJournal)...)
+				result.JournalBatch = batch
 			}
 		}
 	}
 
 	if err := server.setCookie(w, r, "import-request", &result); err != nil {
-		LogR(r, "setting import-request cookie: %w")
+		LogR(r, "setting import-request cookie: %v", err)
 	}
 
 	server.redirect(w, r, "/import")
 }
 
-func (server *Server) tryCreateJournals(ids []int32) {
+// Create journals for imported patients. Google Drive journals are queued and
+// created one at a time by the task queue, which survives restarts. Returns
+// notes about patients for which it failed.
+func (server *Server) tryCreateJournals(ctx context.Context, ids []int32) []string {
+	commonData := MustLoadCommonData(ctx)
+
+	var notes []string
+	for _, id := range ids {
+		patient, err := server.Queries.GetPatientWithSpecies(ctx, GetPatientWithSpeciesParams{
+			ID:         id,
+			LanguageID: int32(server.Config().SystemLanguage),
+		})
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("%s: failed to look up patient: %v", PatientURL(id), err))
+			continue
+		}
+		if _, err := server.createPatientJournal(ctx, payloadCreatePatientJournal{
+			PatientID: id,
+			HomeID:    patient.CurrHomeID.Int32,
+			AppuserID: commonData.User.AppuserID,
+			Vars:      server.journalVars(ctx, patient),
+		}); err != nil {
+			notes = append(notes, fmt.Sprintf("%s: failed to create journal: %v", PatientURL(id), err))
+		}
+	}
+	return notes
 }
 
 func (server *Server) ajaxImportValidateHandler(w http.ResponseWriter, r *http.Request) {
@@ -99,8 +173,12 @@ func (server *Server) ajaxImportValidateHandler(w http.ResponseWriter, r *http.R
 	commonData := MustLoadCommonData(ctx)
 
 	result := server.parseImportForm(r)
+	// Keep showing progress for the previous import while editing the next one
+	var prev ImportRequest
+	server.getCookie(w, r, "import-request", &prev)
+	result.JournalBatch = prev.JournalBatch
 	if err := server.setCookie(w, r, "import-request", &result); err != nil {
-		LogR(r, "setting import-request cookie from AJAX: %w")
+		LogR(r, "setting import-request cookie from AJAX: %v", err)
 	}
 
 	_ = ImportValidation(commonData, result).Render(ctx, w)
@@ -148,7 +226,7 @@ func (server *Server) parseImportForm(r *http.Request) ImportRequest {
 		}
 		url := ""
 		if len(fields) >= 4 {
-			url = journalRegex.FindString(fields[3])
+			url = parseJournalURL(fields[3])
 			if url == "" {
 				out.Notes = append(out.Notes, fmt.Sprintf("line %d: '%s' doesn't seem like a journal URL", i, fields[3]))
 			}
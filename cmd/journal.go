@@ -0,0 +1,527 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/russross/blackfriday/v2"
+)
+
+// Key for the Markdown journals in search_sync_state
+const markdownJournalSyncID = "markdown"
+
+var errPatientHasJournal = errors.New("patient already has a journal")
+
+// Render journal Markdown as HTML. Raw HTML in the Markdown is dropped and only
+// links with safe protocols are kept, so the output can be embedded in a page.
+func renderMarkdown(content string) string {
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+		Flags: blackfriday.SkipHTML | blackfriday.SkipImages | blackfriday.Safelink | blackfriday.NofollowLinks | blackfriday.NoreferrerLinks,
+	})
+	return string(blackfriday.Run([]byte(content), blackfriday.WithRenderer(renderer)))
+}
+
+// The indexed content of the patient's journal, rendered for the patient page.
+// Only available if the user is allowed to read the journal, as in search.
+func (server *Server) patientJournalPreview(ctx context.Context, patient int32, home pgtype.Int4) (JournalPreviewView, bool) {
+	commonData := MustLoadCommonData(ctx)
+
+	if !server.searchBodyAccess(&commonData.User).JournalVisible(home) {
+		return JournalPreviewView{}, false
+	}
+
+	entry, err := server.Queries.GetSearchEntryBody(ctx, GetSearchEntryBodyParams{
+		Namespace:     string(MatchTypePatient),
+		AssociatedUrl: pgtype.Text{String: PatientURL(patient), Valid: true},
+	})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			LogCtx(ctx, "getting journal preview: %v", err)
+		}
+		return JournalPreviewView{}, false
+	}
+
+	// The indexed body ends with text from the extra data, which is not part of the journal
+	content := entry.Body
+	var info SearchPatientInfo
+	if entry.ExtraData.Valid && json.Unmarshal([]byte(entry.ExtraData.String), &info) == nil {
+		content = strings.TrimSuffix(content, info.IndexableText())
+	}
+	if strings.TrimSpace(content) == "" {
+		return JournalPreviewView{}, false
+	}
+
+	return JournalPreviewView{
+		HTML:    renderMarkdown(content),
+		Updated: entry.Updated.Time,
+	}, true
+}
+
+// The Markdown journals among ids that the user can read. As in search, users
+// who can't read every journal can read the ones attached to patients in their
+// homes.
+func (server *Server) visibleMarkdownJournals(ctx context.Context, user *UserData, ids []int32) (map[int32]bool, error) {
+	access := server.searchBodyAccess(user)
+	visible := map[int32]bool{}
+	if access.All {
+		for _, id := range ids {
+			visible[id] = true
+		}
+		return visible, nil
+	}
+
+	rows, err := server.Queries.GetPatientHomesByJournalURL(ctx, SliceToSlice(ids, MarkdownJournalURL))
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if id, ok := parseMarkdownJournalURL(row.JournalUrl); ok && access.JournalVisible(row.CurrHomeID) {
+			visible[id] = true
+		}
+	}
+	return visible, nil
+}
+
+// Show the unauthorized page unless the user can read the Markdown journal.
+func (server *Server) checkMarkdownJournalVisible(w http.ResponseWriter, r *http.Request, id int32) bool {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	visible, err := server.visibleMarkdownJournals(ctx, &commonData.User, []int32{id})
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return false
+	}
+	if !visible[id] {
+		server.renderUnauthorized(w, r, commonData, errors.New(commonData.User.Language.GenericUnauthorized))
+		return false
+	}
+	return true
+}
+
+func (server *Server) getJournalsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	journals, err := server.Queries.GetMarkdownJournals(ctx)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	visible, err := server.visibleMarkdownJournals(ctx, &commonData.User, SliceToSlice(journals, func(j GetMarkdownJournalsRow) int32 {
+		return j.ID
+	}))
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+	journals = FilterSlice(journals, func(j GetMarkdownJournalsRow) bool {
+		return visible[j.ID]
+	})
+
+	_ = JournalsPage(commonData, SliceToSlice(journals, GetMarkdownJournalsRow.ToJournalView)).Render(ctx, w)
+}
+
+func (server *Server) getJournalHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	id, err := server.getPathID(r, "journal")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+	if !server.checkMarkdownJournalVisible(w, r, id) {
+		return
+	}
+
+	journal, err := server.Queries.GetMarkdownJournal(ctx, id)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	_, editing := r.URL.Query()["edit"]
+	server.renderJournalPage(w, r, journal.ToJournalView(), editing)
+}
+
+func (server *Server) renderJournalPage(w http.ResponseWriter, r *http.Request, view JournalView, editing bool) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	ids, err := server.Queries.GetPatientsWithJournalURL(ctx, pgtype.Text{String: view.URL(), Valid: true})
+	if err != nil {
+		LogR(r, "getting patients for journal %d: %v", view.ID, err)
+	}
+	for _, id := range ids {
+		if patient, err := server.Queries.GetPatient(ctx, id); err == nil {
+			view.Patients = append(view.Patients, PatientView{ID: patient.ID, Name: patient.Name})
+		}
+	}
+
+	_ = JournalPage(commonData, view, editing).Render(ctx, w)
+}
+
+func (server *Server) postJournalHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	id, err := server.getPathID(r, "journal")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+	if !server.checkMarkdownJournalVisible(w, r, id) {
+		return
+	}
+
+	fields, err := server.getFormValues(r, "title", "content")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+	version, err := server.getFormID(r, "version")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	title := strings.TrimSpace(fields["title"])
+	content := strings.ReplaceAll(fields["content"], "\r\n", "\n")
+
+	n, err := server.Queries.UpdateMarkdownJournal(ctx, UpdateMarkdownJournalParams{
+		ID:        id,
+		Version:   version,
+		Title:     title,
+		Content:   content,
+		UpdatedBy: pgtype.Int4{Int32: commonData.User.AppuserID, Valid: true},
+	})
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	if n == 0 {
+		// Someone else saved in the meantime. Show the edits again on top of
+		// the latest version, so they can be merged by hand and saved again.
+		journal, err := server.Queries.GetMarkdownJournal(ctx, id)
+		if err != nil {
+			server.renderError(w, r, commonData, err)
+			return
+		}
+		commonData.Warning(commonData.User.Language.JournalEditConflict(journal.UpdatedByName), nil)
+		view := journal.ToJournalView()
+		view.Title = title
+		view.Content = content
+		server.renderJournalPage(w, r, view, true)
+		return
+	}
+
+	if err := server.indexJournal(ctx, MarkdownJournalURL(id)); err != nil {
+		LogR(r, "indexing journal %d: %v", id, err)
+	}
+
+	commonData.Success(commonData.User.Language.JournalSaved)
+	server.redirect(w, r, MarkdownJournalURL(id))
+}
+
+// Create a journal for a patient and attach it. Google Drive journals are
+// created by the task queue, in which case this returns before the journal
+// exists and queued is true.
+func (server *Server) createPatientJournal(ctx context.Context, payload payloadCreatePatientJournal) (queued bool, err error) {
+	if server.Config().Journal.Backend == JournalBackendIDGDrive {
+		if payload.TemplateFile == "" {
+			templateFile, err := server.journalTemplateFileForPatient(ctx, payload.PatientID)
+			if err != nil {
+				return false, err
+			}
+			payload.TemplateFile = templateFile
+		}
+		_, err := server.GDriveWorker.EnqueueCreatePatientJournal(ctx, payload)
+		return true, err
+	}
+
+	// The journal is created in the same transaction as it is attached, so
+	// that no journal is left behind if the patient got one in the meantime
+	var item JournalItem
+	if err := server.Transaction(ctx, func(ctx context.Context, q *Queries) error {
+		journals := server.Journals
+		if markdown, ok := journals.(*MarkdownJournalBackend); ok {
+			journals = markdown.WithQueries(q)
+		}
+
+		var err error
+		if item, err = journals.CreateFromTemplate(ctx, payload.Vars); err != nil {
+			return err
+		}
+
+		n, err := q.SetPatientJournalIfMissing(ctx, SetPatientJournalIfMissingParams{
+			ID:         payload.PatientID,
+			JournalUrl: pgtype.Text{String: item.URL, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errPatientHasJournal
+		}
+
+		_, err = q.AddPatientEvent(ctx, AddPatientEventParams{
+			PatientID: payload.PatientID,
+			HomeID:    payload.HomeID,
+			EventID:   int32(EventJournalCreated),
+			AppuserID: payload.AppuserID,
+			Time:      pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		})
+		return err
+	}); err != nil {
+		return false, err
+	}
+
+	server.indexAttachedJournal(ctx, item.URL)
+	return false, nil
+}
+
+// Template variables for the journal of a patient as it is now. The
+// outcome and checkout date are only set for checked out patients. Values
+// that can't be looked up are left empty, so that their fallback is used.
+func patientJournalVars(ctx context.Context, q *Queries, patient GetPatientWithSpeciesRow, lang *Language, binoURL string) GDriveTemplateVars {
+	created := patient.TimeCheckin.Time
+	if registered, err := q.GetFirstEventOfTypeForPatient(ctx, GetFirstEventOfTypeForPatientParams{
+		PatientID: patient.ID,
+		EventID:   int32(EventRegistered),
+	}); err == nil && registered.Valid {
+		created = registered.Time
+	}
+
+	vars := GDriveTemplateVars{
+		Time:        created,
+		Name:        patient.Name,
+		Species:     patient.SpeciesName,
+		BinoURL:     binoURL,
+		CaseNumber:  strconv.Itoa(int(patient.ID)),
+		Finder:      patient.Finder,
+		IntakeCause: patient.IntakeCause,
+	}
+	if scientificName, err := q.GetScientificNameOfSpecies(ctx, patient.SpeciesID); err == nil {
+		vars.ScientificName = scientificName
+	}
+	if patient.CurrHomeID.Valid {
+		if home, err := q.GetHome(ctx, patient.CurrHomeID.Int32); err == nil {
+			vars.Home = home.Name
+		}
+		if users, err := q.GetAppusersForHome(ctx, patient.CurrHomeID.Int32); err == nil {
+			vars.Responsible = strings.Join(SliceToSlice(users, func(u Appuser) string {
+				return u.DisplayName
+			}), ", ")
+		}
+	}
+	if IsCheckoutStatus[Status(patient.Status)] {
+		vars.Outcome = lang.Status[Status(patient.Status)]
+		vars.Checkout = patient.TimeCheckout.Time
+	}
+	return vars
+}
+
+// Template variables for the journal of a patient, in the system language.
+func (server *Server) journalVars(ctx context.Context, patient GetPatientWithSpeciesRow) GDriveTemplateVars {
+	return patientJournalVars(ctx, server.Queries, patient, GetLanguage(int32(server.Config().SystemLanguage)), server.Config().BinoURLForPatient(patient.ID))
+}
+
+// Queue storing a PDF copy of the journal of a patient who was checked out,
+// since the document in Drive can still be changed or deleted.
+func (server *Server) archivePatientJournal(ctx context.Context, patient Patient, appuserID int32) error {
+	if _, ok := gdriveDocumentID(patient.JournalUrl.String); !ok || server.GDriveWorker == nil {
+		return nil
+	}
+	_, err := server.GDriveWorker.EnqueueArchivePatientJournal(ctx, payloadArchivePatientJournal{
+		PatientID: patient.ID,
+		AppuserID: appuserID,
+		HomeID:    patient.CurrHomeID.Int32,
+	})
+	return err
+}
+
+// Bring the name of a patient's journal up to date after the patient was
+// checked out, readmitted or renamed. Google Drive journals are also moved to
+// or from the archive, which happens in the task queue.
+func (server *Server) updatePatientJournal(ctx context.Context, patientID int32) error {
+	patient, err := server.Queries.GetPatientWithSpecies(ctx, GetPatientWithSpeciesParams{
+		ID:         patientID,
+		LanguageID: int32(server.Config().SystemLanguage),
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, ok := gdriveDocumentID(patient.JournalUrl.String); ok && server.GDriveWorker != nil {
+		_, err := server.GDriveWorker.EnqueueUpdatePatientJournal(ctx, payloadUpdatePatientJournal{
+			PatientID:  patientID,
+			LanguageID: server.Config().SystemLanguage,
+			BinoURL:    server.Config().BinoURLForPatient(patientID),
+		})
+		return err
+	}
+
+	id, ok := parseMarkdownJournalURL(patient.JournalUrl.String)
+	if !ok {
+		return nil
+	}
+	title := markdownJournalTitleTemplate
+	if IsCheckoutStatus[Status(patient.Status)] {
+		title += archivedJournalNameSuffix
+	}
+	vars := server.journalVars(ctx, patient)
+	if err := server.Queries.SetMarkdownJournalTitle(ctx, SetMarkdownJournalTitleParams{
+		ID:    id,
+		Title: vars.ApplyToString(title),
+	}); err != nil {
+		return err
+	}
+	server.indexAttachedJournal(ctx, patient.JournalUrl.String)
+	return nil
+}
+
+// Index a journal stored in bino right away when it's attached to a patient, so
+// that it shows up in search as that patient. Drive journals are picked up by
+// the Drive indexer.
+func (server *Server) indexAttachedJournal(ctx context.Context, url string) {
+	if _, ok := parseMarkdownJournalURL(url); !ok || server.Config().Journal.Backend != JournalBackendIDMarkdown {
+		return
+	}
+	if err := server.indexJournal(ctx, url); err != nil {
+		LogCtx(ctx, "indexing journal %s: %v", url, err)
+	}
+}
+
+// Keep the search index up to date with the journals of a backend. Only used
+// for Markdown journals, since journals in Google Drive are indexed by the
+// GDriveWorker along with the other files there.
+func (server *Server) backgroundIndexJournals(ctx context.Context, journals JournalBackend) {
+	for {
+		if err := server.indexChangedJournals(ctx, journals); err != nil {
+			log.Printf("ERROR: indexing journals: %v", err)
+		}
+		select {
+		case <-time.After(searchIndexPollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (server *Server) indexChangedJournals(ctx context.Context, journals JournalBackend) error {
+	state, err := server.Queries.GetSearchSyncState(ctx, markdownJournalSyncID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("loading search sync state: %w", err)
+	}
+
+	changes, err := journals.ListChanged(ctx, state.PageToken)
+	if err != nil {
+		return err
+	}
+	for _, item := range changes.Items {
+		if err := server.indexJournal(ctx, item.URL); err != nil {
+			log.Printf("ERROR (%s): %v", item.Title, err)
+		}
+	}
+	if len(changes.Items) > 0 {
+		log.Printf("Indexed %d journals", len(changes.Items))
+	}
+
+	if state.PageToken == "" {
+		return server.Queries.SetSearchSyncFull(ctx, SetSearchSyncFullParams{
+			DriveID:   markdownJournalSyncID,
+			PageToken: changes.Cursor,
+		})
+	}
+	return server.Queries.SetSearchSyncIncremental(ctx, SetSearchSyncIncrementalParams{
+		DriveID:   markdownJournalSyncID,
+		PageToken: changes.Cursor,
+	})
+}
+
+// Read a journal from the configured backend and update its search entry. A
+// journal attached to a patient is indexed as that patient.
+func (server *Server) indexJournal(ctx context.Context, url string) error {
+	doc, err := server.Journals.Read(ctx, url)
+	if err != nil {
+		return fmt.Errorf("reading journal: %w", err)
+	}
+	item := doc.Item
+
+	ids, err := server.Queries.GetPatientsWithJournalURL(ctx, pgtype.Text{String: item.URL, Valid: true})
+	if err != nil {
+		return fmt.Errorf("querying patients by journal URL: %w", err)
+	}
+
+	journalInfo := SearchJournalInfo{
+		FolderURL:  "/journals",
+		FolderName: "bino",
+	}
+	var extraData interface{ IndexableText() string }
+	extraData = &journalInfo
+	namespace := string(MatchTypeJournal)
+	associatedURL := item.URL
+	if len(ids) == 1 {
+		namespace = string(MatchTypePatient)
+		associatedURL = PatientURL(ids[0])
+		extraData = &SearchPatientInfo{
+			JournalInfo: journalInfo,
+			JournalURL:  item.URL,
+		}
+		// Drop the entry from before the journal was attached
+		if err := server.Queries.DeleteSearchEntry(ctx, DeleteSearchEntryParams{
+			Namespace:     string(MatchTypeJournal),
+			AssociatedUrl: pgtype.Text{String: item.URL, Valid: true},
+		}); err != nil {
+			return fmt.Errorf("deleting unattached entry: %w", err)
+		}
+	}
+
+	extraDataField := pgtype.Text{}
+	if extraDataStr, err := json.Marshal(extraData); err == nil {
+		extraDataField = pgtype.Text{String: string(extraDataStr), Valid: true}
+	}
+
+	// If the title starts with a date, use that as the created-time
+	created := item.Created
+	if fields := strings.Fields(item.Title); len(fields) > 0 {
+		if t, err := time.Parse(time.DateOnly, fields[0]); err == nil {
+			created = t
+		}
+	}
+
+	if server.Config().Journal.ParseData && len(ids) == 1 {
+		if err := importJournalData(ctx, server.Queries, ids[0], item.URL, doc.Content); err != nil {
+			LogCtx(ctx, "importing journal data: %v", err)
+		}
+	}
+
+	lang := server.Config().SystemLanguage
+	if detected, ok := DetectLanguage(doc.Content); ok {
+		lang = detected
+	}
+
+	return server.Queries.UpsertSearchEntry(ctx, UpsertSearchEntryParams{
+		Namespace:     namespace,
+		AssociatedUrl: pgtype.Text{String: associatedURL, Valid: true},
+		Updated:       pgtype.Timestamptz{Time: item.Modified, Valid: !item.Modified.IsZero()},
+		Created:       pgtype.Timestamptz{Time: created, Valid: true},
+		Header:        pgtype.Text{String: item.Title, Valid: true},
+		Lang:          lang.Regconfig(),
+		ExtraData:     extraDataField,
+
+		Body: pgtype.Text{String: doc.Content + extraData.IndexableText(), Valid: true},
+	})
+}
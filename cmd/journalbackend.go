@@ -0,0 +1,313 @@
+//go:generate go tool go-enum --no-iota --values
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ENUM(GDrive = 0, Markdown)
+type JournalBackendID int32
+
+type JournalConfig struct {
+	// Where new journals are created. Journals already attached to patients
+	// keep working regardless of the backend.
+	Backend JournalBackendID
+	// Markdown file used as template by the Markdown backend. A built-in
+	// template is used if empty.
+	MarkdownTemplateLocation string
+	// Import weights and medications from tables in journals attached to
+	// patients
+	ParseData bool
+}
+
+var markdownJournalRegex = regexp.MustCompile(`/journal/\d+`)
+
+// The canonical form of a journal URL from any backend, or "" if the URL is not a journal.
+func parseJournalURL(url string) string {
+	if baseURL := journalRegex.FindString(url); baseURL != "" {
+		return baseURL
+	}
+	return markdownJournalRegex.FindString(url)
+}
+
+// INTERFACE
+
+type JournalItem struct {
+	URL      string
+	Title    string
+	Created  time.Time
+	Modified time.Time
+	// The journal was deleted or access to it was lost
+	Trashed bool
+}
+
+type JournalDocument struct {
+	Item    JournalItem
+	Content string
+}
+
+type JournalChanges struct {
+	Items []JournalItem
+	// Pass to the next call to ListChanged to get the changes after these
+	Cursor string
+}
+
+type JournalBackend interface {
+	// Create a new journal from the template
+	CreateFromTemplate(ctx context.Context, vars GDriveTemplateVars) (JournalItem, error)
+	// Read a journal as Markdown
+	Read(ctx context.Context, url string) (JournalDocument, error)
+	// List the journals changed since the cursor. An empty cursor lists all journals.
+	ListChanged(ctx context.Context, cursor string) (JournalChanges, error)
+	// Give a user write access to the journals
+	Share(ctx context.Context, email string) error
+}
+
+// GOOGLE DRIVE
+
+type GDriveJournalBackend struct {
+	w *GDriveWorker
+}
+
+func NewGDriveJournalBackend(w *GDriveWorker) *GDriveJournalBackend {
+	return &GDriveJournalBackend{w: w}
+}
+
+// The document ID in a Google Docs URL.
+func gdriveDocumentID(url string) (string, bool) {
+	baseURL := journalRegex.FindString(url)
+	if baseURL == "" {
+		return "", false
+	}
+	return strings.TrimPrefix(baseURL, "https://docs.google.com/document/d/"), true
+}
+
+func (item GDriveItem) JournalItem() JournalItem {
+	return JournalItem{
+		URL:      item.DocumentURL(),
+		Title:    item.Name,
+		Created:  item.CreatedTime,
+		Modified: item.ModifiedTime,
+		Trashed:  item.Trashed,
+	}
+}
+
+func (b *GDriveJournalBackend) CreateFromTemplate(ctx context.Context, vars GDriveTemplateVars) (JournalItem, error) {
+	item, err := b.w.CreateJournal("", vars, "")
+	if err != nil {
+		return JournalItem{}, err
+	}
+	return item.JournalItem(), nil
+}
+
+func (b *GDriveJournalBackend) Read(ctx context.Context, url string) (JournalDocument, error) {
+	id, ok := gdriveDocumentID(url)
+	if !ok {
+		return JournalDocument{}, fmt.Errorf("not a Google Docs URL: %s", url)
+	}
+	journal, err := b.w.client.ReadDocument(id)
+	if err != nil {
+		return JournalDocument{}, err
+	}
+	return JournalDocument{
+		Item:    journal.Item.JournalItem(),
+		Content: journal.Content,
+	}, nil
+}
+
+// The cursor is a page token in the Drive changes feed.
+func (b *GDriveJournalBackend) ListChanged(ctx context.Context, cursor string) (JournalChanges, error) {
+	folder := b.w.Config().JournalFolder
+
+	if cursor == "" {
+		pageToken, err := b.w.GetStartPageToken()
+		if err != nil {
+			return JournalChanges{}, fmt.Errorf("getting start page token: %w", err)
+		}
+		res, err := b.w.listFiles(ctx, folder)
+		if err != nil {
+			return JournalChanges{}, err
+		}
+		return JournalChanges{
+			Items:  SliceToSlice(res.Files, GDriveItem.JournalItem),
+			Cursor: pageToken,
+		}, nil
+	}
+
+	var out JournalChanges
+	for {
+		changes, err := b.w.ListChanges(cursor)
+		if err != nil {
+			return JournalChanges{}, err
+		}
+		for _, change := range changes.Changes {
+			switch {
+			case change.Removed:
+				item := GDriveItem{ID: change.FileID, Trashed: true}
+				out.Items = append(out.Items, item.JournalItem())
+			case change.IsDocument && slices.Contains(change.Item.Parents, folder):
+				out.Items = append(out.Items, change.Item.JournalItem())
+			}
+		}
+		if changes.NewStartPageToken != "" {
+			out.Cursor = changes.NewStartPageToken
+			return out, nil
+		}
+		if changes.NextPageToken == "" {
+			return JournalChanges{}, errors.New("changes list has neither next page token nor new start page token")
+		}
+		cursor = changes.NextPageToken
+	}
+}
+
+// The invitation is queued, and sent in the background.
+func (b *GDriveJournalBackend) Share(ctx context.Context, email string) error {
+	_, err := b.w.EnqueueInviteUser(ctx, b.w.Config().JournalFolder, email, "writer")
+	return err
+}
+
+// MARKDOWN
+
+// Added to the journal name when the patient is checked out, unless configured otherwise
+const archivedJournalNameSuffix = " - Outcome CheckoutDate"
+
+const (
+	markdownJournalTitleTemplate   = "YYYY-MM-DD Name (Species)"
+	markdownJournalDefaultTemplate = `# YYYY-MM-DD Name
+
+Species
+
+[Bino](BinoURL)
+
+## Innkomst
+
+## Behandling
+
+## Utfall
+`
+)
+
+// Journals stored as Markdown in the database and edited in bino.
+type MarkdownJournalBackend struct {
+	queries  *Queries
+	template string
+}
+
+func NewMarkdownJournalBackend(queries *Queries, cfg JournalConfig) (*MarkdownJournalBackend, error) {
+	template := markdownJournalDefaultTemplate
+	if cfg.MarkdownTemplateLocation != "" {
+		raw, err := os.ReadFile(cfg.MarkdownTemplateLocation)
+		if err != nil {
+			return nil, fmt.Errorf("reading journal template: %w", err)
+		}
+		template = string(raw)
+	}
+	return &MarkdownJournalBackend{
+		queries:  queries,
+		template: template,
+	}, nil
+}
+
+// The same backend, using the given queries, for example in a transaction.
+func (b *MarkdownJournalBackend) WithQueries(q *Queries) *MarkdownJournalBackend {
+	return &MarkdownJournalBackend{
+		queries:  q,
+		template: b.template,
+	}
+}
+
+func MarkdownJournalURL(id int32) string {
+	return fmt.Sprintf("/journal/%d", id)
+}
+
+// The journal ID in a Markdown journal URL.
+func parseMarkdownJournalURL(url string) (int32, bool) {
+	baseURL := markdownJournalRegex.FindString(url)
+	if baseURL == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(baseURL, "/journal/"), 10, 32)
+	return int32(id), err == nil
+}
+
+func (j Journal) JournalItem() JournalItem {
+	return JournalItem{
+		URL:      MarkdownJournalURL(j.ID),
+		Title:    j.Title,
+		Created:  j.Created.Time,
+		Modified: j.Updated.Time,
+	}
+}
+
+func (b *MarkdownJournalBackend) CreateFromTemplate(ctx context.Context, vars GDriveTemplateVars) (JournalItem, error) {
+	journal, err := b.queries.CreateMarkdownJournal(ctx, CreateMarkdownJournalParams{
+		Title:   vars.ApplyToString(markdownJournalTitleTemplate),
+		Content: vars.ApplyToString(b.template),
+	})
+	if err != nil {
+		return JournalItem{}, err
+	}
+	return journal.JournalItem(), nil
+}
+
+func (b *MarkdownJournalBackend) Read(ctx context.Context, url string) (JournalDocument, error) {
+	id, ok := parseMarkdownJournalURL(url)
+	if !ok {
+		return JournalDocument{}, fmt.Errorf("not a bino journal URL: %s", url)
+	}
+	journal, err := b.queries.GetMarkdownJournal(ctx, id)
+	if err != nil {
+		return JournalDocument{}, err
+	}
+	return JournalDocument{
+		Item: JournalItem{
+			URL:      MarkdownJournalURL(journal.ID),
+			Title:    journal.Title,
+			Created:  journal.Created.Time,
+			Modified: journal.Updated.Time,
+		},
+		Content: journal.Content,
+	}, nil
+}
+
+// List the journals changed since the cursor. An empty cursor lists all
+// journals. The cursor is the oldest transaction that could still commit a
+// change, so a journal may be listed twice, but a change is never missed.
+func (b *MarkdownJournalBackend) ListChanged(ctx context.Context, cursor string) (JournalChanges, error) {
+	var since int64
+	if cursor != "" {
+		var err error
+		if since, err = strconv.ParseInt(cursor, 10, 64); err != nil {
+			return JournalChanges{}, fmt.Errorf("bad cursor '%s': %w", cursor, err)
+		}
+	}
+
+	// Taken before listing, so that journals changed in the meantime are listed next time
+	next, err := b.queries.GetMarkdownJournalChangeCursor(ctx)
+	if err != nil {
+		return JournalChanges{}, err
+	}
+
+	journals, err := b.queries.GetMarkdownJournalsChangedSince(ctx, since)
+	if err != nil {
+		return JournalChanges{}, err
+	}
+
+	return JournalChanges{
+		Items:  SliceToSlice(journals, Journal.JournalItem),
+		Cursor: strconv.FormatInt(next, 10),
+	}, nil
+}
+
+// Access to Markdown journals follows access to bino, so there is nothing to share.
+func (b *MarkdownJournalBackend) Share(ctx context.Context, email string) error {
+	return nil
+}
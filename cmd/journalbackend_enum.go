@@ -0,0 +1,63 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version: v0.9.1
+
+// Built By: go install
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// JournalBackendIDGDrive is a JournalBackendID of type GDrive.
+	JournalBackendIDGDrive JournalBackendID = 0
+	// JournalBackendIDMarkdown is a JournalBackendID of type Markdown.
+	JournalBackendIDMarkdown JournalBackendID = 1
+)
+
+var ErrInvalidJournalBackendID = errors.New("not a valid JournalBackendID")
+
+const _JournalBackendIDName = "GDriveMarkdown"
+
+// JournalBackendIDValues returns a list of the values for JournalBackendID
+func JournalBackendIDValues() []JournalBackendID {
+	return []JournalBackendID{
+		JournalBackendIDGDrive,
+		JournalBackendIDMarkdown,
+	}
+}
+
+var _JournalBackendIDMap = map[JournalBackendID]string{
+	JournalBackendIDGDrive:   _JournalBackendIDName[0:6],
+	JournalBackendIDMarkdown: _JournalBackendIDName[6:14],
+}
+
+// String implements the Stringer interface.
+func (x JournalBackendID) String() string {
+	if str, ok := _JournalBackendIDMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("JournalBackendID(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x JournalBackendID) IsValid() bool {
+	_, ok := _JournalBackendIDMap[x]
+	return ok
+}
+
+var _JournalBackendIDValue = map[string]JournalBackendID{
+	_JournalBackendIDName[0:6]:  JournalBackendIDGDrive,
+	_JournalBackendIDName[6:14]: JournalBackendIDMarkdown,
+}
+
+// ParseJournalBackendID attempts to convert a string to a JournalBackendID.
+func ParseJournalBackendID(name string) (JournalBackendID, error) {
+	if x, ok := _JournalBackendIDValue[name]; ok {
+		return x, nil
+	}
+	return JournalBackendID(0), fmt.Errorf("%s is %w", name, ErrInvalidJournalBackendID)
+}
@@ -0,0 +1,215 @@
+//go:generate go tool go-enum --no-iota --values
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ENUM(None = 0, Unreadable, Trashed, OutsideFolders)
+type JournalProblem int32
+
+const journalCheckInterval = 24 * time.Hour
+
+// What bino could find out about a journal before attaching it to a patient.
+type JournalCheck struct {
+	// Canonical URL of the journal
+	URL     string
+	Title   string
+	Problem JournalProblem
+	// Error from the journal backend, if any
+	Detail string
+	// Whether the journal could be looked up at all. Google Drive journals
+	// can't be checked when Google Drive is not in use.
+	Checked bool
+}
+
+// Whether the journal can't be attached at all.
+func (jc JournalCheck) Blocking() bool {
+	return jc.Problem == JournalProblemUnreadable || jc.Problem == JournalProblemTrashed
+}
+
+// Look up the journal in its backend. Errors are only returned when the
+// backend could not be reached, and problems with the journal itself are
+// reported in the check.
+func (server *Server) checkJournal(ctx context.Context, url string) (JournalCheck, error) {
+	check := JournalCheck{URL: parseJournalURL(url)}
+	if check.URL == "" {
+		return check, errBadJournalURL
+	}
+
+	if id, ok := parseMarkdownJournalURL(check.URL); ok {
+		journal, err := server.Queries.GetMarkdownJournal(ctx, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			check.Problem = JournalProblemUnreadable
+			check.Detail = err.Error()
+		} else if err != nil {
+			return check, err
+		}
+		check.Title = journal.Title
+		check.Checked = true
+		return check, nil
+	}
+
+	id, _ := gdriveDocumentID(check.URL)
+	if server.GDriveWorker == nil {
+		return check, nil
+	}
+	return server.GDriveWorker.CheckJournal(check, id)
+}
+
+func (w *GDriveWorker) CheckJournal(check JournalCheck, id string) (JournalCheck, error) {
+	item, err := w.GetFile(id)
+	if err != nil {
+		// Drive answers not found for documents the service account can't read
+		if retry, _ := gdriveTaskShouldRetry(err); retry {
+			return check, err
+		}
+		check.Problem = JournalProblemUnreadable
+		check.Detail = err.Error()
+		check.Checked = true
+		return check, nil
+	}
+	check.Title = item.Name
+	check.Checked = true
+
+	if item.Trashed {
+		check.Problem = JournalProblemTrashed
+		return check, nil
+	}
+
+	inFolders, err := w.inJournalFolders(item)
+	if err != nil {
+		return check, err
+	}
+	if !inFolders {
+		check.Problem = JournalProblemOutsideFolders
+	}
+	return check, nil
+}
+
+// Whether the item is in the journal folder, one of the extra journal folders
+// or the archive.
+func (w *GDriveWorker) inJournalFolders(item GDriveItem) (bool, error) {
+	for _, parent := range item.Parents {
+		if parent == w.Config().JournalFolder || slices.Contains(w.Config().ExtraJournalFolders, parent) {
+			return true, nil
+		}
+	}
+	return w.inJournalArchive(item)
+}
+
+// Check the journals of active patients, so that journals that were trashed
+// or lost their sharing show up on the patient page.
+func (w *GDriveWorker) journalCheckWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(journalCheckInterval):
+		}
+		if !w.Health().OK() {
+			log.Printf("Skipping journal check, Google Drive is unavailable")
+			continue
+		}
+
+		patients, err := w.queries.GetActivePatientJournals(ctx)
+		if err != nil {
+			log.Printf("ERROR: getting journals to check: %v", err)
+			continue
+		}
+
+		nProblems := 0
+		for _, patient := range patients {
+			id, ok := gdriveDocumentID(patient.JournalUrl)
+			if !ok {
+				continue
+			}
+			check, err := w.CheckJournal(JournalCheck{URL: patient.JournalUrl}, id)
+			if err != nil {
+				log.Printf("ERROR: checking journal of patient %d: %v", patient.ID, err)
+				continue
+			}
+			if check.Problem != JournalProblemNone {
+				nProblems++
+			}
+			if err := w.queries.SetPatientJournalCheck(ctx, SetPatientJournalCheckParams{
+				PatientID:  patient.ID,
+				JournalUrl: patient.JournalUrl,
+				Problem:    int32(check.Problem),
+				Detail:     check.Detail,
+			}); err != nil {
+				log.Printf("ERROR: storing journal check of patient %d: %v", patient.ID, err)
+			}
+		}
+		log.Printf("Checked %d journals, %d with problems", len(patients), nProblems)
+	}
+}
+
+func (server *Server) ajaxCheckJournalHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	patient, err := server.getPathID(r, "patient")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	url, err := server.getFormValue(r, "url")
+	if err != nil || url == "" {
+		return
+	}
+
+	patientData, err := server.Queries.GetPatient(ctx, patient)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	check, err := server.checkJournal(ctx, url)
+	if err != nil && !errors.Is(err, errBadJournalURL) {
+		LogR(r, "checking journal %s: %v", url, err)
+	}
+
+	duplicates, _ := server.journalDuplicates(ctx, patient, check.URL)
+
+	var proposedName string
+	if check.Title != "" {
+		if hints := parseJournalTitle(check.Title, nil); hints.Name != patientData.Name {
+			proposedName = hints.Name
+		}
+	}
+
+	_ = JournalCheckResult(commonData, PatientView{ID: patient}, check, err, duplicates, proposedName).Render(ctx, w)
+}
+
+// Other patients who have the journal at the URL.
+func (server *Server) journalDuplicates(ctx context.Context, patient int32, url string) ([]PatientView, error) {
+	if url == "" {
+		return nil, nil
+	}
+	ids, err := server.Queries.GetPatientsWithJournalURL(ctx, pgtype.Text{String: url, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	var out []PatientView
+	for _, id := range ids {
+		if id == patient {
+			continue
+		}
+		other, err := server.Queries.GetPatient(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, PatientView{ID: other.ID, Name: other.Name})
+	}
+	return out, nil
+}
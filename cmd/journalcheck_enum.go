@@ -0,0 +1,73 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version: v0.9.1
+
+// Built By: go install
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// JournalProblemNone is a JournalProblem of type None.
+	JournalProblemNone JournalProblem = 0
+	// JournalProblemUnreadable is a JournalProblem of type Unreadable.
+	JournalProblemUnreadable JournalProblem = 1
+	// JournalProblemTrashed is a JournalProblem of type Trashed.
+	JournalProblemTrashed JournalProblem = 2
+	// JournalProblemOutsideFolders is a JournalProblem of type OutsideFolders.
+	JournalProblemOutsideFolders JournalProblem = 3
+)
+
+var ErrInvalidJournalProblem = errors.New("not a valid JournalProblem")
+
+const _JournalProblemName = "NoneUnreadableTrashedOutsideFolders"
+
+// JournalProblemValues returns a list of the values for JournalProblem
+func JournalProblemValues() []JournalProblem {
+	return []JournalProblem{
+		JournalProblemNone,
+		JournalProblemUnreadable,
+		JournalProblemTrashed,
+		JournalProblemOutsideFolders,
+	}
+}
+
+var _JournalProblemMap = map[JournalProblem]string{
+	JournalProblemNone:           _JournalProblemName[0:4],
+	JournalProblemUnreadable:     _JournalProblemName[4:14],
+	JournalProblemTrashed:        _JournalProblemName[14:21],
+	JournalProblemOutsideFolders: _JournalProblemName[21:35],
+}
+
+// String implements the Stringer interface.
+func (x JournalProblem) String() string {
+	if str, ok := _JournalProblemMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("JournalProblem(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x JournalProblem) IsValid() bool {
+	_, ok := _JournalProblemMap[x]
+	return ok
+}
+
+var _JournalProblemValue = map[string]JournalProblem{
+	_JournalProblemName[0:4]:   JournalProblemNone,
+	_JournalProblemName[4:14]:  JournalProblemUnreadable,
+	_JournalProblemName[14:21]: JournalProblemTrashed,
+	_JournalProblemName[21:35]: JournalProblemOutsideFolders,
+}
+
+// ParseJournalProblem attempts to convert a string to a JournalProblem.
+func ParseJournalProblem(name string) (JournalProblem, error) {
+	if x, ok := _JournalProblemValue[name]; ok {
+		return x, nil
+	}
+	return JournalProblem(0), fmt.Errorf("%s is %w", name, ErrInvalidJournalProblem)
+}
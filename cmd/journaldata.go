@@ -0,0 +1,374 @@
+//go:generate go tool go-enum --no-iota --values
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ENUM(Weight = 0, Medication)
+type MeasurementKind int32
+
+// ENUM(None = 0, MissingDate, DateWithoutYear, BadDate, BadValue)
+type JournalImportProblem int32
+
+// ENUM(Pending = 0, Accepted, Rejected)
+type JournalImportReviewStatus int32
+
+// A row from a table in a journal, as a measurement on the patient.
+type journalDataRow struct {
+	Kind MeasurementKind
+	// The row as written in the journal, used to recognise it later
+	Raw  string
+	Date time.Time
+	// Weight in grams
+	Value float64
+	// Medication and dose
+	Note string
+	// Why the row needs a review before it can be imported
+	Problem JournalImportProblem
+}
+
+var (
+	journalTableSeparatorRegex = regexp.MustCompile(`^:?-+:?$`)
+	journalDateISORegex        = regexp.MustCompile(`^(\d{4})-(\d{1,2})-(\d{1,2})$`)
+	journalDateRegex           = regexp.MustCompile(`^(\d{1,2})[./](\d{1,2})[./](\d{4}|\d{2})$`)
+	journalDateNoYearRegex     = regexp.MustCompile(`^\d{1,2}[./]\d{1,2}\.?$`)
+	journalWeightRegex         = regexp.MustCompile(`(?i)^(\d+(?:[.,]\d+)?)\s*(kg|g|gram)?$`)
+	journalEscapeRegex         = regexp.MustCompile(`\\([[:punct:]])`)
+)
+
+// Import the rows of the tables in a journal as measurements on the patient.
+// Measurements are recognised by the text of their row, so that rows can be
+// added or moved in the journal. Measurements and pending reviews of rows that
+// are no longer in the journal are removed, and rows that can't be read with
+// certainty are queued for review.
+func importJournalData(ctx context.Context, q *Queries, patientID int32, url, content string) error {
+	var kinds []int32
+	var rawRows []string
+	for _, row := range parseJournalData(content) {
+		kinds = append(kinds, int32(row.Kind))
+		rawRows = append(rawRows, row.Raw)
+		if row.Problem != JournalImportProblemNone {
+			if err := q.AddJournalImportReview(ctx, AddJournalImportReviewParams{
+				PatientID: patientID,
+				Kind:      int32(row.Kind),
+				Problem:   int32(row.Problem),
+				RawRow:    row.Raw,
+				SourceUrl: url,
+			}); err != nil {
+				return fmt.Errorf("queueing row for review: %w", err)
+			}
+			continue
+		}
+		if err := q.AddPatientMeasurement(ctx, AddPatientMeasurementParams{
+			PatientID: patientID,
+			Kind:      int32(row.Kind),
+			Measured:  pgtype.Date{Time: row.Date, Valid: true},
+			Value:     row.Value,
+			Note:      row.Note,
+			SourceUrl: url,
+			SourceRaw: pgtype.Text{String: row.Raw, Valid: true},
+		}); err != nil {
+			return fmt.Errorf("adding measurement: %w", err)
+		}
+	}
+
+	if err := q.DeleteRemovedPatientMeasurements(ctx, DeleteRemovedPatientMeasurementsParams{
+		PatientID: patientID,
+		SourceUrl: url,
+		Kinds:     kinds,
+		RawRows:   rawRows,
+	}); err != nil {
+		return fmt.Errorf("removing measurements: %w", err)
+	}
+	if err := q.DeleteRemovedJournalImportReviews(ctx, DeleteRemovedJournalImportReviewsParams{
+		PatientID: patientID,
+		SourceUrl: url,
+		Kinds:     kinds,
+		RawRows:   rawRows,
+	}); err != nil {
+		return fmt.Errorf("removing reviews: %w", err)
+	}
+	return nil
+}
+
+// Find the tables in exported journal Markdown that have a date column and a
+// weight or medication column, and read their rows.
+func parseJournalData(content string) []journalDataRow {
+	var out []journalDataRow
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		if !isJournalTableLine(lines[i]) {
+			continue
+		}
+		start := i
+		for i < len(lines) && isJournalTableLine(lines[i]) {
+			i++
+		}
+		out = append(out, parseJournalTable(lines[start:i])...)
+	}
+	return out
+}
+
+func isJournalTableLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "|")
+}
+
+func splitJournalTableLine(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	// Escaped pipes are part of the cell
+	cells := strings.Split(strings.ReplaceAll(line, `\|`, "\x00"), "|")
+	for i, cell := range cells {
+		cell = strings.ReplaceAll(cell, "\x00", "|")
+		cell = journalEscapeRegex.ReplaceAllString(cell, "$1")
+		cell = strings.ReplaceAll(cell, "**", "")
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+type journalTableColumns struct {
+	date, weight, medication, dose int
+	// Unit given in the weight header, grams if empty
+	weightUnit string
+}
+
+func parseJournalTableHeader(cells []string) journalTableColumns {
+	cols := journalTableColumns{date: -1, weight: -1, medication: -1, dose: -1}
+	for i, cell := range cells {
+		cell = strings.ToLower(cell)
+		fields := strings.FieldsFunc(cell, func(r rune) bool {
+			return r == ' ' || r == '(' || r == ')' || r == '[' || r == ']'
+		})
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "dato", "date":
+			cols.date = i
+		case "vekt", "weight":
+			cols.weight = i
+			if len(fields) > 1 && (fields[1] == "kg" || fields[1] == "g") {
+				cols.weightUnit = fields[1]
+			}
+		case "medisin", "medisiner", "medication", "medicine":
+			cols.medication = i
+		case "dose", "dosering", "dosage":
+			cols.dose = i
+		}
+	}
+	return cols
+}
+
+func parseJournalTable(lines []string) []journalDataRow {
+	if len(lines) < 3 {
+		return nil
+	}
+	cols := parseJournalTableHeader(splitJournalTableLine(lines[0]))
+	if cols.date < 0 || (cols.weight < 0 && cols.medication < 0) {
+		return nil
+	}
+	for _, cell := range splitJournalTableLine(lines[1]) {
+		if !journalTableSeparatorRegex.MatchString(cell) {
+			return nil
+		}
+	}
+
+	var out []journalDataRow
+	for _, line := range lines[2:] {
+		cells := splitJournalTableLine(line)
+		cell := func(i int) string {
+			if i < 0 || i >= len(cells) {
+				return ""
+			}
+			return cells[i]
+		}
+		raw := strings.Join(cells, " | ")
+		date, dateProblem := parseJournalDate(cell(cols.date))
+
+		if weight := cell(cols.weight); weight != "" {
+			row := journalDataRow{Kind: MeasurementKindWeight, Raw: raw, Date: date, Problem: dateProblem}
+			if value, ok := parseJournalWeight(weight, cols.weightUnit); ok {
+				row.Value = value
+			} else if row.Problem == JournalImportProblemNone {
+				row.Problem = JournalImportProblemBadValue
+			}
+			out = append(out, row)
+		}
+
+		if medication := cell(cols.medication); medication != "" {
+			note := medication
+			if dose := cell(cols.dose); dose != "" {
+				note += " " + dose
+			}
+			out = append(out, journalDataRow{Kind: MeasurementKindMedication, Raw: raw, Date: date, Note: note, Problem: dateProblem})
+		}
+	}
+	return out
+}
+
+func parseJournalDate(s string) (time.Time, JournalImportProblem) {
+	var year, month, day int
+	if m := journalDateISORegex.FindStringSubmatch(s); m != nil {
+		year, _ = strconv.Atoi(m[1])
+		month, _ = strconv.Atoi(m[2])
+		day, _ = strconv.Atoi(m[3])
+	} else if m := journalDateRegex.FindStringSubmatch(s); m != nil {
+		day, _ = strconv.Atoi(m[1])
+		month, _ = strconv.Atoi(m[2])
+		year, _ = strconv.Atoi(m[3])
+		if len(m[3]) == 2 {
+			year += 2000
+		}
+	} else if s == "" {
+		return time.Time{}, JournalImportProblemMissingDate
+	} else if journalDateNoYearRegex.MatchString(s) {
+		return time.Time{}, JournalImportProblemDateWithoutYear
+	} else {
+		return time.Time{}, JournalImportProblemBadDate
+	}
+
+	// Reject dates that don't exist, such as 31.02
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if t.Year() != year || int(t.Month()) != month || t.Day() != day {
+		return time.Time{}, JournalImportProblemBadDate
+	}
+	return t, JournalImportProblemNone
+}
+
+// The weight in grams. The unit in the cell takes precedence over the one in the header.
+func parseJournalWeight(s, headerUnit string) (float64, bool) {
+	m := journalWeightRegex.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", "."), 64)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	unit := strings.ToLower(m[2])
+	if unit == "" {
+		unit = headerUnit
+	}
+	if unit == "kg" {
+		value *= 1000
+	}
+	return value, true
+}
+
+func (server *Server) getJournalImportReviewHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	reviews, err := server.Queries.GetPendingJournalImportReviews(ctx)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	_ = JournalImportReviewPage(
+		commonData,
+		SliceToSlice(reviews, GetPendingJournalImportReviewsRow.ToJournalImportReviewView),
+	).Render(ctx, w)
+}
+
+func (server *Server) postJournalImportReviewAcceptHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	id, err := server.getPathID(r, "review")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	fields, err := server.getFormValues(r, "date", "value")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	review, err := server.Queries.GetJournalImportReview(ctx, id)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	params := AddPatientMeasurementParams{
+		PatientID: review.PatientID,
+		Kind:      review.Kind,
+		SourceUrl: review.SourceUrl,
+		SourceRaw: pgtype.Text{String: review.RawRow, Valid: true},
+		Reviewed:  true,
+	}
+	date, dateErr := time.Parse(time.DateOnly, fields["date"])
+	params.Measured = pgtype.Date{Time: date, Valid: true}
+	valueOK := true
+	if MeasurementKind(review.Kind) == MeasurementKindWeight {
+		params.Value, valueOK = parseJournalWeight(strings.TrimSpace(fields["value"]), "")
+	} else {
+		params.Note = strings.TrimSpace(fields["value"])
+		valueOK = params.Note != ""
+	}
+	if dateErr != nil || !valueOK {
+		commonData.Error(commonData.User.Language.JournalImportReviewBadValue, dateErr)
+		server.redirectToReferer(w, r)
+		return
+	}
+
+	if err := server.Transaction(ctx, func(ctx context.Context, q *Queries) error {
+		// Someone else may have reviewed the row in the meantime
+		n, err := q.SetJournalImportReviewStatus(ctx, SetJournalImportReviewStatusParams{
+			ID:     id,
+			Status: int32(JournalImportReviewStatusAccepted),
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errors.New(commonData.User.Language.GenericNotFound)
+		}
+		return q.AddPatientMeasurement(ctx, params)
+	}); err != nil {
+		commonData.Error(commonData.User.Language.GenericFailed, err)
+	} else {
+		commonData.Success(commonData.User.Language.JournalImportReviewAccepted)
+	}
+
+	server.redirectToReferer(w, r)
+}
+
+func (server *Server) postJournalImportReviewRejectHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	id, err := server.getPathID(r, "review")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	if n, err := server.Queries.SetJournalImportReviewStatus(ctx, SetJournalImportReviewStatusParams{
+		ID:     id,
+		Status: int32(JournalImportReviewStatusRejected),
+	}); err != nil {
+		commonData.Error(commonData.User.Language.GenericFailed, err)
+	} else if n == 0 {
+		commonData.Warning(commonData.User.Language.GenericNotFound, nil)
+	} else {
+		commonData.Success(commonData.User.Language.JournalImportReviewRejected)
+	}
+
+	server.redirectToReferer(w, r)
+}
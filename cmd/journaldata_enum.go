@@ -0,0 +1,187 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version: v0.9.1
+
+// Built By: go install
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// JournalImportProblemNone is a JournalImportProblem of type None.
+	JournalImportProblemNone JournalImportProblem = 0
+	// JournalImportProblemMissingDate is a JournalImportProblem of type MissingDate.
+	JournalImportProblemMissingDate JournalImportProblem = 1
+	// JournalImportProblemDateWithoutYear is a JournalImportProblem of type DateWithoutYear.
+	JournalImportProblemDateWithoutYear JournalImportProblem = 2
+	// JournalImportProblemBadDate is a JournalImportProblem of type BadDate.
+	JournalImportProblemBadDate JournalImportProblem = 3
+	// JournalImportProblemBadValue is a JournalImportProblem of type BadValue.
+	JournalImportProblemBadValue JournalImportProblem = 4
+)
+
+var ErrInvalidJournalImportProblem = errors.New("not a valid JournalImportProblem")
+
+const _JournalImportProblemName = "NoneMissingDateDateWithoutYearBadDateBadValue"
+
+// JournalImportProblemValues returns a list of the values for JournalImportProblem
+func JournalImportProblemValues() []JournalImportProblem {
+	return []JournalImportProblem{
+		JournalImportProblemNone,
+		JournalImportProblemMissingDate,
+		JournalImportProblemDateWithoutYear,
+		JournalImportProblemBadDate,
+		JournalImportProblemBadValue,
+	}
+}
+
+var _JournalImportProblemMap = map[JournalImportProblem]string{
+	JournalImportProblemNone:            _JournalImportProblemName[0:4],
+	JournalImportProblemMissingDate:     _JournalImportProblemName[4:15],
+	JournalImportProblemDateWithoutYear: _JournalImportProblemName[15:30],
+	JournalImportProblemBadDate:         _JournalImportProblemName[30:37],
+	JournalImportProblemBadValue:        _JournalImportProblemName[37:45],
+}
+
+// String implements the Stringer interface.
+func (x JournalImportProblem) String() string {
+	if str, ok := _JournalImportProblemMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("JournalImportProblem(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x JournalImportProblem) IsValid() bool {
+	_, ok := _JournalImportProblemMap[x]
+	return ok
+}
+
+var _JournalImportProblemValue = map[string]JournalImportProblem{
+	_JournalImportProblemName[0:4]:   JournalImportProblemNone,
+	_JournalImportProblemName[4:15]:  JournalImportProblemMissingDate,
+	_JournalImportProblemName[15:30]: JournalImportProblemDateWithoutYear,
+	_JournalImportProblemName[30:37]: JournalImportProblemBadDate,
+	_JournalImportProblemName[37:45]: JournalImportProblemBadValue,
+}
+
+// ParseJournalImportProblem attempts to convert a string to a JournalImportProblem.
+func ParseJournalImportProblem(name string) (JournalImportProblem, error) {
+	if x, ok := _JournalImportProblemValue[name]; ok {
+		return x, nil
+	}
+	return JournalImportProblem(0), fmt.Errorf("%s is %w", name, ErrInvalidJournalImportProblem)
+}
+
+const (
+	// JournalImportReviewStatusPending is a JournalImportReviewStatus of type Pending.
+	JournalImportReviewStatusPending JournalImportReviewStatus = 0
+	// JournalImportReviewStatusAccepted is a JournalImportReviewStatus of type Accepted.
+	JournalImportReviewStatusAccepted JournalImportReviewStatus = 1
+	// JournalImportReviewStatusRejected is a JournalImportReviewStatus of type Rejected.
+	JournalImportReviewStatusRejected JournalImportReviewStatus = 2
+)
+
+var ErrInvalidJournalImportReviewStatus = errors.New("not a valid JournalImportReviewStatus")
+
+const _JournalImportReviewStatusName = "PendingAcceptedRejected"
+
+// JournalImportReviewStatusValues returns a list of the values for JournalImportReviewStatus
+func JournalImportReviewStatusValues() []JournalImportReviewStatus {
+	return []JournalImportReviewStatus{
+		JournalImportReviewStatusPending,
+		JournalImportReviewStatusAccepted,
+		JournalImportReviewStatusRejected,
+	}
+}
+
+var _JournalImportReviewStatusMap = map[JournalImportReviewStatus]string{
+	JournalImportReviewStatusPending:  _JournalImportReviewStatusName[0:7],
+	JournalImportReviewStatusAccepted: _JournalImportReviewStatusName[7:15],
+	JournalImportReviewStatusRejected: _JournalImportReviewStatusName[15:23],
+}
+
+// String implements the Stringer interface.
+func (x JournalImportReviewStatus) String() string {
+	if str, ok := _JournalImportReviewStatusMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("JournalImportReviewStatus(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x JournalImportReviewStatus) IsValid() bool {
+	_, ok := _JournalImportReviewStatusMap[x]
+	return ok
+}
+
+var _JournalImportReviewStatusValue = map[string]JournalImportReviewStatus{
+	_JournalImportReviewStatusName[0:7]:   JournalImportReviewStatusPending,
+	_JournalImportReviewStatusName[7:15]:  JournalImportReviewStatusAccepted,
+	_JournalImportReviewStatusName[15:23]: JournalImportReviewStatusRejected,
+}
+
+// ParseJournalImportReviewStatus attempts to convert a string to a JournalImportReviewStatus.
+func ParseJournalImportReviewStatus(name string) (JournalImportReviewStatus, error) {
+	if x, ok := _JournalImportReviewStatusValue[name]; ok {
+		return x, nil
+	}
+	return JournalImportReviewStatus(0), fmt.Errorf("%s is %w", name, ErrInvalidJournalImportReviewStatus)
+}
+
+const (
+	// MeasurementKindWeight is a MeasurementKind of type Weight.
+	MeasurementKindWeight MeasurementKind = 0
+	// MeasurementKindMedication is a MeasurementKind of type Medication.
+	MeasurementKindMedication MeasurementKind = 1
+)
+
+var ErrInvalidMeasurementKind = errors.New("not a valid MeasurementKind")
+
+const _MeasurementKindName = "WeightMedication"
+
+// MeasurementKindValues returns a list of the values for MeasurementKind
+func MeasurementKindValues() []MeasurementKind {
+	return []MeasurementKind{
+		MeasurementKindWeight,
+		MeasurementKindMedication,
+	}
+}
+
+var _MeasurementKindMap = map[MeasurementKind]string{
+	MeasurementKindWeight:     _MeasurementKindName[0:6],
+	MeasurementKindMedication: _MeasurementKindName[6:16],
+}
+
+// String implements the Stringer interface.
+func (x MeasurementKind) String() string {
+	if str, ok := _MeasurementKindMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("MeasurementKind(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x MeasurementKind) IsValid() bool {
+	_, ok := _MeasurementKindMap[x]
+	return ok
+}
+
+var _MeasurementKindValue = map[string]MeasurementKind{
+	_MeasurementKindName[0:6]:  MeasurementKindWeight,
+	_MeasurementKindName[6:16]: MeasurementKindMedication,
+}
+
+// ParseMeasurementKind attempts to convert a string to a MeasurementKind.
+func ParseMeasurementKind(name string) (MeasurementKind, error) {
+	if x, ok := _MeasurementKindValue[name]; ok {
+		return x, nil
+	}
+	return MeasurementKind(0), fmt.Errorf("%s is %w", name, ErrInvalidMeasurementKind)
+}
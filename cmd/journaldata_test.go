@@ -0,0 +1,170 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseJournalDate(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	for _, tc := range []struct {
+		in      string
+		want    time.Time
+		problem JournalImportProblem
+	}{
+		{in: "14.03.2025", want: date(2025, 3, 14)},
+		{in: "14.3.25", want: date(2025, 3, 14)},
+		{in: "4/3/2025", want: date(2025, 3, 4)},
+		{in: "2025-03-14", want: date(2025, 3, 14)},
+		{in: "2025-3-4", want: date(2025, 3, 4)},
+		{in: "29.02.2024", want: date(2024, 2, 29)},
+		{in: "", problem: JournalImportProblemMissingDate},
+		{in: "14.03", problem: JournalImportProblemDateWithoutYear},
+		{in: "14.03.", problem: JournalImportProblemDateWithoutYear},
+		{in: "14/3", problem: JournalImportProblemDateWithoutYear},
+		{in: "31.02.2025", problem: JournalImportProblemBadDate},
+		{in: "29.02.2025", problem: JournalImportProblemBadDate},
+		{in: "14.13.2025", problem: JournalImportProblemBadDate},
+		{in: "2025-14-03", problem: JournalImportProblemBadDate},
+		{in: "14.03.202", problem: JournalImportProblemBadDate},
+		{in: "i går", problem: JournalImportProblemBadDate},
+	} {
+		got, problem := parseJournalDate(tc.in)
+		if problem != tc.problem || !got.Equal(tc.want) {
+			t.Errorf("parseJournalDate(%q) = %v, %v, want %v, %v", tc.in, got, problem, tc.want, tc.problem)
+		}
+	}
+}
+
+func TestParseJournalWeight(t *testing.T) {
+	for _, tc := range []struct {
+		in, headerUnit string
+		want           float64
+		ok             bool
+	}{
+		{in: "1200", want: 1200, ok: true},
+		{in: "1200 g", want: 1200, ok: true},
+		{in: "1200gram", want: 1200, ok: true},
+		{in: "1,2 kg", want: 1200, ok: true},
+		{in: "1.25KG", want: 1250, ok: true},
+		{in: "1,2", headerUnit: "kg", want: 1200, ok: true},
+		{in: "850 g", headerUnit: "kg", want: 850, ok: true},
+		{in: "0", ok: false},
+		{in: "", ok: false},
+		{in: "ca 1200", ok: false},
+		{in: "1200 lb", ok: false},
+		{in: "-5", ok: false},
+	} {
+		got, ok := parseJournalWeight(tc.in, tc.headerUnit)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("parseJournalWeight(%q, %q) = %v, %v, want %v, %v", tc.in, tc.headerUnit, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestParseJournalData(t *testing.T) {
+	march := func(day int) time.Time {
+		return time.Date(2025, 3, day, 0, 0, 0, 0, time.UTC)
+	}
+	for _, tc := range []struct {
+		name    string
+		content string
+		want    []journalDataRow
+	}{
+		{
+			name: "weights and medication",
+			content: `Intro text
+
+| Dato | Vekt (g) | Medisin | Dose |
+|------|----------|---------|------|
+| 14.03.2025 | 1200 | Metacam | 0,1 ml |
+| 15.03.2025 | 1250 | | |
+`,
+			want: []journalDataRow{
+				{Kind: MeasurementKindWeight, Raw: "14.03.2025 | 1200 | Metacam | 0,1 ml", Date: march(14), Value: 1200},
+				{Kind: MeasurementKindMedication, Raw: "14.03.2025 | 1200 | Metacam | 0,1 ml", Date: march(14), Note: "Metacam 0,1 ml"},
+				{Kind: MeasurementKindWeight, Raw: "15.03.2025 | 1250 |  | ", Date: march(15), Value: 1250},
+			},
+		},
+		{
+			name: "kg in header, bold and escapes",
+			content: `| **Date** | **Weight (kg)** |
+| :--- | ---: |
+| 2025-03-14 | 1,2 |
+| 14\.03\.2025 | 1\,3 |
+`,
+			want: []journalDataRow{
+				{Kind: MeasurementKindWeight, Raw: "2025-03-14 | 1,2", Date: march(14), Value: 1200},
+				{Kind: MeasurementKindWeight, Raw: "14.03.2025 | 1,3", Date: march(14), Value: 1300},
+			},
+		},
+		{
+			name: "rows with problems",
+			content: `| Dato | Vekt |
+|---|---|
+| 14.03 | 1200 |
+| 14.03.2025 | mye |
+| | 900 |
+`,
+			want: []journalDataRow{
+				{Kind: MeasurementKindWeight, Raw: "14.03 | 1200", Value: 1200, Problem: JournalImportProblemDateWithoutYear},
+				{Kind: MeasurementKindWeight, Raw: "14.03.2025 | mye", Date: march(14), Problem: JournalImportProblemBadValue},
+				{Kind: MeasurementKindWeight, Raw: " | 900", Value: 900, Problem: JournalImportProblemMissingDate},
+			},
+		},
+		{
+			name: "several tables",
+			content: `| Dato | Vekt |
+|---|---|
+| 14.03.2025 | 1200 |
+
+| Dato | Medisin |
+|---|---|
+| 15.03.2025 | Baytril |
+`,
+			want: []journalDataRow{
+				{Kind: MeasurementKindWeight, Raw: "14.03.2025 | 1200", Date: march(14), Value: 1200},
+				{Kind: MeasurementKindMedication, Raw: "15.03.2025 | Baytril", Date: march(15), Note: "Baytril"},
+			},
+		},
+		{
+			name: "tables without the right columns",
+			content: `| Navn | Vekt |
+|---|---|
+| Kråka | 1200 |
+
+| Dato | Notat |
+|---|---|
+| 14.03.2025 | Spiste |
+
+| Dato | Vekt |
+| 14.03.2025 | 1200 |
+`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parseJournalData(tc.content)
+			if !slices.EqualFunc(got, tc.want, func(a, b journalDataRow) bool {
+				return a.Kind == b.Kind && a.Raw == b.Raw && a.Date.Equal(b.Date) && a.Value == b.Value && a.Note == b.Note && a.Problem == b.Problem
+			}) {
+				t.Errorf("got %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// Rows are recognised by their text, so adding a row above others doesn't
+// change how the others are identified
+func TestParseJournalDataInsertedRow(t *testing.T) {
+	before := parseJournalData("| Dato | Vekt |\n|---|---|\n| 14.03.2025 | 1200 |\n| 15.03.2025 | 1250 |\n")
+	after := parseJournalData("| Dato | Vekt |\n|---|---|\n| 13.03.2025 | 1150 |\n| 14.03.2025 | 1200 |\n| 15.03.2025 | 1250 |\n")
+	if len(before) != 2 || len(after) != 3 {
+		t.Fatalf("got %d and %d rows, want 2 and 3", len(before), len(after))
+	}
+	if !slices.Equal([]string{before[0].Raw, before[1].Raw}, []string{after[1].Raw, after[2].Raw}) {
+		t.Errorf("rows changed identity after inserting a row: %+v, %+v", before, after)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Revisions by the same editor closer together than this are shown as one edit
+const journalEditMergeWindow = time.Hour
+
+// Whether the user can see the edit history of the journal at the URL. Only
+// Google Drive keeps revisions, and since they show who wrote in the journal,
+// they're only shown to users with Drive access who can read the journal.
+func (server *Server) canListJournalEdits(user *UserData, url string, home pgtype.Int4) bool {
+	if server.GDriveWorker == nil || !user.HasGDriveAccess || !server.searchBodyAccess(user).JournalVisible(home) {
+		return false
+	}
+	_, ok := gdriveDocumentID(url)
+	return ok
+}
+
+// The edits made to the journal of a patient, oldest first. Editors are
+// matched with bino users by email.
+func (server *Server) getJournalEdits(ctx context.Context, url string, home pgtype.Int4) ([]JournalEditView, error) {
+	id, _ := gdriveDocumentID(url)
+	revisions, err := server.GDriveWorker.ListRevisions(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Google may report emails with different case than the user registered with
+	users := map[string]UserView{}
+	for email, user := range server.getUserViews(ctx) {
+		users[strings.ToLower(email)] = user
+	}
+
+	inHome := map[string]bool{}
+	if home.Valid {
+		members, err := server.Queries.GetAppusersForHome(ctx, home.Int32)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			inHome[strings.ToLower(member.Email)] = true
+		}
+	}
+
+	return mergeJournalRevisions(revisions, users, inHome), nil
+}
+
+// Turn revisions into edits, merging revisions that the same editor made
+// within journalEditMergeWindow of each other.
+func mergeJournalRevisions(revisions []GDriveRevision, users map[string]UserView, inHome map[string]bool) []JournalEditView {
+	slices.SortStableFunc(revisions, func(a, b GDriveRevision) int {
+		return a.ModifiedTime.Compare(b.ModifiedTime)
+	})
+
+	var out []JournalEditView
+	for _, rev := range revisions {
+		email := strings.ToLower(rev.Email)
+		if n := len(out); n > 0 {
+			last := &out[n-1]
+			if strings.EqualFold(last.Email, email) && last.Name == rev.Name &&
+				rev.ModifiedTime.Sub(last.Time) < journalEditMergeWindow {
+				last.Time = rev.ModifiedTime
+				last.NRevisions++
+				continue
+			}
+		}
+		out = append(out, JournalEditView{
+			Time:       rev.ModifiedTime,
+			User:       users[email],
+			Name:       rev.Name,
+			Email:      email,
+			InHome:     email != "" && inHome[email],
+			NRevisions: 1,
+		})
+	}
+	return out
+}
+
+// Summarise the edits per editor, most recent editor first.
+func journalEditors(edits []JournalEditView) []JournalEditorView {
+	byEditor := map[string]*JournalEditorView{}
+	var order []string
+	for _, edit := range edits {
+		key := edit.Email + "\x00" + edit.Name
+		editor, ok := byEditor[key]
+		if !ok {
+			editor = &JournalEditorView{}
+			byEditor[key] = editor
+			order = append(order, key)
+		}
+		editor.Last = edit
+		editor.NEdits++
+	}
+
+	out := SliceToSlice(order, func(key string) JournalEditorView {
+		return *byEditor[key]
+	})
+	slices.SortStableFunc(out, func(a, b JournalEditorView) int {
+		return b.Last.Time.Compare(a.Last.Time)
+	})
+	return out
+}
+
+// Merge events and journal edits, both oldest first, into one history.
+func patientHistory(events []EventView, edits []JournalEditView) []PatientHistoryEntry {
+	out := make([]PatientHistoryEntry, 0, len(events)+len(edits))
+	for i := range events {
+		out = append(out, PatientHistoryEntry{Time: events[i].Time, Event: &events[i]})
+	}
+	for i := range edits {
+		out = append(out, PatientHistoryEntry{Time: edits[i].Time, Edit: &edits[i]})
+	}
+	slices.SortStableFunc(out, func(a, b PatientHistoryEntry) int {
+		return a.Time.Compare(b.Time)
+	})
+	return out
+}
+
+func (server *Server) ajaxJournalHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	id, err := server.getPathID(r, "patient")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	patient, err := server.Queries.GetPatient(ctx, id)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	events, err := server.getPatientEvents(ctx, commonData, patient.ID)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	view := PatientHistoryView{
+		Patient: PatientView{ID: patient.ID, Name: patient.Name},
+	}
+
+	var edits []JournalEditView
+	if server.canListJournalEdits(&commonData.User, patient.JournalUrl.String, patient.CurrHomeID) {
+		if edits, err = server.getJournalEdits(ctx, patient.JournalUrl.String, patient.CurrHomeID); err != nil {
+			LogR(r, "listing journal revisions: %v", err)
+			view.EditsError = err.Error()
+		}
+	}
+	view.Entries = patientHistory(events, edits)
+	view.Editors = journalEditors(edits)
+
+	_ = PatientHistory(ctx, commonData, view, server).Render(ctx, w)
+}
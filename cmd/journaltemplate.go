@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var gdriveIDRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// The Google Docs ID of the template for new journals for a patient, based on
+// the species. Empty if the configured template should be used.
+func (server *Server) journalTemplateFileForPatient(ctx context.Context, patientID int32) (string, error) {
+	patient, err := server.Queries.GetPatient(ctx, patientID)
+	if err != nil {
+		return "", err
+	}
+	template, err := server.Queries.GetJournalTemplateForSpecies(ctx, patient.SpeciesID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return template.GdriveFile, nil
+}
+
+// Read the template from Google Drive and store which variables it uses, and
+// why it can't be used if so.
+func (server *Server) validateJournalTemplate(ctx context.Context, template JournalTemplate) error {
+	doc, err := server.GDriveWorker.ReadTemplate(template.GdriveFile)
+	if err == nil {
+		err = doc.Validate()
+	}
+
+	validationError := pgtype.Text{}
+	if err != nil {
+		validationError = pgtype.Text{String: err.Error(), Valid: true}
+	}
+	if dbErr := server.Queries.SetJournalTemplateValidation(ctx, SetJournalTemplateValidationParams{
+		ID:              template.ID,
+		Variables:       SliceToSlice(doc.Variables(), Template.Placeholder),
+		ValidationError: validationError,
+	}); dbErr != nil {
+		return errors.Join(err, dbErr)
+	}
+	return err
+}
+
+func (server *Server) getJournalTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	if server.GDriveWorker == nil {
+		server.renderError(w, r, commonData, errors.New(commonData.User.Language.GDriveNotInUse))
+		return
+	}
+
+	info, err := server.GDriveWorker.GetGDriveConfigInfo()
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	templates, err := server.Queries.GetJournalTemplates(ctx)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	species, err := server.Queries.GetSpeciesWithLanguage(ctx, commonData.Lang32())
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	mappings, err := server.Queries.GetSpeciesJournalTemplates(ctx)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+	templateForSpecies := SliceToMap(mappings, func(in SpeciesJournalTemplate) (int32, int32) {
+		return in.SpeciesID, in.TemplateID
+	})
+
+	_ = JournalTemplatesPage(
+		commonData,
+		info.TemplateDoc.Item,
+		SliceToSlice(templates, JournalTemplate.ToJournalTemplateView),
+		SliceToSlice(species, func(in GetSpeciesWithLanguageRow) SpeciesView {
+			return in.ToSpeciesView(false)
+		}),
+		templateForSpecies,
+	).Render(ctx, w)
+}
+
+func (server *Server) postJournalTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	fields, err := server.getFormValues(r, "name", "url")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	name := strings.TrimSpace(fields["name"])
+	id, ok := gdriveDocumentID(fields["url"])
+	if !ok {
+		id = strings.TrimSpace(fields["url"])
+		ok = gdriveIDRegex.MatchString(id)
+	}
+	if name == "" || !ok {
+		commonData.Error(commonData.User.Language.JournalTemplateBadURL, nil)
+		server.redirectToReferer(w, r)
+		return
+	}
+
+	templateID, err := server.Queries.AddJournalTemplate(ctx, AddJournalTemplateParams{
+		Name:       name,
+		GdriveFile: id,
+	})
+	if err != nil {
+		commonData.Error(commonData.User.Language.GenericFailed, err)
+		server.redirectToReferer(w, r)
+		return
+	}
+
+	if err := server.validateJournalTemplate(ctx, JournalTemplate{ID: templateID, GdriveFile: id}); err != nil {
+		commonData.Warning(commonData.User.Language.JournalTemplateInvalid, err)
+	} else {
+		commonData.Success(commonData.User.Language.JournalTemplateAdded)
+	}
+
+	server.redirectToReferer(w, r)
+}
+
+func (server *Server) postJournalTemplateValidateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	id, err := server.getPathID(r, "template")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	template, err := server.Queries.GetJournalTemplate(ctx, id)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	if err := server.validateJournalTemplate(ctx, template); err != nil {
+		commonData.Warning(commonData.User.Language.JournalTemplateInvalid, err)
+	} else {
+		commonData.Success(commonData.User.Language.JournalTemplateValid)
+	}
+
+	server.redirectToReferer(w, r)
+}
+
+func (server *Server) postJournalTemplateDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	id, err := server.getPathID(r, "template")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	if n, err := server.Queries.DeleteJournalTemplate(ctx, id); err != nil {
+		commonData.Error(commonData.User.Language.GenericFailed, err)
+	} else if n == 0 {
+		commonData.Warning(commonData.User.Language.GenericNotFound, nil)
+	} else {
+		commonData.Success(commonData.User.Language.JournalTemplateDeleted)
+	}
+
+	server.redirectToReferer(w, r)
+}
+
+func (server *Server) postSpeciesJournalTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	species, err := server.getPathID(r, "species")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	template, err := server.getFormID(r, "template")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	// Zero means the configured template
+	if template == 0 {
+		err = server.Queries.DeleteSpeciesJournalTemplate(ctx, species)
+	} else {
+		err = server.Queries.SetSpeciesJournalTemplate(ctx, SetSpeciesJournalTemplateParams{
+			SpeciesID:  species,
+			TemplateID: template,
+		})
+	}
+	if err != nil {
+		commonData.Error(commonData.User.Language.GenericFailed, err)
+	} else {
+		commonData.Success(commonData.User.Language.JournalTemplateSpeciesSaved)
+	}
+
+	server.redirectToReferer(w, r)
+}
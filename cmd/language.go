@@ -4,6 +4,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
@@ -56,6 +57,7 @@ type Language struct {
 	AdminInviteCode             string
 	AdminRoot                   string
 	AdminDebug                  string
+	AdminManageSearchIndex      string
 
 	AuthLogOut string
 
@@ -65,6 +67,8 @@ type Language struct {
 	CheckinIHaveThePatient string
 	CheckinPatientName     string
 	CheckinYouAreHomeless  string
+	CheckinFinder          string
+	CheckinIntakeCause     string
 
 	DashboardNoPatientsInHome      string
 	DashboardGoToJournal           string
@@ -173,8 +177,14 @@ type Language struct {
 	HomeUnavailableFromInstruction string
 	HomeUnavailableToInstruction   string
 
-	ImportHeader   string
-	ImportPatients string
+	ImportHeader            string
+	ImportPatients          string
+	ImportJournals          string
+	ImportJournalPatient    string
+	ImportJournalStatus     string
+	ImportJournalAttempts   string
+	ImportJournalError      string
+	ImportJournalNotStarted string
 
 	LanguageUpdateFailed string
 
@@ -189,6 +199,13 @@ type Language struct {
 	PatientEventUser      string
 	PatientEventHome      string
 
+	PatientSimilar            string
+	PatientSimilarExplanation string
+	PatientSimilarNone        string
+	PatientSimilarOutcome     string
+	PatientSimilarDaysInCare  string
+	PatientSimilarSimilarity  string
+
 	UserHomes      string
 	UserIsHomeless string
 
@@ -200,6 +217,199 @@ type Language struct {
 	SearchTimePreferenceNone  string
 	SearchTimePreferenceNewer string
 	SearchTimePreferenceOlder string
+	SearchFilterNamespace     string
+	SearchFilterSpecies       string
+	SearchFilterHome          string
+	SearchFilterStatus        string
+	SearchFilterActivity      string
+	SearchFilterAny           string
+	SearchFilterActive        string
+	SearchFilterFormer        string
+	SearchFilterPatientsOnly  string
+
+	SearchIndexStats               string
+	SearchIndexNamespace           string
+	SearchIndexEntries             string
+	SearchIndexSkipped             string
+	SearchIndexLastUpdated         string
+	SearchIndexSkippedEntries      string
+	SearchIndexNoSkippedEntries    string
+	SearchIndexSkipReason          string
+	SearchIndexFolders             string
+	SearchIndexFolder              string
+	SearchIndexFiles               string
+	SearchIndexLastSync            string
+	SearchIndexLastError           string
+	SearchIndexLastFullSync        string
+	SearchIndexLastIncrementalSync string
+	SearchIndexNever               string
+	SearchIndexReindex             string
+	SearchIndexReindexAll          string
+	SearchIndexReindexDocument     string
+	SearchIndexDocumentURL         string
+	SearchIndexReindexQueued       string
+	SearchIndexReindexQueueFull    string
+	SearchIndexNotJournalFolder    string
+	SearchIndexNotIndexedURL       string
+
+	SavedSearches            string
+	SavedSearchSave          string
+	SavedSearchSaved         string
+	SavedSearchDeleted       string
+	SavedSearchNone          string
+	SavedSearchAlert         string
+	SavedSearchAlerts        map[SearchAlert]string
+	SavedSearchEmailDisabled string
+	SavedSearchDigestIntro   string
+
+	Notifications           string
+	NotificationsNone       string
+	NotificationsClear      string
+	NotificationMatch       string
+	NotificationSavedSearch string
+	NotificationTime        string
+
+	Journals            string
+	JournalsNone        string
+	JournalTitle        string
+	JournalContent      string
+	JournalCreated      string
+	JournalUpdated      string
+	JournalEdit         string
+	JournalSaved        string
+	JournalMarkdownHelp string
+	GDriveNotInUse      string
+
+	JournalCreationQueued  string
+	JournalCreationPending string
+	JournalCreationFailed  string
+	JournalUpdateFailed    string
+
+	PatientReadmit               string
+	PatientFinder                string
+	PatientJournalPreview        string
+	PatientJournalPreviewUpdated string
+	PatientIntakeCause           string
+	PatientReadmitButton         string
+	PatientReadmitNotCheckedOut  string
+
+	JournalTemplates            string
+	JournalTemplatesExplanation string
+	JournalTemplatesNone        string
+	JournalTemplateName         string
+	JournalTemplateURL          string
+	JournalTemplateVariables    string
+	JournalTemplateStatus       string
+	JournalTemplateValidate     string
+	JournalTemplateNotValidated string
+	JournalTemplateBadURL       string
+	JournalTemplateInvalid      string
+	JournalTemplateValid        string
+	JournalTemplateAdded        string
+	JournalTemplateDeleted      string
+	JournalTemplateForSpecies   string
+	JournalTemplateDefault      string
+	JournalTemplateSpeciesSaved string
+	JournalTemplateChoose       string
+	AdminManageJournalTemplates string
+
+	GDriveTasks            string
+	GDriveTasksExplanation string
+	GDriveTasksNone        string
+	GDriveTaskType         string
+	GDriveTaskPatient      string
+	GDriveTaskAttempts     string
+	GDriveTaskUpdated      string
+	GDriveTaskNextAttempt  string
+	GDriveTaskLastError    string
+	GDriveTaskRetry        string
+	GDriveTaskRetried      string
+	GDriveTaskStatuses     map[GDriveTaskStatus]string
+
+	JournalEditsFailed string
+
+	JournalArchiveFailed string
+
+	GDriveUnavailable                 string
+	GDriveUnavailableBanner           string
+	GDriveUnavailableDetails          string
+	GDriveUnavailableExplanation      string
+	GDriveLastChecked                 string
+	GDriveRecheck                     string
+	GDriveStillUnavailable            string
+	GDriveAvailableAgain              string
+	PatientJournalArchives            string
+	PatientJournalArchivesExplanation string
+	FileIsJournalArchive              string
+	JournalEditors                    string
+	JournalEditor                     string
+	JournalEditorEdits                string
+	JournalEditorLastEdit             string
+	JournalEditorInHome               string
+
+	AdminSettings               string
+	SettingsExplanation         string
+	SettingsSystem              string
+	SettingsDefault             string
+	SettingsChanged             string
+	SettingsSave                string
+	SettingsSaved               string
+	SettingsUnchanged           string
+	SettingsGDriveInvalid       string
+	SettingsGDriveRefreshFailed string
+	SettingsExtraFoldersHelp    string
+	SettingsArchiveFolderHelp   string
+	RuntimeSettings             map[RuntimeSetting]string
+	JournalVisibilities         map[JournalVisibility]string
+
+	JournalProblems        map[JournalProblem]string
+	JournalUnavailable     string
+	JournalCheckFound      string
+	JournalCheckNotChecked string
+	JournalCheckFailed     string
+	JournalCheckUseName    string
+	JournalProblemPatients string
+
+	OrphanJournals                   string
+	OrphanJournalsExplanation        string
+	OrphanJournalsNone               string
+	OrphanJournalHints               string
+	OrphanJournalCreatePatient       string
+	OrphanJournalCreatePatientButton string
+	OrphanJournalAttach              string
+	OrphanJournalAttachButton        string
+	OrphanJournalSelectPatient       string
+	OrphanJournalPatientCreated      string
+	OrphanJournalAttached            string
+	OrphanJournalAttachFailed        string
+	OrphanJournalNameRequired        string
+
+	GDrivePermissions            string
+	GDrivePermissionsExplanation string
+	GDrivePermissionsAutomatic   string
+	GDrivePermissionsNone        string
+	GDrivePermissionsApply       string
+	GDrivePermissionFolder       string
+	GDrivePermissionAction       string
+	GDrivePermissionActions      map[GDrivePermissionAction]string
+
+	Measurements                   string
+	MeasurementDate                string
+	MeasurementKinds               map[MeasurementKind]string
+	MeasurementValue               string
+	MeasurementsPendingReview      string
+	JournalImportReview            string
+	JournalImportReviewExplanation string
+	JournalImportReviewNone        string
+	JournalImportReviewRow         string
+	JournalImportReviewProblem     string
+	JournalImportReviewAccept      string
+	JournalImportReviewReject      string
+	JournalImportReviewAccepted    string
+	JournalImportReviewRejected    string
+	JournalImportReviewBadValue    string
+	JournalImportProblems          map[JournalImportProblem]string
+	AdminJournalImportReview       string
 
 	NavbarCalendar  string
 	NavbarDashboard string
@@ -260,10 +470,140 @@ func (l *Language) HomeIsUnavailable(name string, note string) string {
 	}
 }
 
+func (l *Language) SearchUnknownQualifier(name string, known []string) string {
+	switch l.ID {
+	case LanguageIDNO:
+		return fmt.Sprintf("Ukjent søkefelt \"%s:\", søker etter det som tekst. Kjente felt: %s.", name, strings.Join(known, ", "))
+	case LanguageIDEN:
+		fallthrough
+	default:
+		return fmt.Sprintf("Unknown search field \"%s:\", searching for it as text. Known fields: %s.", name, strings.Join(known, ", "))
+	}
+}
+
+func (l *Language) SearchQualifierNoMatch(name, value string) string {
+	switch l.ID {
+	case LanguageIDNO:
+		return fmt.Sprintf("Fant ingenting som passer \"%s:%s\", så det ble ignorert.", name, value)
+	case LanguageIDEN:
+		fallthrough
+	default:
+		return fmt.Sprintf("Nothing matches \"%s:%s\", so it was ignored.", name, value)
+	}
+}
+
+func (l *Language) SearchQualifierInvalidDate(name, value string) string {
+	switch l.ID {
+	case LanguageIDNO:
+		return fmt.Sprintf("Ugyldig dato i \"%s:%s\", bruk formatet ÅÅÅÅ-MM-DD.", name, value)
+	case LanguageIDEN:
+		fallthrough
+	default:
+		return fmt.Sprintf("Invalid date in \"%s:%s\", use the format YYYY-MM-DD.", name, value)
+	}
+}
+
+func (l *Language) SavedSearchDigestSubject(n int) string {
+	switch l.ID {
+	case LanguageIDNO:
+		return fmt.Sprintf("bino: %d nye treff for lagrede søk", n)
+	case LanguageIDEN:
+		fallthrough
+	default:
+		return fmt.Sprintf("bino: %d new matches for saved searches", n)
+	}
+}
+
+func (l *Language) GDrivePermissionChangesQueued(n int) string {
+	switch l.ID {
+	case LanguageIDNO:
+		return fmt.Sprintf("%d endringer av tilganger ble lagt i køen.", n)
+	case LanguageIDEN:
+		fallthrough
+	default:
+		return fmt.Sprintf("%d permission changes were queued.", n)
+	}
+}
+
+func (l *Language) JournalEdited(nRevisions int) string {
+	switch l.ID {
+	case LanguageIDNO:
+		if nRevisions > 1 {
+			return fmt.Sprintf("Redigerte journalen (%d versjoner)", nRevisions)
+		}
+		return "Redigerte journalen"
+	case LanguageIDEN:
+		fallthrough
+	default:
+		if nRevisions > 1 {
+			return fmt.Sprintf("Edited the journal (%d revisions)", nRevisions)
+		}
+		return "Edited the journal"
+	}
+}
+
+func (l *Language) SettingInvalid(setting string) string {
+	switch l.ID {
+	case LanguageIDNO:
+		return fmt.Sprintf("Ugyldig verdi for '%s'", setting)
+	case LanguageIDEN:
+		fallthrough
+	default:
+		return fmt.Sprintf("Invalid value for '%s'", setting)
+	}
+}
+
+func (l *Language) JournalAttachDuplicate(names []string) string {
+	switch l.ID {
+	case LanguageIDNO:
+		return fmt.Sprintf("Journalen er også knyttet til %s.", strings.Join(names, ", "))
+	case LanguageIDEN:
+		fallthrough
+	default:
+		return fmt.Sprintf("The journal is also attached to %s.", strings.Join(names, ", "))
+	}
+}
+
+func (l *Language) JournalEditConflict(name string) string {
+	switch l.ID {
+	case LanguageIDNO:
+		return fmt.Sprintf("%s lagret journalen mens du redigerte. Sammenlign med den nyeste versjonen før du lagrer igjen.", name)
+	case LanguageIDEN:
+		fallthrough
+	default:
+		return fmt.Sprintf("%s saved the journal while you were editing. Compare with the latest version before saving again.", name)
+	}
+}
+
 func (l *Language) TODO(s string) string {
 	return fmt.Sprintf("TODO[%s]", s)
 }
 
+// Regconfig is the Postgres text search configuration for the language.
+func (id LanguageID) Regconfig() string {
+	switch id {
+	case LanguageIDEN:
+		return "english"
+	case LanguageIDNO:
+		fallthrough
+	default:
+		return "norwegian"
+	}
+}
+
+// OtherRegconfig is the text search configuration of the other language that
+// documents can be indexed in.
+func (id LanguageID) OtherRegconfig() string {
+	switch id {
+	case LanguageIDEN:
+		return LanguageIDNO.Regconfig()
+	case LanguageIDNO:
+		fallthrough
+	default:
+		return LanguageIDEN.Regconfig()
+	}
+}
+
 var NO = &Language{
 	ID:       LanguageIDNO,
 	Emoji:    "🇳🇴",
@@ -339,6 +679,7 @@ var NO = &Language{
 	AdminInviteCode:             "Kode",
 	AdminRoot:                   "Admin",
 	AdminDebug:                  "Debug",
+	AdminManageSearchIndex:      "Søkeindeks",
 
 	AuthLogOut: "Logg ut",
 
@@ -348,6 +689,8 @@ var NO = &Language{
 	CheckinIHaveThePatient: "Pasienten er her",
 	CheckinPatientName:     "Pasientens navn",
 	CheckinYouAreHomeless:  "Du kan ikke sjekke inn pasienter ennå fordi du ikke er koblet til et rehabhjem.",
+	CheckinFinder:          "Funnet av (valgfritt)",
+	CheckinIntakeCause:     "Årsak til innleggelse (valgfritt)",
 
 	DashboardNoPatientsInHome:      "Ingen pasienter",
 	DashboardGoToJournal:           "Gå til pasientjournal i Google Drive",
@@ -392,7 +735,7 @@ var NO = &Language{
 	GDriveEmailInBino:                      "Email i Bino",
 	GDriveGiveAccess:                       "Gi skrivetilgang",
 	GDriveLoadFoldersFailed:                "Kunne ikke laste inn mapper fra Google Drive",
-	GDriveUserInvited:                      "Brukeren ble invitert til mappen",
+	GDriveUserInvited:                      "Invitasjonen til mappen sendes i bakgrunnen",
 	GDriveCreateJournalForPatient:          "Opprett pasientjournal i Google Drive",
 	GDriveSelectExistingJournalInstruction: "Eller velg en eksisterende journal i Google Drive:",
 	GDriveNoJournalForPatient:              "Det er ikke koblet noen journal til pasienten.",
@@ -453,8 +796,14 @@ var NO = &Language{
 	HomeUnavailableFromInstruction: "Datoen du blir utilgjengelig.",
 	HomeUnavailableToInstruction:   "Siste dato du er utilgjengelig.",
 
-	ImportHeader:   "Importverktøy",
-	ImportPatients: "Importer pasienter",
+	ImportHeader:            "Importverktøy",
+	ImportPatients:          "Importer pasienter",
+	ImportJournals:          "Journaler",
+	ImportJournalPatient:    "Pasient",
+	ImportJournalStatus:     "Status",
+	ImportJournalAttempts:   "Forsøk",
+	ImportJournalError:      "Feil",
+	ImportJournalNotStarted: "Ikke startet",
 
 	LanguageUpdateFailed: "Kunne ikke oppdatere språk",
 
@@ -472,6 +821,13 @@ var NO = &Language{
 	PatientEventUser:      "Endret av",
 	PatientEventHome:      "Rehabhjem",
 
+	PatientSimilar:            "Lignende tidligere pasienter",
+	PatientSimilarExplanation: "Tidligere pasienter med journaler som ligner på denne, helst av samme art.",
+	PatientSimilarNone:        "Fant ingen lignende tidligere pasienter.",
+	PatientSimilarOutcome:     "Utfall",
+	PatientSimilarDaysInCare:  "Dager i rehab",
+	PatientSimilarSimilarity:  "Likhet",
+
 	UserHomes:      "Tilkoblede rehabhjem",
 	UserIsHomeless: "Ingen tilkoblede rehabhjem",
 
@@ -483,6 +839,237 @@ var NO = &Language{
 	SearchTimePreferenceNone:  "Nei",
 	SearchTimePreferenceOlder: "Eldre",
 	SearchTimePreferenceNewer: "Nyere",
+	SearchFilterNamespace:     "Type",
+	SearchFilterSpecies:       "Art",
+	SearchFilterHome:          "Rehabhjem (nåværende eller siste)",
+	SearchFilterStatus:        "Status",
+	SearchFilterActivity:      "Pasienter",
+	SearchFilterAny:           "Alle",
+	SearchFilterActive:        "I rehab nå",
+	SearchFilterFormer:        "Tidligere",
+	SearchFilterPatientsOnly:  "Art, rehabhjem og status gjelder bare pasienter, så journaler og filer som ikke hører til en pasient vises ikke.",
+
+	SearchIndexStats:               "Statistikk",
+	SearchIndexNamespace:           "Type",
+	SearchIndexEntries:             "Oppføringer",
+	SearchIndexSkipped:             "Hoppet over",
+	SearchIndexLastUpdated:         "Sist oppdatert",
+	SearchIndexSkippedEntries:      "Dokumenter som ikke kunne leses",
+	SearchIndexNoSkippedEntries:    "Alle dokumenter ble lest.",
+	SearchIndexSkipReason:          "Feilmelding",
+	SearchIndexFolders:             "Mapper",
+	SearchIndexFolder:              "Mappe",
+	SearchIndexFiles:               "Dokumenter",
+	SearchIndexLastSync:            "Sist gjennomgått",
+	SearchIndexLastError:           "Siste feil",
+	SearchIndexLastFullSync:        "Siste fullstendige gjennomgang: ",
+	SearchIndexLastIncrementalSync: "Siste sjekk etter endringer: ",
+	SearchIndexNever:               "Aldri",
+	SearchIndexReindex:             "Indekser på nytt",
+	SearchIndexReindexAll:          "Indekser alt på nytt",
+	SearchIndexReindexDocument:     "Indekser et dokument på nytt",
+	SearchIndexDocumentURL:         "Lenke til dokumentet",
+	SearchIndexReindexQueued:       "Lagt i kø for ny indeksering. Det kan ta litt tid.",
+	SearchIndexReindexQueueFull:    "For mange forespørsler i kø, prøv igjen senere.",
+	SearchIndexNotJournalFolder:    "Mappen er ikke en av journalmappene.",
+	SearchIndexNotIndexedURL:       "Lenken er ikke til en journal eller fil som indekseres.",
+
+	SavedSearches:      "Lagrede søk",
+	SavedSearchSave:    "Lagre søk",
+	SavedSearchSaved:   "Søket ble lagret.",
+	SavedSearchDeleted: "Søket ble slettet.",
+	SavedSearchNone:    "Ingen lagrede søk. Du kan lagre et søk fra søkesiden.",
+	SavedSearchAlert:   "Varsling",
+	SavedSearchAlerts: map[SearchAlert]string{
+		SearchAlertNone:  "Ingen varsler",
+		SearchAlertApp:   "Varsle i bino",
+		SearchAlertEmail: "Varsle i bino og på e-post",
+	},
+	SavedSearchEmailDisabled: "E-postvarsling er ikke satt opp, så du får bare varsler i bino.",
+	SavedSearchDigestIntro:   "Det er nye treff for dine lagrede søk i bino:",
+
+	Notifications:           "Varsler",
+	NotificationsNone:       "Ingen varsler.",
+	NotificationsClear:      "Fjern alle",
+	NotificationMatch:       "Treff",
+	NotificationSavedSearch: "Lagret søk",
+	NotificationTime:        "Tid",
+
+	Journals:            "Journaler",
+	JournalsNone:        "Ingen journaler ennå.",
+	JournalTitle:        "Tittel",
+	JournalContent:      "Innhold",
+	JournalCreated:      "Opprettet",
+	JournalUpdated:      "Sist endret",
+	JournalEdit:         "Rediger",
+	JournalSaved:        "Journalen ble lagret.",
+	JournalMarkdownHelp: "Skrives i Markdown: # Overskrift, **fet**, *kursiv*, - punktliste.",
+	GDriveNotInUse:      "Bino er ikke satt opp til å bruke Google Drive for journaler.",
+
+	JournalCreationQueued:  "Journalen opprettes i bakgrunnen.",
+	JournalCreationPending: "Journalen opprettes...",
+	JournalCreationFailed:  "Kunne ikke opprette journal",
+	JournalUpdateFailed:    "Kunne ikke oppdatere navnet på journalen",
+
+	PatientReadmit:               "Ta inn igjen",
+	PatientFinder:                "Funnet av",
+	PatientIntakeCause:           "Årsak til innleggelse",
+	PatientJournalPreview:        "Journal",
+	PatientJournalPreviewUpdated: "Sist oppdatert",
+	PatientReadmitButton:         "Ta inn",
+	PatientReadmitNotCheckedOut:  "Bare utskrevne pasienter kan tas inn igjen",
+
+	JournalTemplates:            "Journalmaler",
+	JournalTemplatesExplanation: "Arter kan ha egne maler for nye journaler. Malene er Google Docs-dokumenter, og kan bruke variablene som er beskrevet i README. Ingen av variablene er påkrevd, men navnet på malen må bruke minst én av dem.",
+	JournalTemplatesNone:        "Ingen maler ennå. Alle journaler lages fra standardmalen.",
+	JournalTemplateName:         "Navn",
+	JournalTemplateURL:          "Google Docs-lenke eller ID",
+	JournalTemplateVariables:    "Variabler",
+	JournalTemplateStatus:       "Status",
+	JournalTemplateValidate:     "Sjekk",
+	JournalTemplateNotValidated: "Ikke sjekket",
+	JournalTemplateBadURL:       "Oppgi et navn og en lenke til et Google Docs-dokument.",
+	JournalTemplateInvalid:      "Malen kan ikke brukes",
+	JournalTemplateValid:        "Malen er i orden.",
+	JournalTemplateAdded:        "Malen ble lagt til.",
+	JournalTemplateDeleted:      "Malen ble slettet.",
+	JournalTemplateForSpecies:   "Mal per art",
+	JournalTemplateDefault:      "Standard",
+	JournalTemplateSpeciesSaved: "Malen for arten ble lagret.",
+	JournalTemplateChoose:       "Mal",
+	AdminManageJournalTemplates: "Journalmaler",
+
+	GDriveTasks:            "Google Drive-oppgaver",
+	GDriveTasksExplanation: "Endringer i Google Drive gjøres i bakgrunnen, og prøves på nytt hvis Google Drive ikke svarer.",
+	GDriveTasksNone:        "Ingen oppgaver.",
+	GDriveTaskType:         "Oppgave",
+	GDriveTaskPatient:      "Pasient",
+	GDriveTaskAttempts:     "Forsøk",
+	GDriveTaskUpdated:      "Sist endret",
+	GDriveTaskNextAttempt:  "Neste forsøk",
+	GDriveTaskLastError:    "Siste feil",
+	GDriveTaskRetry:        "Prøv igjen",
+	GDriveTaskRetried:      "Oppgaven blir prøvd igjen.",
+	GDriveTaskStatuses: map[GDriveTaskStatus]string{
+		GDriveTaskStatusPending: "Venter",
+		GDriveTaskStatusRunning: "Kjører",
+		GDriveTaskStatusDone:    "Ferdig",
+		GDriveTaskStatusFailed:  "Feilet",
+	},
+
+	JournalEditsFailed: "Kunne ikke hente endringer i journalen",
+
+	JournalArchiveFailed: "Kunne ikke lagre en PDF-kopi av journalen",
+
+	GDriveUnavailable:                 "Google Drive er utilgjengelig",
+	GDriveUnavailableBanner:           "Google Drive er utilgjengelig, så journaler kan ikke opprettes eller oppdateres. Resten av bino virker som vanlig.",
+	GDriveUnavailableDetails:          "Se detaljer",
+	GDriveUnavailableExplanation:      "Journalmappen eller journalmalen kunne ikke hentes fra Google Drive. Funksjoner som bruker Google Drive er skrudd av, og oppgaver blir liggende i køen, til det virker igjen. bino prøver igjen automatisk.",
+	GDriveLastChecked:                 "Sist sjekket",
+	GDriveRecheck:                     "Sjekk igjen nå",
+	GDriveStillUnavailable:            "Google Drive er fortsatt utilgjengelig",
+	GDriveAvailableAgain:              "Google Drive virker igjen",
+	PatientJournalArchives:            "Arkiverte journaler",
+	PatientJournalArchivesExplanation: "PDF-kopier av journalen fra da pasienten ble skrevet ut. De kan ikke endres eller slettes.",
+	FileIsJournalArchive:              "Filen er en arkivert journal og kan ikke slettes",
+	JournalEditors:                    "Endringer i journalen",
+	JournalEditor:                     "Redigert av",
+	JournalEditorEdits:                "Antall endringer",
+	JournalEditorLastEdit:             "Sist endret",
+	JournalEditorInHome:               "I nåværende hjem",
+
+	AdminSettings:               "Innstillinger",
+	SettingsExplanation:         "Innstillinger som endres her overstyrer config.json og tas i bruk med en gang, uten omstart.",
+	SettingsSystem:              "Personvern og system",
+	SettingsDefault:             "Verdi i config.json",
+	SettingsChanged:             "Sist endret",
+	SettingsSave:                "Lagre innstillinger",
+	SettingsSaved:               "Innstillingene ble lagret",
+	SettingsUnchanged:           "Ingen innstillinger ble endret",
+	SettingsGDriveInvalid:       "Innstillingene ble ikke lagret fordi mappene eller malen ikke kunne hentes fra Google Drive",
+	SettingsGDriveRefreshFailed: "Innstillingene ble lagret, men Google Drive kunne ikke brukes med dem",
+	SettingsExtraFoldersHelp:    "Én mappe per linje. Journaler i disse mappene blir også søkbare.",
+	SettingsArchiveFolderHelp:   "La stå tom for å la journalene ligge der de er når pasienten skrives ut.",
+	RuntimeSettings: map[RuntimeSetting]string{
+		RuntimeSettingJournalFolder:             "Journalmappe",
+		RuntimeSettingTemplateFile:              "Journalmal",
+		RuntimeSettingExtraJournalFolders:       "Ekstra journalmapper",
+		RuntimeSettingArchiveFolder:             "Arkivmappe",
+		RuntimeSettingLogDeletionPolicy:         "Dager før logger slettes",
+		RuntimeSettingRevokeConsentPolicy:       "Dager før samtykke til logging trekkes tilbake",
+		RuntimeSettingRehabberJournalVisibility: "Journaler rehabiliterere kan lese i søk",
+		RuntimeSettingSystemBaseURL:             "Adressen til bino",
+	},
+	JournalVisibilities: map[JournalVisibility]string{
+		JournalVisibilityOwnHomes: "Bare journaler i egne hjem",
+		JournalVisibilityAllHomes: "Journaler i alle hjem",
+	},
+
+	JournalProblems: map[JournalProblem]string{
+		JournalProblemNone:           "",
+		JournalProblemUnreadable:     "Journalen finnes ikke, eller bino har ikke tilgang til den",
+		JournalProblemTrashed:        "Journalen ligger i papirkurven",
+		JournalProblemOutsideFolders: "Journalen ligger ikke i en av journalmappene",
+	},
+	JournalUnavailable:     "Journalen kan ikke brukes",
+	JournalCheckFound:      "Fant journalen",
+	JournalCheckNotChecked: "Journalen kan ikke sjekkes uten Google Drive.",
+	JournalCheckFailed:     "Kunne ikke sjekke journalen",
+	JournalCheckUseName:    "Bruk navnet",
+	JournalProblemPatients: "Pasienter med journal som ikke kan åpnes",
+
+	OrphanJournals:                   "Journaler uten pasient",
+	OrphanJournalsExplanation:        "Disse journalene ligger i journalmappene, men hører ikke til noen pasient. Lag en pasient fra journalen, eller knytt den til en pasient som ikke har journal.",
+	OrphanJournalsNone:               "Alle journaler hører til en pasient.",
+	OrphanJournalHints:               "Fra tittelen",
+	OrphanJournalCreatePatient:       "Ny pasient",
+	OrphanJournalCreatePatientButton: "Lag pasient",
+	OrphanJournalAttach:              "Eksisterende pasient",
+	OrphanJournalAttachButton:        "Knytt til pasient",
+	OrphanJournalSelectPatient:       "Velg pasient",
+	OrphanJournalPatientCreated:      "Pasienten ble laget, og journalen ble knyttet til den.",
+	OrphanJournalAttached:            "Journalen ble knyttet til pasienten.",
+	OrphanJournalAttachFailed:        "Kunne ikke knytte journalen til pasienten",
+	OrphanJournalNameRequired:        "Pasienten må ha et navn",
+
+	GDrivePermissions:            "Tilganger til journalmappene",
+	GDrivePermissionsExplanation: "Brukere med tilgangsnivå rehabiliterer eller høyere skal kunne redigere journalmappene, og ingen andre skal ha tilgang. Dette er endringene som trengs for det. Ingenting er endret ennå.",
+	GDrivePermissionsAutomatic:   "Endringene gjøres automatisk én gang i døgnet.",
+	GDrivePermissionsNone:        "Tilgangene stemmer med brukerne i bino.",
+	GDrivePermissionsApply:       "Gjør endringene",
+	GDrivePermissionFolder:       "Mappe",
+	GDrivePermissionAction:       "Endring",
+	GDrivePermissionActions: map[GDrivePermissionAction]string{
+		GDrivePermissionActionGrant:  "Gi tilgang",
+		GDrivePermissionActionRevoke: "Fjern tilgang",
+	},
+
+	Measurements:    "Målinger fra journalen",
+	MeasurementDate: "Dato",
+	MeasurementKinds: map[MeasurementKind]string{
+		MeasurementKindWeight:     "Vekt",
+		MeasurementKindMedication: "Medisin",
+	},
+	MeasurementValue:               "Verdi",
+	MeasurementsPendingReview:      "Noen rader i journalen må sjekkes før de kan importeres.",
+	JournalImportReview:            "Rader fra journaler til gjennomgang",
+	JournalImportReviewExplanation: "Disse radene fra tabeller i journaler kunne ikke leses sikkert. Rett opp dato og verdi og godta dem, eller avvis dem. Avviste rader blir ikke importert igjen.",
+	JournalImportReviewNone:        "Ingen rader venter på gjennomgang.",
+	JournalImportReviewRow:         "Rad i journalen",
+	JournalImportReviewProblem:     "Problem",
+	JournalImportReviewAccept:      "Godta",
+	JournalImportReviewReject:      "Avvis",
+	JournalImportReviewAccepted:    "Raden ble importert.",
+	JournalImportReviewRejected:    "Raden ble avvist.",
+	JournalImportReviewBadValue:    "Oppgi en gyldig dato og verdi.",
+	JournalImportProblems: map[JournalImportProblem]string{
+		JournalImportProblemNone:            "",
+		JournalImportProblemMissingDate:     "Mangler dato",
+		JournalImportProblemDateWithoutYear: "Datoen mangler år",
+		JournalImportProblemBadDate:         "Ukjent datoformat",
+		JournalImportProblemBadValue:        "Ukjent verdi",
+	},
+	AdminJournalImportReview: "Rader fra journaler til gjennomgang",
 
 	Status: map[Status]string{
 		StatusUnknown:                        "Ukjent",
@@ -510,11 +1097,13 @@ var NO = &Language{
 		EventJournalCreated:                 "Opprettet journal i Google Drive",
 		EventJournalAttached:                "Koblet til journal i Google Drive",
 		EventJournalDetached:                "Koblet fra journal i Google Drive",
+		EventReadmitted:                     "Tatt inn igjen",
 	},
 
 	MatchType: map[MatchType]string{
 		MatchTypeJournal: "📝 Journal",
 		MatchTypePatient: "❤️‍🩹 Pasient",
+		MatchTypeFile:    "📎 Fil",
 	},
 
 	CapabilitiesLink:              "Les om brukertilganger i Bino",
@@ -522,26 +1111,31 @@ var NO = &Language{
 	CapabilitiesYourAccessLevelIs: "Ditt tilgangsnivå er: ",
 	CapabilitiesExplanation:       "Her er det brukere med forskjellig tilgangsnivå kan gjøre: ",
 	Capabilities: map[Capability]string{
-		CapViewAllActivePatients: "Se alle pasienter som er i rehab",
-		CapViewAllFormerPatients: "Se alle pasienter som har vært i rehab",
-		CapViewAllHomes:          "Se alle rehabhjem",
-		CapViewAllUsers:          "Se alle brukere",
-		CapViewCalendar:          "Se kalenderen",
-		CapSearch:                "Søke i journaler",
-		CapSetOwnPreferences:     "Sette egne preferanser for språk o.l.",
-		CapCheckInPatient:        "Sjekke inn pasienter",
-		CapManageOwnPatients:     "Endre informasjon om egne pasienter",
-		CapManageAllPatients:     "Redigere alle pasienter",
-		CapManageOwnHomes:        "Endre informasjon om eget rehabhjem",
-		CapManageAllHomes:        "Endre informasjon om alle rehabhjem",
-		CapCreatePatientJournal:  "Opprette nye pasientjournaler i Google Drive",
-		CapManageSpecies:         "Endre listen over arter",
-		CapManageUsers:           "Endre informasjon om andre brukere",
-		CapDeleteUsers:           "Slette brukere",
-		CapViewAdminTools:        "Se liste over adminverktøy",
-		CapViewGDriveSettings:    "Se Google Drive-innstillinger",
-		CapInviteToGDrive:        "Invitere brukere til Google Drive-mappen fra Bino",
-		CapInviteToBino:          "Invitere nye brukere til Bino",
+		CapViewAllActivePatients:  "Se alle pasienter som er i rehab",
+		CapViewAllFormerPatients:  "Se alle pasienter som har vært i rehab",
+		CapViewAllHomes:           "Se alle rehabhjem",
+		CapViewAllUsers:           "Se alle brukere",
+		CapViewCalendar:           "Se kalenderen",
+		CapSearch:                 "Søke i journaler",
+		CapSetOwnPreferences:      "Sette egne preferanser for språk o.l.",
+		CapCheckInPatient:         "Sjekke inn pasienter",
+		CapManageOwnPatients:      "Endre informasjon om egne pasienter",
+		CapManageAllPatients:      "Redigere alle pasienter",
+		CapManageOwnHomes:         "Endre informasjon om eget rehabhjem",
+		CapManageAllHomes:         "Endre informasjon om alle rehabhjem",
+		CapCreatePatientJournal:   "Opprette nye pasientjournaler i Google Drive",
+		CapManageSpecies:          "Endre listen over arter",
+		CapManageUsers:            "Endre informasjon om andre brukere",
+		CapDeleteUsers:            "Slette brukere",
+		CapViewAdminTools:         "Se liste over adminverktøy",
+		CapViewGDriveSettings:     "Se Google Drive-innstillinger",
+		CapInviteToGDrive:         "Invitere brukere til Google Drive-mappen fra Bino",
+		CapManageJournalTemplates: "Administrere journalmaler",
+		CapManageOrphanJournals:   "Knytte journaler uten pasient til pasienter",
+		CapManageGDriveSettings:   "Feilsøke og endre oppsettet av Google Drive",
+		CapManageSettings:         "Endre innstillinger for personvern og systemet",
+		CapInviteToBino:           "Invitere nye brukere til Bino",
+		CapManageSearchIndex:      "Administrere søkeindeksen",
 	},
 }
 
@@ -621,6 +1215,7 @@ var EN = &Language{
 	AdminInviteCode:             "Code",
 	AdminRoot:                   "Admin",
 	AdminDebug:                  "Debug",
+	AdminManageSearchIndex:      "Search index",
 
 	AuthLogOut: "Log out",
 
@@ -630,6 +1225,8 @@ var EN = &Language{
 	CheckinIHaveThePatient: "The patient is here",
 	CheckinPatientName:     "Name of the patient",
 	CheckinYouAreHomeless:  "You can't check in patients yet because you're not connected to a rehab home.",
+	CheckinFinder:          "Found by (optional)",
+	CheckinIntakeCause:     "Reason for intake (optional)",
 
 	DashboardNoPatientsInHome:      "No patients",
 	DashboardGoToJournal:           "Go to patient journal in Google Drive",
@@ -676,7 +1273,7 @@ var EN = &Language{
 	GDriveLoadFoldersFailed:                "Failed to load folders from Google Drive",
 	GDriveBaseDirUpdated:                   "Google Drive journal folder was updated. Remember to also update the template.",
 	GDriveTemplateUpdated:                  "Template journal was updated",
-	GDriveUserInvited:                      "The user was invited to the journal folder",
+	GDriveUserInvited:                      "The invitation to the journal folder is being sent in the background",
 	GDriveCreateJournalForPatient:          "Create journal in Google Drive",
 	GDriveSelectExistingJournalInstruction: "Or connect an existing journal in Google Drive:",
 	GDriveNoJournalForPatient:              "No journal found",
@@ -737,8 +1334,14 @@ var EN = &Language{
 	HomeUnavailableFromInstruction: "The date when you become unavailable.",
 	HomeUnavailableToInstruction:   "The last date when you are unavailable.",
 
-	ImportHeader:   "Importer",
-	ImportPatients: "Importe patients",
+	ImportHeader:            "Importer",
+	ImportPatients:          "Importe patients",
+	ImportJournals:          "Journals",
+	ImportJournalPatient:    "Patient",
+	ImportJournalStatus:     "Status",
+	ImportJournalAttempts:   "Attempts",
+	ImportJournalError:      "Error",
+	ImportJournalNotStarted: "Not started",
 
 	LanguageUpdateFailed: "Failed to update language",
 
@@ -753,6 +1356,13 @@ var EN = &Language{
 	PatientEventUser:      "User",
 	PatientEventHome:      "Home",
 
+	PatientSimilar:            "Similar past patients",
+	PatientSimilarExplanation: "Former patients whose journals resemble this one, preferably of the same species.",
+	PatientSimilarNone:        "No similar past patients found.",
+	PatientSimilarOutcome:     "Outcome",
+	PatientSimilarDaysInCare:  "Days in care",
+	PatientSimilarSimilarity:  "Similarity",
+
 	UserHomes:      "Associated rehab homes",
 	UserIsHomeless: "No associated rehab homes",
 
@@ -764,6 +1374,237 @@ var EN = &Language{
 	SearchTimePreferenceNone:  "None",
 	SearchTimePreferenceOlder: "Older",
 	SearchTimePreferenceNewer: "Newer",
+	SearchFilterNamespace:     "Type",
+	SearchFilterSpecies:       "Species",
+	SearchFilterHome:          "Home (current or last)",
+	SearchFilterStatus:        "Status",
+	SearchFilterActivity:      "Patients",
+	SearchFilterAny:           "All",
+	SearchFilterActive:        "Currently in rehab",
+	SearchFilterFormer:        "Former",
+	SearchFilterPatientsOnly:  "Species, home and status only apply to patients, so journals and files that don't belong to a patient are not shown.",
+
+	SearchIndexStats:               "Statistics",
+	SearchIndexNamespace:           "Type",
+	SearchIndexEntries:             "Entries",
+	SearchIndexSkipped:             "Skipped",
+	SearchIndexLastUpdated:         "Last updated",
+	SearchIndexSkippedEntries:      "Documents that could not be read",
+	SearchIndexNoSkippedEntries:    "All documents were read.",
+	SearchIndexSkipReason:          "Error",
+	SearchIndexFolders:             "Folders",
+	SearchIndexFolder:              "Folder",
+	SearchIndexFiles:               "Documents",
+	SearchIndexLastSync:            "Last synced",
+	SearchIndexLastError:           "Last error",
+	SearchIndexLastFullSync:        "Last full sync: ",
+	SearchIndexLastIncrementalSync: "Last check for changes: ",
+	SearchIndexNever:               "Never",
+	SearchIndexReindex:             "Reindex",
+	SearchIndexReindexAll:          "Reindex everything",
+	SearchIndexReindexDocument:     "Reindex a document",
+	SearchIndexDocumentURL:         "Link to the document",
+	SearchIndexReindexQueued:       "Queued for reindexing. It may take a while.",
+	SearchIndexReindexQueueFull:    "Too many requests queued, try again later.",
+	SearchIndexNotJournalFolder:    "The folder is not one of the journal folders.",
+	SearchIndexNotIndexedURL:       "The link is not to a journal or file that is indexed.",
+
+	SavedSearches:      "Saved searches",
+	SavedSearchSave:    "Save search",
+	SavedSearchSaved:   "The search was saved.",
+	SavedSearchDeleted: "The search was deleted.",
+	SavedSearchNone:    "No saved searches. You can save a search from the search page.",
+	SavedSearchAlert:   "Alerts",
+	SavedSearchAlerts: map[SearchAlert]string{
+		SearchAlertNone:  "No alerts",
+		SearchAlertApp:   "Notify in bino",
+		SearchAlertEmail: "Notify in bino and by email",
+	},
+	SavedSearchEmailDisabled: "Email alerts are not configured, so you will only be notified in bino.",
+	SavedSearchDigestIntro:   "There are new matches for your saved searches in bino:",
+
+	Notifications:           "Notifications",
+	NotificationsNone:       "No notifications.",
+	NotificationsClear:      "Clear all",
+	NotificationMatch:       "Match",
+	NotificationSavedSearch: "Saved search",
+	NotificationTime:        "Time",
+
+	Journals:            "Journals",
+	JournalsNone:        "No journals yet.",
+	JournalTitle:        "Title",
+	JournalContent:      "Content",
+	JournalCreated:      "Created",
+	JournalUpdated:      "Last changed",
+	JournalEdit:         "Edit",
+	JournalSaved:        "The journal was saved.",
+	JournalMarkdownHelp: "Written in Markdown: # Heading, **bold**, *italic*, - bullet list.",
+	GDriveNotInUse:      "Bino is not set up to use Google Drive for journals.",
+
+	JournalCreationQueued:  "The journal is being created in the background.",
+	JournalCreationPending: "Creating journal...",
+	JournalCreationFailed:  "Could not create journal",
+	JournalUpdateFailed:    "Could not update the name of the journal",
+
+	PatientReadmit:               "Readmit",
+	PatientFinder:                "Found by",
+	PatientIntakeCause:           "Reason for intake",
+	PatientJournalPreview:        "Journal",
+	PatientJournalPreviewUpdated: "Last updated",
+	PatientReadmitButton:         "Readmit",
+	PatientReadmitNotCheckedOut:  "Only checked out patients can be readmitted",
+
+	JournalTemplates:            "Journal templates",
+	JournalTemplatesExplanation: "Species can have their own template for new journals. Templates are Google Docs, and can use the variables described in the README. None of the variables are required, but the name of the template must use at least one of them.",
+	JournalTemplatesNone:        "No templates yet. All journals are created from the default template.",
+	JournalTemplateName:         "Name",
+	JournalTemplateURL:          "Google Docs link or ID",
+	JournalTemplateVariables:    "Variables",
+	JournalTemplateStatus:       "Status",
+	JournalTemplateValidate:     "Check",
+	JournalTemplateNotValidated: "Not checked",
+	JournalTemplateBadURL:       "Enter a name and a link to a Google Docs document.",
+	JournalTemplateInvalid:      "The template can't be used",
+	JournalTemplateValid:        "The template is OK.",
+	JournalTemplateAdded:        "The template was added.",
+	JournalTemplateDeleted:      "The template was deleted.",
+	JournalTemplateForSpecies:   "Template per species",
+	JournalTemplateDefault:      "Default",
+	JournalTemplateSpeciesSaved: "The template for the species was saved.",
+	JournalTemplateChoose:       "Template",
+	AdminManageJournalTemplates: "Journal templates",
+
+	GDriveTasks:            "Google Drive tasks",
+	GDriveTasksExplanation: "Changes to Google Drive are made in the background, and retried if Google Drive doesn't respond.",
+	GDriveTasksNone:        "No tasks.",
+	GDriveTaskType:         "Task",
+	GDriveTaskPatient:      "Patient",
+	GDriveTaskAttempts:     "Attempts",
+	GDriveTaskUpdated:      "Last changed",
+	GDriveTaskNextAttempt:  "Next attempt",
+	GDriveTaskLastError:    "Last error",
+	GDriveTaskRetry:        "Retry",
+	GDriveTaskRetried:      "The task will be retried.",
+	GDriveTaskStatuses: map[GDriveTaskStatus]string{
+		GDriveTaskStatusPending: "Pending",
+		GDriveTaskStatusRunning: "Running",
+		GDriveTaskStatusDone:    "Done",
+		GDriveTaskStatusFailed:  "Failed",
+	},
+
+	JournalEditsFailed: "Could not fetch changes to the journal",
+
+	JournalArchiveFailed: "Could not store a PDF copy of the journal",
+
+	GDriveUnavailable:                 "Google Drive is unavailable",
+	GDriveUnavailableBanner:           "Google Drive is unavailable, so journals can't be created or updated. The rest of bino works as usual.",
+	GDriveUnavailableDetails:          "See details",
+	GDriveUnavailableExplanation:      "The journal folder or the journal template could not be fetched from Google Drive. Features that use Google Drive are turned off, and tasks wait in the queue, until it works again. bino tries again automatically.",
+	GDriveLastChecked:                 "Last checked",
+	GDriveRecheck:                     "Check again now",
+	GDriveStillUnavailable:            "Google Drive is still unavailable",
+	GDriveAvailableAgain:              "Google Drive works again",
+	PatientJournalArchives:            "Archived journals",
+	PatientJournalArchivesExplanation: "PDF copies of the journal from when the patient was checked out. They can't be changed or deleted.",
+	FileIsJournalArchive:              "The file is an archived journal and can't be deleted",
+	JournalEditors:                    "Changes to the journal",
+	JournalEditor:                     "Edited by",
+	JournalEditorEdits:                "Number of edits",
+	JournalEditorLastEdit:             "Last edited",
+	JournalEditorInHome:               "In current home",
+
+	AdminSettings:               "Settings",
+	SettingsExplanation:         "Settings changed here override config.json and are applied right away, without a restart.",
+	SettingsSystem:              "Privacy and system",
+	SettingsDefault:             "Value in config.json",
+	SettingsChanged:             "Last changed",
+	SettingsSave:                "Save settings",
+	SettingsSaved:               "The settings were saved",
+	SettingsUnchanged:           "No settings were changed",
+	SettingsGDriveInvalid:       "The settings were not saved because the folders or the template could not be fetched from Google Drive",
+	SettingsGDriveRefreshFailed: "The settings were saved, but Google Drive could not be used with them",
+	SettingsExtraFoldersHelp:    "One folder per line. Journals in these folders can also be searched.",
+	SettingsArchiveFolderHelp:   "Leave empty to leave journals where they are when the patient is checked out.",
+	RuntimeSettings: map[RuntimeSetting]string{
+		RuntimeSettingJournalFolder:             "Journal folder",
+		RuntimeSettingTemplateFile:              "Journal template",
+		RuntimeSettingExtraJournalFolders:       "Extra journal folders",
+		RuntimeSettingArchiveFolder:             "Archive folder",
+		RuntimeSettingLogDeletionPolicy:         "Days before logs are deleted",
+		RuntimeSettingRevokeConsentPolicy:       "Days before consent to logging is revoked",
+		RuntimeSettingRehabberJournalVisibility: "Journals rehabbers can read in search",
+		RuntimeSettingSystemBaseURL:             "Address of bino",
+	},
+	JournalVisibilities: map[JournalVisibility]string{
+		JournalVisibilityOwnHomes: "Only journals in their own homes",
+		JournalVisibilityAllHomes: "Journals in all homes",
+	},
+
+	JournalProblems: map[JournalProblem]string{
+		JournalProblemNone:           "",
+		JournalProblemUnreadable:     "The journal doesn't exist, or bino can't access it",
+		JournalProblemTrashed:        "The journal is in the trash",
+		JournalProblemOutsideFolders: "The journal is not in one of the journal folders",
+	},
+	JournalUnavailable:     "The journal can't be used",
+	JournalCheckFound:      "Found the journal",
+	JournalCheckNotChecked: "The journal can't be checked without Google Drive.",
+	JournalCheckFailed:     "Could not check the journal",
+	JournalCheckUseName:    "Use the name",
+	JournalProblemPatients: "Patients whose journal can't be opened",
+
+	OrphanJournals:                   "Journals without a patient",
+	OrphanJournalsExplanation:        "These journals are in the journal folders, but don't belong to any patient. Create a patient from the journal, or attach it to a patient who has no journal.",
+	OrphanJournalsNone:               "All journals belong to a patient.",
+	OrphanJournalHints:               "From the title",
+	OrphanJournalCreatePatient:       "New patient",
+	OrphanJournalCreatePatientButton: "Create patient",
+	OrphanJournalAttach:              "Existing patient",
+	OrphanJournalAttachButton:        "Attach to patient",
+	OrphanJournalSelectPatient:       "Select patient",
+	OrphanJournalPatientCreated:      "The patient was created, and the journal was attached to it.",
+	OrphanJournalAttached:            "The journal was attached to the patient.",
+	OrphanJournalAttachFailed:        "Could not attach the journal to the patient",
+	OrphanJournalNameRequired:        "The patient must have a name",
+
+	GDrivePermissions:            "Journal folder permissions",
+	GDrivePermissionsExplanation: "Users with rehabber access or higher should be able to edit the journal folders, and nobody else should have access. These are the changes needed for that. Nothing has been changed yet.",
+	GDrivePermissionsAutomatic:   "The changes are applied automatically once a day.",
+	GDrivePermissionsNone:        "The permissions match the users in bino.",
+	GDrivePermissionsApply:       "Apply changes",
+	GDrivePermissionFolder:       "Folder",
+	GDrivePermissionAction:       "Change",
+	GDrivePermissionActions: map[GDrivePermissionAction]string{
+		GDrivePermissionActionGrant:  "Grant access",
+		GDrivePermissionActionRevoke: "Revoke access",
+	},
+
+	Measurements:    "Measurements from the journal",
+	MeasurementDate: "Date",
+	MeasurementKinds: map[MeasurementKind]string{
+		MeasurementKindWeight:     "Weight",
+		MeasurementKindMedication: "Medication",
+	},
+	MeasurementValue:               "Value",
+	MeasurementsPendingReview:      "Some rows in the journal need a review before they can be imported.",
+	JournalImportReview:            "Journal rows to review",
+	JournalImportReviewExplanation: "These rows from tables in journals could not be read with certainty. Correct the date and value and accept them, or reject them. Rejected rows are not imported again.",
+	JournalImportReviewNone:        "No rows are waiting for review.",
+	JournalImportReviewRow:         "Row in the journal",
+	JournalImportReviewProblem:     "Problem",
+	JournalImportReviewAccept:      "Accept",
+	JournalImportReviewReject:      "Reject",
+	JournalImportReviewAccepted:    "The row was imported.",
+	JournalImportReviewRejected:    "The row was rejected.",
+	JournalImportReviewBadValue:    "Enter a valid date and value.",
+	JournalImportProblems: map[JournalImportProblem]string{
+		JournalImportProblemNone:            "",
+		JournalImportProblemMissingDate:     "Missing date",
+		JournalImportProblemDateWithoutYear: "The date has no year",
+		JournalImportProblemBadDate:         "Unknown date format",
+		JournalImportProblemBadValue:        "Unknown value",
+	},
+	AdminJournalImportReview: "Journal rows to review",
 
 	Status: map[Status]string{
 		StatusUnknown:                        "Unknown",
@@ -791,11 +1632,13 @@ var EN = &Language{
 		EventJournalCreated:                 "Created journal",
 		EventJournalAttached:                "Linked journal",
 		EventJournalDetached:                "Unlinked journal",
+		EventReadmitted:                     "Readmitted",
 	},
 
 	MatchType: map[MatchType]string{
 		MatchTypeJournal: "📝 Journal",
 		MatchTypePatient: "❤️‍🩹 Patient",
+		MatchTypeFile:    "📎 File",
 	},
 
 	CapabilitiesHeader:            "Access levels and capabilities",
@@ -803,26 +1646,31 @@ var EN = &Language{
 	CapabilitiesYourAccessLevelIs: "Your access level is: ",
 	CapabilitiesExplanation:       "Here is the list of actions users with different levels can perform: ",
 	Capabilities: map[Capability]string{
-		CapViewAllActivePatients: "View all patients currently in rehab",
-		CapViewAllFormerPatients: "View all patients who have been in rehab",
-		CapViewAllHomes:          "View all rehab homes",
-		CapViewAllUsers:          "View all users",
-		CapViewCalendar:          "View the calendar",
-		CapSearch:                "Search in journals",
-		CapSetOwnPreferences:     "Set own preferences for language etc.",
-		CapCheckInPatient:        "Check in patients",
-		CapManageOwnPatients:     "Edit information about own patients",
-		CapManageAllPatients:     "Edit all patients",
-		CapManageOwnHomes:        "Edit information about own rehab home",
-		CapManageAllHomes:        "Edit information about all rehab homes",
-		CapCreatePatientJournal:  "Create new patient journals in Google Drive",
-		CapManageSpecies:         "Edit the list of species",
-		CapManageUsers:           "Edit information about other users",
-		CapDeleteUsers:           "Delete users",
-		CapViewAdminTools:        "View list of admin tools",
-		CapViewGDriveSettings:    "View Google Drive settings",
-		CapInviteToGDrive:        "Invite users to the Google Drive folder from Bino",
-		CapInviteToBino:          "Invite new users to Bino",
+		CapViewAllActivePatients:  "View all patients currently in rehab",
+		CapViewAllFormerPatients:  "View all patients who have been in rehab",
+		CapViewAllHomes:           "View all rehab homes",
+		CapViewAllUsers:           "View all users",
+		CapViewCalendar:           "View the calendar",
+		CapSearch:                 "Search in journals",
+		CapSetOwnPreferences:      "Set own preferences for language etc.",
+		CapCheckInPatient:         "Check in patients",
+		CapManageOwnPatients:      "Edit information about own patients",
+		CapManageAllPatients:      "Edit all patients",
+		CapManageOwnHomes:         "Edit information about own rehab home",
+		CapManageAllHomes:         "Edit information about all rehab homes",
+		CapCreatePatientJournal:   "Create new patient journals in Google Drive",
+		CapManageSpecies:          "Edit the list of species",
+		CapManageUsers:            "Edit information about other users",
+		CapDeleteUsers:            "Delete users",
+		CapViewAdminTools:         "View list of admin tools",
+		CapViewGDriveSettings:     "View Google Drive settings",
+		CapInviteToGDrive:         "Invite users to the Google Drive folder from Bino",
+		CapManageJournalTemplates: "Manage journal templates",
+		CapManageOrphanJournals:   "Attach journals without a patient to patients",
+		CapManageGDriveSettings:   "Troubleshoot and change the Google Drive setup",
+		CapManageSettings:         "Change privacy and system settings",
+		CapInviteToBino:           "Invite new users to Bino",
+		CapManageSearchIndex:      "Manage the search index",
 	},
 }
 
@@ -930,6 +1778,21 @@ func (l *Language) FormatTimeAbs(t time.Time) string {
 	}
 }
 
+func (l *Language) FormatDate(t time.Time) string {
+	if t.IsZero() {
+		return l.GenericNever
+	}
+
+	switch l.ID {
+	case LanguageIDNO:
+		return fmt.Sprintf("%d. %s %d", t.Day(), l.Months[t.Month()], t.Year())
+	case LanguageIDEN:
+		return t.Format("January 2, 2006")
+	default:
+		return t.Format(time.DateOnly)
+	}
+}
+
 func (l *Language) FormatTimeRel(t time.Time) string {
 	if t.IsZero() {
 		return l.GenericNever
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
 
 	"github.com/joho/godotenv"
 )
@@ -17,7 +18,7 @@ func main() {
 
 	godotenv.Load(".env")
 
-	config, err := loadConfig("config.json")
+	baseConfig, err := loadConfig("config.json")
 	if err != nil {
 		panic(err)
 	}
@@ -30,15 +31,35 @@ func main() {
 
 	queries := New(conn)
 
-	gdriveSA, err := NewGDriveWithServiceAccount(ctx, config.GoogleDrive, queries)
+	// Settings changed from the admin pages override config.json
+	config, err := applyRuntimeSettings(ctx, queries, baseConfig)
 	if err != nil {
-		panic(err)
+		log.Printf("ERROR: using config.json without the settings changed from the admin pages: %v", err)
+	}
+
+	files := NewLocalFileStorage(ctx, "file", "tmp")
+
+	// Google Drive is only used for journals, so it's not set up without them
+	var worker *GDriveWorker
+	var journals JournalBackend
+	switch config.Journal.Backend {
+	case JournalBackendIDMarkdown:
+		journals, err = NewMarkdownJournalBackend(queries, config.Journal)
+		if err != nil {
+			panic(err)
+		}
+	default:
+		gdriveSA, err := NewGDriveWithServiceAccount(ctx, config.GoogleDrive, queries)
+		if err != nil {
+			panic(err)
+		}
+		worker = NewGDriveWorker(ctx, config.GoogleDrive, config.Journal, gdriveSA, files)
+		journals = NewGDriveJournalBackend(worker)
 	}
-	worker := NewGDriveWorker(ctx, config.GoogleDrive, gdriveSA)
 
 	go backgroundDeleteExpiredItems(ctx, queries)
 
-	err = startServer(ctx, conn, queries, worker, config, BuildKey)
+	err = startServer(ctx, conn, queries, worker, journals, files, baseConfig, config, BuildKey)
 	if err != nil {
 		panic(err)
 	}
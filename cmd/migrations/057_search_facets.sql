@@ -0,0 +1,51 @@
+-- +migrate Up
+ALTER TABLE search ADD COLUMN patient_id INT GENERATED ALWAYS AS (
+    CASE WHEN ns = 'patient'
+        THEN substring(associated_url FROM '^/patient/([0-9]+)$')::int
+    END
+) STORED;
+
+CREATE INDEX search_patient_id ON search (patient_id);
+
+-- Facet data for each search entry. Home is the current home, or the home of
+-- the latest event for patients that have been checked out.
+CREATE VIEW search_facet AS
+SELECT
+    s.ns,
+    s.associated_url,
+    p.species_id,
+    COALESCE(p.curr_home_id, lh.home_id) AS home_id,
+    p.status,
+    (p.curr_home_id IS NOT NULL)         AS active
+FROM search s
+LEFT JOIN patient p
+  ON p.id = s.patient_id
+LEFT JOIN LATERAL (
+    SELECT pe.home_id
+    FROM patient_event pe
+    WHERE pe.patient_id = p.id
+    ORDER BY pe.time DESC
+    LIMIT 1
+) lh ON TRUE
+;
+
+CREATE OR REPLACE FUNCTION search_match_filters(
+    f            search_facet,
+    namespaces   text[],
+    species_ids  int[],
+    home_ids     int[],
+    statuses     int[],
+    is_active    boolean
+)
+RETURNS boolean
+LANGUAGE sql
+STABLE
+AS $smf$
+    SELECT COALESCE(
+            (namespaces  IS NULL OR f.ns         = ANY(namespaces))
+        AND (species_ids IS NULL OR f.species_id = ANY(species_ids))
+        AND (home_ids    IS NULL OR f.home_id    = ANY(home_ids))
+        AND (statuses    IS NULL OR f.status     = ANY(statuses))
+        AND (is_active   IS NULL OR f.active     = is_active)
+    , FALSE)
+$smf$;
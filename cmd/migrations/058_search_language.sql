@@ -0,0 +1,18 @@
+-- +migrate Up
+-- Query matched against both the searching user's language and the language
+-- the document was indexed with, so that stemming works for either.
+CREATE OR REPLACE FUNCTION search_tsquery(
+    user_lang regconfig,
+    doc_lang  regconfig,
+    query     text
+)
+RETURNS tsquery
+LANGUAGE sql
+STABLE
+AS $stq$
+    SELECT CASE
+        WHEN doc_lang IS NULL OR doc_lang = user_lang
+            THEN websearch_to_tsquery(user_lang, query)
+        ELSE websearch_to_tsquery(user_lang, query) || websearch_to_tsquery(doc_lang, query)
+    END
+$stq$;
@@ -0,0 +1,12 @@
+-- +migrate Up
+-- Position in the Drive changes feed for incremental search indexing
+CREATE TABLE search_sync_state(
+    -- Shared drive ID, empty for My Drive
+    drive_id TEXT PRIMARY KEY,
+    -- Page token for the next call to changes.list, empty to force a full sync
+    page_token TEXT NOT NULL,
+    -- When the changes feed was last processed
+    last_incremental_sync TIMESTAMPTZ,
+    -- When all folders were last listed and reconciled
+    last_full_sync TIMESTAMPTZ
+);
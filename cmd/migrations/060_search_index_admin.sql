@@ -0,0 +1,15 @@
+-- +migrate Up
+-- Why reading the document failed for skipped entries
+ALTER TABLE search ADD COLUMN skip_reason TEXT;
+
+-- Result of the last full listing of each journal folder
+CREATE TABLE search_folder_sync(
+    folder_id TEXT PRIMARY KEY,
+    folder_name TEXT NOT NULL DEFAULT '',
+    -- When the folder was last listed successfully
+    last_sync TIMESTAMPTZ,
+    -- Number of documents found in the last successful listing
+    n_files INT NOT NULL DEFAULT 0,
+    -- Error from the last attempt, if it failed
+    last_error TEXT
+);
@@ -0,0 +1,14 @@
+-- +migrate Up
+-- Only the owner can find the entry, e.g. for personal files. NULL is visible to everyone.
+ALTER TABLE search ADD COLUMN owner_id INT REFERENCES appuser(id) ON DELETE CASCADE;
+
+CREATE OR REPLACE FUNCTION search_visible(
+    s       search,
+    user_id int
+)
+RETURNS boolean
+LANGUAGE sql
+STABLE
+AS $sv$
+    SELECT s.owner_id IS NULL OR s.owner_id = user_id
+$sv$;
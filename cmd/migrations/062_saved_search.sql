@@ -0,0 +1,34 @@
+-- +migrate Up
+-- When the header or body of the entry last changed, used for search alerts
+ALTER TABLE search ADD COLUMN indexed TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- Search queries saved by users, optionally with alerts for new matches
+CREATE TABLE saved_search(
+    id SERIAL PRIMARY KEY,
+    appuser_id INT NOT NULL REFERENCES appuser(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    -- URL-encoded search parameters, as used on the search page
+    params TEXT NOT NULL,
+    -- SearchAlert: 0 = none, 1 = in-app, 2 = in-app and email digest
+    alert INT NOT NULL DEFAULT 0,
+    created TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- Entries indexed after this are new matches
+    last_checked TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX saved_search_appuser_id ON saved_search (appuser_id);
+
+-- In-app notifications about new matches for saved searches
+CREATE TABLE notification(
+    id SERIAL PRIMARY KEY,
+    appuser_id INT NOT NULL REFERENCES appuser(id) ON DELETE CASCADE,
+    saved_search_id INT NOT NULL REFERENCES saved_search(id) ON DELETE CASCADE,
+    header TEXT NOT NULL,
+    url TEXT NOT NULL,
+    created TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    seen BOOLEAN NOT NULL DEFAULT FALSE,
+    emailed BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (saved_search_id, url)
+);
+
+CREATE INDEX notification_appuser_id ON notification (appuser_id, seen);
@@ -0,0 +1,71 @@
+-- +migrate Up
+-- Whether the user may see the body of a search entry. Journals (including
+-- patients) are restricted to homes, other entries such as files are not.
+CREATE OR REPLACE FUNCTION search_body_visible(
+    s            search,
+    f            search_facet,
+    all_bodies   boolean,
+    other_bodies boolean,
+    home_ids     int[]
+)
+RETURNS boolean
+LANGUAGE sql
+STABLE
+AS $sbv$
+    SELECT COALESCE(
+           all_bodies
+        OR (s.ns IN ('journal', 'patient') AND f.home_id = ANY(home_ids))
+        OR (s.ns NOT IN ('journal', 'patient') AND other_bodies)
+    , FALSE)
+$sbv$;
+
+-- Matching takes body visibility into account, so that hidden bodies can't be
+-- probed by searching for words in them.
+DROP FUNCTION search_match_advanced(search, tsquery, text, real, timestamptz, timestamptz, timestamptz, timestamptz);
+
+CREATE OR REPLACE FUNCTION search_match_advanced(
+    s            search,
+    tsq          tsquery,
+    query        text,
+    simthreshold real,
+    min_created  timestamptz,
+    max_created  timestamptz,
+    min_updated  timestamptz,
+    max_updated  timestamptz,
+    body_visible boolean
+)
+RETURNS boolean
+LANGUAGE sql
+STABLE
+AS $sma$
+    SELECT (
+           (tsq @@ s.fts_header)
+        OR (body_visible AND (tsq @@ s.fts_body))
+        OR (s.header ILIKE ('%' || query || '%'))
+        OR (body_visible AND (s.body ILIKE ('%' || query || '%')))
+        OR (similarity(lower(s.header), lower(query)) > simthreshold)
+        OR (body_visible AND (similarity(lower(s.body), lower(query)) > simthreshold))
+    )
+    AND (NOT s.skipped)
+    AND (min_created IS NULL OR s.created >= min_created)
+    AND (max_created IS NULL OR s.created <= max_created)
+    AND (min_updated IS NULL OR s.updated >= min_updated)
+    AND (max_updated IS NULL OR s.updated <= max_updated)
+$sma$;
+
+DROP FUNCTION search_match_basic(search, tsquery);
+
+CREATE OR REPLACE FUNCTION search_match_basic(
+    s            search,
+    tsq          tsquery,
+    body_visible boolean
+)
+RETURNS boolean
+LANGUAGE sql
+STABLE
+AS $smb$
+    SELECT (
+           (tsq @@ s.fts_header)
+        OR (body_visible AND (tsq @@ s.fts_body))
+    ) AND NOT s.skipped
+$smb$;
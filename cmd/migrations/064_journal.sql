@@ -0,0 +1,14 @@
+-- +migrate Up
+-- Journals stored in bino itself as Markdown, used by the Markdown journal backend
+CREATE TABLE journal(
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by INT REFERENCES appuser(id) ON DELETE SET NULL,
+    -- Incremented on every save, to detect concurrent edits
+    version INT NOT NULL DEFAULT 1
+);
+
+CREATE INDEX journal_updated ON journal (updated);
@@ -0,0 +1,25 @@
+-- +migrate Up
+-- Google Drive tasks that change something, run in the background and retried on failure
+CREATE TABLE gdrive_task(
+    id SERIAL PRIMARY KEY,
+    -- GDriveTaskRequestID
+    type INT NOT NULL,
+    -- JSON-encoded arguments for the task
+    payload TEXT NOT NULL,
+    -- Enqueueing a task with the key of an unfinished task does nothing
+    idempotency_key TEXT NOT NULL UNIQUE,
+    -- GDriveTaskStatus: 0 = pending, 1 = running, 2 = done, 3 = failed
+    status INT NOT NULL DEFAULT 0,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    -- JSON-encoded result of the task, when done
+    result TEXT,
+    patient_id INT REFERENCES patient(id) ON DELETE CASCADE,
+    appuser_id INT REFERENCES appuser(id) ON DELETE SET NULL,
+    created TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX gdrive_task_next_attempt ON gdrive_task (next_attempt) WHERE status = 0;
+CREATE INDEX gdrive_task_patient_id ON gdrive_task (patient_id);
@@ -0,0 +1,20 @@
+-- +migrate Up
+-- Google Docs that journals can be created from, in addition to GoogleDrive.TemplateFile
+CREATE TABLE journal_template(
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    gdrive_file TEXT NOT NULL UNIQUE,
+    -- Template variables found in the document at the last validation
+    variables TEXT[] NOT NULL DEFAULT '{}',
+    -- NULL if the template has never been validated
+    validated TIMESTAMPTZ,
+    -- NULL if the last validation succeeded
+    validation_error TEXT,
+    created TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Template used for new journals for a species. Species not listed here use GoogleDrive.TemplateFile.
+CREATE TABLE species_journal_template(
+    species_id INT PRIMARY KEY REFERENCES species(id) ON DELETE CASCADE,
+    template_id INT NOT NULL REFERENCES journal_template(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+-- +migrate Up
+-- Who found the patient and why it was brought in, as registered at check-in
+ALTER TABLE patient ADD COLUMN finder TEXT NOT NULL DEFAULT '';
+ALTER TABLE patient ADD COLUMN intake_cause TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,38 @@
+-- +migrate Up
+-- Structured data read from tables in journals
+CREATE TABLE patient_measurement(
+    id SERIAL PRIMARY KEY,
+    patient_id INT NOT NULL REFERENCES patient(id) ON DELETE CASCADE,
+    -- MeasurementKind: 0 = weight, 1 = medication
+    kind INT NOT NULL,
+    measured DATE NOT NULL,
+    -- Weight in grams, 0 for other kinds
+    value DOUBLE PRECISION NOT NULL DEFAULT 0,
+    -- Medication and dose, empty for other kinds
+    note TEXT NOT NULL DEFAULT '',
+    -- The journal the row was read from
+    source_url TEXT NOT NULL,
+    created TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- Reading the same journal again doesn't import the rows twice
+    UNIQUE (patient_id, kind, measured, value, note)
+);
+
+-- Rows from journal tables that could not be imported without a human looking at them
+CREATE TABLE journal_import_review(
+    id SERIAL PRIMARY KEY,
+    patient_id INT NOT NULL REFERENCES patient(id) ON DELETE CASCADE,
+    -- MeasurementKind
+    kind INT NOT NULL,
+    -- JournalImportProblem
+    problem INT NOT NULL,
+    -- The row as it was written in the journal
+    raw_row TEXT NOT NULL,
+    source_url TEXT NOT NULL,
+    -- JournalImportReviewStatus: 0 = pending, 1 = accepted, 2 = rejected
+    status INT NOT NULL DEFAULT 0,
+    created TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    -- Rows that have been reviewed are not queued again
+    UNIQUE (patient_id, kind, raw_row)
+);
+
+CREATE INDEX journal_import_review_status ON journal_import_review (status);
@@ -0,0 +1,12 @@
+-- +migrate Up
+-- Whether the journal of a patient could be opened when it was last checked
+CREATE TABLE patient_journal_check(
+    patient_id INT PRIMARY KEY REFERENCES patient(id) ON DELETE CASCADE,
+    -- The journal that was checked
+    journal_url TEXT NOT NULL,
+    -- JournalProblem: 0 = none, 1 = unreadable, 2 = trashed, 3 = outside the journal folders
+    problem INT NOT NULL,
+    -- Error from the journal backend, if any
+    detail TEXT NOT NULL DEFAULT '',
+    checked TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,15 @@
+-- +migrate Up
+-- PDF copies of journals, made when the patient is checked out. The files
+-- can't be deleted while they are listed here.
+CREATE TABLE patient_journal_archive(
+    id SERIAL PRIMARY KEY,
+    patient_id INT NOT NULL REFERENCES patient(id),
+    file_id INT NOT NULL UNIQUE REFERENCES file(id),
+    -- The journal that was exported
+    journal_url TEXT NOT NULL,
+    -- Home of the patient before checkout, which decides who can read the copy
+    home_id INT REFERENCES home(id) ON DELETE SET NULL,
+    created TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX patient_journal_archive_patient_idx ON patient_journal_archive(patient_id);
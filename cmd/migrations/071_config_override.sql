@@ -0,0 +1,9 @@
+-- +migrate Up
+-- Settings changed from the admin pages. They override the same settings in
+-- config.json, and are keyed by RuntimeSetting.
+CREATE TABLE config_override(
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by INT REFERENCES appuser(id) ON DELETE SET NULL
+);
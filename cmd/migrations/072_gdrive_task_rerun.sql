@@ -0,0 +1,4 @@
+-- +migrate Up
+-- Set when a task is queued again while it's running, so that it runs once
+-- more with the new payload when the current run is finished
+ALTER TABLE gdrive_task ADD COLUMN rerun BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,21 @@
+-- +migrate Up
+-- Basic matching against the user's language, and also the other language for
+-- documents indexed in it. Both queries are built once per search rather than
+-- per row, and each document is matched against one of them, so that the full
+-- text indexes can be used.
+CREATE OR REPLACE FUNCTION search_match_basic_lang(
+    s            search,
+    qry_user     tsquery,
+    qry_both     tsquery,
+    other_lang   regconfig,
+    body_visible boolean
+)
+RETURNS boolean
+LANGUAGE sql
+STABLE
+AS $smbl$
+    SELECT (s.lang = other_lang AND search_match_basic(s, qry_both, body_visible))
+        OR (s.lang IS DISTINCT FROM other_lang AND search_match_basic(s, qry_user, body_visible))
+$smbl$;
+
+DROP FUNCTION search_tsquery(regconfig, regconfig, text);
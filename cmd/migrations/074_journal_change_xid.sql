@@ -0,0 +1,10 @@
+-- +migrate Up
+-- The transaction that last changed the journal, used as the cursor when
+-- indexing journals. Unlike the update time, it can't be passed by a change
+-- that commits after a later one has been read.
+ALTER TABLE journal ADD COLUMN change_xid BIGINT NOT NULL DEFAULT pg_current_xact_id()::TEXT::BIGINT;
+
+CREATE INDEX journal_change_xid ON journal (change_xid);
+
+-- The cursor used to be an update time, so index everything again
+UPDATE search_sync_state SET page_token = '' WHERE drive_id = 'markdown';
@@ -0,0 +1,14 @@
+-- +migrate Up
+-- Measurements are identified by the table row they were read from, so that a
+-- row that is corrected in the journal replaces the measurement instead of
+-- adding another one. A row can hold both a weight and a medication, so the
+-- kind is part of the key. Rows imported before this have no position, and
+-- are taken over by the row they match when the journal is read again.
+ALTER TABLE patient_measurement ADD COLUMN source_row INT;
+
+ALTER TABLE patient_measurement DROP CONSTRAINT patient_measurement_patient_id_kind_measured_value_note_key;
+
+ALTER TABLE patient_measurement ADD CONSTRAINT patient_measurement_source_row UNIQUE (patient_id, source_url, kind, source_row);
+
+-- Accepted rows are imported with the position of the row that was reviewed
+ALTER TABLE journal_import_review ADD COLUMN source_row INT;
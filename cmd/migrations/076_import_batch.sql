@@ -0,0 +1,14 @@
+-- +migrate Up
+-- Patients added by one run of the import tool, so that the progress of
+-- creating their journals can be shown without keeping the IDs in a cookie
+CREATE TABLE import_batch(
+    id SERIAL PRIMARY KEY,
+    appuser_id INT NOT NULL REFERENCES appuser(id) ON DELETE CASCADE,
+    created TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE import_batch_patient(
+    batch_id INT NOT NULL REFERENCES import_batch(id) ON DELETE CASCADE,
+    patient_id INT NOT NULL REFERENCES patient(id) ON DELETE CASCADE,
+    PRIMARY KEY (batch_id, patient_id)
+);
@@ -0,0 +1,11 @@
+-- +migrate Up
+-- The start of each patient journal, lowercased, for finding similar
+-- patients. Comparing bounded excerpts through a trigram index avoids
+-- computing the similarity of every journal on each page load.
+ALTER TABLE search
+ADD COLUMN similarity_excerpt TEXT
+GENERATED ALWAYS AS (lower(left(body, 2000))) STORED;
+
+CREATE INDEX search_similarity_excerpt_trgm ON search
+USING gin (similarity_excerpt gin_trgm_ops)
+WHERE ns = 'patient' AND NOT skipped;
@@ -0,0 +1,27 @@
+-- +migrate Up
+-- Measurements are identified by the text of the table row they were read
+-- from instead of its position, so that adding or removing rows above it
+-- doesn't move the measurement to another row. Each import removes the
+-- measurements whose row is no longer in the journal, except those that were
+-- accepted in a review, which are never replaced by an import.
+ALTER TABLE patient_measurement ADD COLUMN source_raw TEXT;
+ALTER TABLE patient_measurement ADD COLUMN reviewed BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE patient_measurement AS pm
+SET source_raw = jir.raw_row,
+    reviewed   = TRUE
+FROM journal_import_review AS jir
+WHERE jir.status = 1
+  AND jir.patient_id = pm.patient_id
+  AND jir.source_url = pm.source_url
+  AND jir.kind = pm.kind
+  AND jir.source_row = pm.source_row
+;
+
+-- Other rows imported before this have no text, and are replaced by the next
+-- import of their journal
+ALTER TABLE patient_measurement DROP CONSTRAINT patient_measurement_source_row;
+ALTER TABLE patient_measurement DROP COLUMN source_row;
+ALTER TABLE patient_measurement ADD CONSTRAINT patient_measurement_source_raw UNIQUE (patient_id, source_url, kind, source_raw);
+
+ALTER TABLE journal_import_review DROP COLUMN source_row;
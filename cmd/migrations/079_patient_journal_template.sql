@@ -0,0 +1,5 @@
+-- +migrate Up
+-- The Google Docs template the journal of the patient was created from, so
+-- that it's renamed after the same template at checkout. Not known for
+-- journals attached by hand, or created before this.
+ALTER TABLE patient ADD COLUMN journal_template TEXT;
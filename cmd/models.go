@@ -27,6 +27,13 @@ type AppuserLanguage struct {
 	LanguageID int32
 }
 
+type ConfigOverride struct {
+	Key       string
+	Value     string
+	Updated   pgtype.Timestamptz
+	UpdatedBy pgtype.Int4
+}
+
 type File struct {
 	ID            int32
 	Uuid          string
@@ -38,6 +45,23 @@ type File struct {
 	Size          int64
 }
 
+type GdriveTask struct {
+	ID             int32
+	Type           int32
+	Payload        string
+	IdempotencyKey string
+	Status         int32
+	Attempts       int32
+	NextAttempt    pgtype.Timestamptz
+	LastError      pgtype.Text
+	Result         pgtype.Text
+	PatientID      pgtype.Int4
+	AppuserID      pgtype.Int4
+	Created        pgtype.Timestamptz
+	Updated        pgtype.Timestamptz
+	Rerun          bool
+}
+
 // Each row is a rehab home
 type Home struct {
 	ID       int32
@@ -66,6 +90,17 @@ type HomeUnavailable struct {
 	Note     pgtype.Text
 }
 
+type ImportBatch struct {
+	ID        int32
+	AppuserID int32
+	Created   pgtype.Timestamptz
+}
+
+type ImportBatchPatient struct {
+	BatchID   int32
+	PatientID int32
+}
+
 type Invitation struct {
 	ID          string
 	Email       pgtype.Text
@@ -75,17 +110,63 @@ type Invitation struct {
 	Home        pgtype.Int4
 }
 
+type Journal struct {
+	ID        int32
+	Title     string
+	Content   string
+	Created   pgtype.Timestamptz
+	Updated   pgtype.Timestamptz
+	UpdatedBy pgtype.Int4
+	Version   int32
+	ChangeXid int64
+}
+
+type JournalImportReview struct {
+	ID        int32
+	PatientID int32
+	Kind      int32
+	Problem   int32
+	RawRow    string
+	SourceUrl string
+	Status    int32
+	Created   pgtype.Timestamptz
+}
+
+type JournalTemplate struct {
+	ID              int32
+	Name            string
+	GdriveFile      string
+	Variables       []string
+	Validated       pgtype.Timestamptz
+	ValidationError pgtype.Text
+	Created         pgtype.Timestamptz
+}
+
+type Notification struct {
+	ID            int32
+	AppuserID     int32
+	SavedSearchID int32
+	Header        string
+	Url           string
+	Created       pgtype.Timestamptz
+	Seen          bool
+	Emailed       bool
+}
+
 // Each row represents a patient
 type Patient struct {
-	ID           int32
-	SpeciesID    int32
-	CurrHomeID   pgtype.Int4
-	Name         string
-	Status       int32
-	JournalUrl   pgtype.Text
-	SortOrder    int32
-	TimeCheckin  pgtype.Timestamptz
-	TimeCheckout pgtype.Timestamptz
+	ID              int32
+	SpeciesID       int32
+	CurrHomeID      pgtype.Int4
+	Name            string
+	Status          int32
+	JournalUrl      pgtype.Text
+	SortOrder       int32
+	TimeCheckin     pgtype.Timestamptz
+	TimeCheckout    pgtype.Timestamptz
+	Finder          string
+	IntakeCause     string
+	JournalTemplate pgtype.Text
 }
 
 // Each row represents an event that has occurred to a specific patient
@@ -100,18 +181,87 @@ type PatientEvent struct {
 	AppuserID    int32
 }
 
+type PatientJournalArchive struct {
+	ID         int32
+	PatientID  int32
+	FileID     int32
+	JournalUrl string
+	HomeID     pgtype.Int4
+	Created    pgtype.Timestamptz
+}
+
+type PatientJournalCheck struct {
+	PatientID  int32
+	JournalUrl string
+	Problem    int32
+	Detail     string
+	Checked    pgtype.Timestamptz
+}
+
+type PatientMeasurement struct {
+	ID        int32
+	PatientID int32
+	Kind      int32
+	Measured  pgtype.Date
+	Value     float64
+	Note      string
+	SourceUrl string
+	Created   pgtype.Timestamptz
+	SourceRaw pgtype.Text
+	Reviewed  bool
+}
+
+type SavedSearch struct {
+	ID          int32
+	AppuserID   int32
+	Name        string
+	Params      string
+	Alert       int32
+	Created     pgtype.Timestamptz
+	LastChecked pgtype.Timestamptz
+}
+
 type Search struct {
+	Ns                string
+	Updated           pgtype.Timestamptz
+	Header            pgtype.Text
+	Body              pgtype.Text
+	Lang              interface{}
+	FtsHeader         interface{}
+	FtsBody           interface{}
+	AssociatedUrl     pgtype.Text
+	ExtraData         pgtype.Text
+	Skipped           bool
+	Created           pgtype.Timestamptz
+	PatientID         pgtype.Int4
+	SkipReason        pgtype.Text
+	OwnerID           pgtype.Int4
+	Indexed           pgtype.Timestamptz
+	SimilarityExcerpt pgtype.Text
+}
+
+type SearchFacet struct {
 	Ns            string
-	Updated       pgtype.Timestamptz
-	Header        pgtype.Text
-	Body          pgtype.Text
-	Lang          interface{}
-	FtsHeader     interface{}
-	FtsBody       interface{}
 	AssociatedUrl pgtype.Text
-	ExtraData     pgtype.Text
-	Skipped       bool
-	Created       pgtype.Timestamptz
+	SpeciesID     pgtype.Int4
+	HomeID        int32
+	Status        pgtype.Int4
+	Active        interface{}
+}
+
+type SearchFolderSync struct {
+	FolderID   string
+	FolderName string
+	LastSync   pgtype.Timestamptz
+	NFiles     int32
+	LastError  pgtype.Text
+}
+
+type SearchSyncState struct {
+	DriveID             string
+	PageToken           string
+	LastIncrementalSync pgtype.Timestamptz
+	LastFullSync        pgtype.Timestamptz
 }
 
 type Session struct {
@@ -128,6 +278,11 @@ type Species struct {
 	ScientificName string
 }
 
+type SpeciesJournalTemplate struct {
+	SpeciesID  int32
+	TemplateID int32
+}
+
 // Internationalization for species
 type SpeciesLanguage struct {
 	SpeciesID  int32
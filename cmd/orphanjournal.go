@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// What the title of a journal says about the patient, following the naming of
+// the journal templates, e.g. "2025-03-14 Name (Species)".
+type JournalTitleHints struct {
+	Date    time.Time
+	Name    string
+	Species string
+	// Zero if the species is unknown
+	SpeciesID int32
+}
+
+func parseJournalTitle(title string, species []SpeciesView) JournalTitleHints {
+	var hints JournalTitleHints
+	title = strings.TrimSpace(title)
+
+	if fields := strings.Fields(title); len(fields) > 0 {
+		if date, problem := parseJournalDate(fields[0]); problem == JournalImportProblemNone {
+			hints.Date = date
+			title = strings.TrimSpace(strings.TrimPrefix(title, fields[0]))
+		}
+	}
+
+	// Archived journals have the outcome and checkout date after a dash
+	if before, _, ok := strings.Cut(title, " - "); ok {
+		title = before
+	}
+
+	if open := strings.LastIndex(title, "("); open >= 0 && strings.HasSuffix(title, ")") {
+		hints.Species = strings.TrimSpace(title[open+1 : len(title)-1])
+		title = title[:open]
+		for _, sp := range species {
+			if strings.EqualFold(sp.Name, hints.Species) {
+				hints.SpeciesID = sp.ID
+				break
+			}
+		}
+	}
+
+	hints.Name = strings.TrimSpace(title)
+	return hints
+}
+
+func (server *Server) getOrphanJournalsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	orphans, err := server.Queries.GetOrphanJournals(ctx)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	speciesData, err := server.Queries.GetSpeciesWithLanguage(ctx, commonData.Lang32())
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+	species := SliceToSlice(speciesData, func(in GetSpeciesWithLanguageRow) SpeciesView {
+		return in.ToSpeciesView(false)
+	})
+
+	homes, err := server.Queries.GetHomes(ctx)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	patients, err := server.Queries.GetActivePatients(ctx, commonData.Lang32())
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+	patientsWithoutJournal := FilterSlice(patients, func(p GetActivePatientsRow) bool {
+		return p.JournalUrl.String == ""
+	})
+
+	problems, err := server.Queries.GetPatientsWithJournalProblems(ctx)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	_ = OrphanJournalsPage(
+		commonData,
+		SliceToSlice(problems, GetPatientsWithJournalProblemsRow.ToJournalProblemView),
+		SliceToSlice(orphans, func(in GetOrphanJournalsRow) OrphanJournalView {
+			return in.ToOrphanJournalView(species)
+		}),
+		species,
+		SliceToSlice(homes, func(home Home) HomeView {
+			return HomeView{Home: home}
+		}),
+		SliceToSlice(patientsWithoutJournal, func(in GetActivePatientsRow) PatientView {
+			return PatientView{
+				ID:      in.ID,
+				Name:    in.Name,
+				Species: in.Species,
+			}
+		}),
+	).Render(ctx, w)
+}
+
+func (server *Server) postOrphanJournalCreatePatientHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	fields, err := server.getFormValues(r, "url", "name")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	ids, err := server.getFormIDs(r, "home", "species")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	name := strings.TrimSpace(fields["name"])
+	if name == "" {
+		commonData.Error(commonData.User.Language.OrphanJournalNameRequired, nil)
+		server.redirectToReferer(w, r)
+		return
+	}
+
+	// Don't create a patient for a journal that can't be attached
+	check, err := server.checkAttachableJournal(ctx, 0, fields["url"])
+	if err != nil {
+		commonData.Error(commonData.User.Language.OrphanJournalAttachFailed, err)
+		server.redirectToReferer(w, r)
+		return
+	}
+
+	// The patient is only created if the journal can be attached to it
+	if err := server.Transaction(ctx, func(ctx context.Context, q *Queries) error {
+		patientID, err := q.AddPatient(ctx, AddPatientParams{
+			SpeciesID:  ids["species"],
+			CurrHomeID: pgtype.Int4{Int32: ids["home"], Valid: true},
+			Name:       name,
+			Status:     int32(StatusAdmitted),
+		})
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.AddPatientEvent(ctx, AddPatientEventParams{
+			PatientID: patientID,
+			AppuserID: commonData.User.AppuserID,
+			EventID:   int32(EventRegistered),
+			HomeID:    ids["home"],
+			Time:      pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		}); err != nil {
+			return fmt.Errorf("registering patient: %w", err)
+		}
+
+		return storePatientJournal(ctx, q, patientID, check)
+	}); err != nil {
+		commonData.Error(commonData.User.Language.OrphanJournalAttachFailed, err)
+		server.redirectToReferer(w, r)
+		return
+	}
+
+	server.indexAttachedJournal(ctx, check.URL)
+	commonData.Success(commonData.User.Language.OrphanJournalPatientCreated)
+	server.redirectToReferer(w, r)
+}
+
+func (server *Server) postOrphanJournalAttachHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	url, err := server.getFormValue(r, "url")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	patient, err := server.getFormID(r, "patient")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	if err := server.attachJournal(ctx, patient, url); err != nil {
+		commonData.Error(commonData.User.Language.OrphanJournalAttachFailed, err)
+	} else {
+		commonData.Success(commonData.User.Language.OrphanJournalAttached)
+	}
+
+	server.redirectToReferer(w, r)
+}
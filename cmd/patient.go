@@ -1,15 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"regexp"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 var journalRegex = regexp.MustCompile(`(https:\/\/docs\.google\.com\/document\/d\/[^\/?#\n]+)`)
 
+// Tuning for the similar patients panel
+const (
+	similarPatientsLimit         = 5
+	similarPatientsMinSimilarity = 0.1
+	similarPatientsSpeciesWeight = 0.2
+)
+
 func (server *Server) getPatientHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	commonData := MustLoadCommonData(ctx)
@@ -47,13 +58,118 @@ func (server *Server) getPatientHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	eventData, err := server.Queries.GetEventsForPatient(ctx, patientData.ID)
+	events, err := server.getPatientEvents(ctx, commonData, patientData.ID)
 	if err != nil {
 		server.renderError(w, r, commonData, err)
 		return
 	}
 
-	events := SliceToSlice(eventData, func(r GetEventsForPatientRow) EventView {
+	// Not essential for the page, so errors are only logged
+	var similar []GetSimilarPatientsRow
+	if err := server.Transaction(ctx, func(ctx context.Context, q *Queries) error {
+		if err := q.SetSimilarityThreshold(ctx, fmt.Sprint(similarPatientsMinSimilarity)); err != nil {
+			return err
+		}
+		var err error
+		similar, err = q.GetSimilarPatients(ctx, GetSimilarPatientsParams{
+			PatientID:        patientData.ID,
+			LanguageID:       commonData.Lang32(),
+			SpeciesWeight:    similarPatientsSpeciesWeight,
+			ExcludedStatuses: []int32{int32(StatusDeleted)},
+			ResultLimit:      similarPatientsLimit,
+		})
+		return err
+	}); err != nil {
+		LogR(r, "finding similar patients: %v", err)
+	}
+
+	patientView := []PatientView{{
+		ID:         patientData.ID,
+		Status:     patientData.Status,
+		Name:       patientData.Name,
+		Species:    patientData.SpeciesName,
+		JournalURL: patientData.JournalUrl.String,
+	}}
+	server.addJournalTaskStatus(ctx, patientView)
+
+	// Templates only apply to Google Drive journals
+	var journalTemplates []JournalTemplate
+	var journalTemplateID int32
+	if server.Config().Journal.Backend == JournalBackendIDGDrive && !patientData.JournalUrl.Valid {
+		if journalTemplates, err = server.Queries.GetJournalTemplates(ctx); err != nil {
+			LogR(r, "getting journal templates: %v", err)
+		}
+		if template, err := server.Queries.GetJournalTemplateForSpecies(ctx, patientData.SpeciesID); err == nil {
+			journalTemplateID = template.ID
+		}
+	}
+
+	measurements, err := server.Queries.GetPatientMeasurements(ctx, patientData.ID)
+	if err != nil {
+		LogR(r, "getting measurements: %v", err)
+	}
+	nPendingReview, err := server.Queries.CountPendingJournalImportReviewsForPatient(ctx, patientData.ID)
+	if err != nil {
+		LogR(r, "counting rows to review: %v", err)
+	}
+
+	var journalProblem JournalProblem
+	var journalProblemDetail string
+	if check, err := server.Queries.GetPatientJournalCheck(ctx, patientData.ID); err == nil {
+		journalProblem = JournalProblem(check.Problem)
+		journalProblemDetail = check.Detail
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		LogR(r, "getting journal check: %v", err)
+	}
+
+	var journalPreview *JournalPreviewView
+	if patientData.JournalUrl.Valid {
+		if preview, ok := server.patientJournalPreview(ctx, patientData.ID, patientData.CurrHomeID); ok {
+			journalPreview = &preview
+		}
+	}
+
+	archives, err := server.Queries.GetPatientJournalArchives(ctx, patientData.ID)
+	if err != nil {
+		LogR(r, "getting archived journals: %v", err)
+	}
+	access := server.searchBodyAccess(&commonData.User)
+	archives = FilterSlice(archives, func(in GetPatientJournalArchivesRow) bool {
+		return access.JournalVisible(in.HomeID)
+	})
+
+	PatientPage(ctx, commonData, PatientPageView{
+		Patient: patientView[0],
+		Home:    home,
+		Homes: SliceToSlice(homes, func(home Home) HomeView {
+			return HomeView{Home: home}
+		}),
+		History: PatientHistoryView{
+			Patient:   patientView[0],
+			Entries:   patientHistory(events, nil),
+			LoadEdits: server.canListJournalEdits(&commonData.User, patientData.JournalUrl.String, patientData.CurrHomeID),
+		},
+		Similar:              SliceToSlice(similar, GetSimilarPatientsRow.ToSimilarPatientView),
+		JournalTemplates:     SliceToSlice(journalTemplates, JournalTemplate.ToJournalTemplateView),
+		JournalTemplateID:    journalTemplateID,
+		Finder:               patientData.Finder,
+		IntakeCause:          patientData.IntakeCause,
+		Measurements:         SliceToSlice(measurements, PatientMeasurement.ToMeasurementView),
+		PendingReview:        nPendingReview > 0,
+		JournalProblem:       journalProblem,
+		JournalProblemDetail: journalProblemDetail,
+		JournalPreview:       journalPreview,
+		JournalArchives:      SliceToSlice(archives, GetPatientJournalArchivesRow.ToFileView),
+	}, server).Render(ctx, w)
+}
+
+func (server *Server) getPatientEvents(ctx context.Context, commonData *CommonData, patient int32) ([]EventView, error) {
+	eventData, err := server.Queries.GetEventsForPatient(ctx, patient)
+	if err != nil {
+		return nil, err
+	}
+
+	return SliceToSlice(eventData, func(r GetEventsForPatientRow) EventView {
 		return EventView{
 			Row:     r,
 			TimeRel: commonData.User.Language.FormatTimeRel(r.Time.Time),
@@ -73,22 +189,7 @@ func (server *Server) getPatientHandler(w http.ResponseWriter, r *http.Request)
 				},
 			},
 		}
-	})
-
-	PatientPage(ctx, commonData, PatientPageView{
-		Patient: PatientView{
-			ID:         patientData.ID,
-			Status:     patientData.Status,
-			Name:       patientData.Name,
-			Species:    patientData.SpeciesName,
-			JournalURL: patientData.JournalUrl.String,
-		},
-		Home: home,
-		Homes: SliceToSlice(homes, func(home Home) HomeView {
-			return HomeView{Home: home}
-		}),
-		Events: events,
-	}, server).Render(ctx, w)
+	}), nil
 }
 
 func (server *Server) createJournalHandler(w http.ResponseWriter, r *http.Request) {
@@ -103,7 +204,7 @@ func (server *Server) createJournalHandler(w http.ResponseWriter, r *http.Reques
 
 	patientData, err := server.Queries.GetPatientWithSpecies(ctx, GetPatientWithSpeciesParams{
 		ID:         patient,
-		LanguageID: int32(server.Config.SystemLanguage),
+		LanguageID: int32(server.Config().SystemLanguage),
 	})
 	if err != nil {
 		server.renderError(w, r, commonData, err)
@@ -116,50 +217,47 @@ func (server *Server) createJournalHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	created, err := server.Queries.GetFirstEventOfTypeForPatient(ctx, GetFirstEventOfTypeForPatientParams{
-		PatientID: patient,
-		EventID:   int32(EventRegistered),
-	})
-	if err != nil || !created.Valid {
-		LogR(r, "vad creation date, using current time. Err=%v", err)
-		created = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	// Chosen on the patient page. Without a choice, the template for the species is used.
+	var templateFile string
+	if templateID, err := server.getFormID(r, "template"); err == nil {
+		if templateID == 0 {
+			templateFile = server.Config().GoogleDrive.TemplateFile
+		} else if template, err := server.Queries.GetJournalTemplate(ctx, templateID); err == nil {
+			if template.ValidationError.Valid {
+				commonData.Error(commonData.User.Language.JournalTemplateInvalid, errors.New(template.ValidationError.String))
+				server.redirectToReferer(w, r)
+				return
+			}
+			templateFile = template.GdriveFile
+		} else {
+			server.renderError(w, r, commonData, err)
+			return
+		}
 	}
 
-	item, err := server.GDriveWorker.CreateJournal(GDriveTemplateVars{
-		Time:    created.Time,
-		Name:    patientData.Name,
-		Species: patientData.SpeciesName,
-		BinoURL: server.Config.BinoURLForPatient(patient),
+	queued, err := server.createPatientJournal(ctx, payloadCreatePatientJournal{
+		PatientID:    patient,
+		HomeID:       patientData.CurrHomeID.Int32,
+		AppuserID:    commonData.User.AppuserID,
+		TemplateFile: templateFile,
+		Vars:         server.journalVars(ctx, patientData),
 	})
-	if err != nil {
-		commonData.Error(commonData.User.Language.TODO("failed to create"), err)
+	if errors.Is(err, errPatientHasJournal) {
+		commonData.Warning(commonData.User.Language.TODO("journal URL already exists"), nil)
 		server.redirectToReferer(w, r)
 		return
 	}
-
-	if tag, err := server.Queries.SetPatientJournal(ctx, SetPatientJournalParams{
-		ID: patient,
-		JournalUrl: pgtype.Text{
-			String: item.DocumentURL(),
-			Valid:  true,
-		},
-	}); err != nil || tag.RowsAffected() == 0 {
-		commonData.Error(commonData.User.Language.TODO("failed to set in DB"), err)
+	if err != nil {
+		commonData.Error(commonData.User.Language.TODO("failed to create"), err)
 		server.redirectToReferer(w, r)
 		return
 	}
 
-	if _, err := server.Queries.AddPatientEvent(ctx, AddPatientEventParams{
-		PatientID: patient,
-		HomeID:    patientData.CurrHomeID.Int32,
-		EventID:   int32(EventJournalCreated),
-		AppuserID: commonData.User.AppuserID,
-		Time:      pgtype.Timestamptz{Time: time.Now(), Valid: true},
-	}); err != nil {
-		commonData.Warning(commonData.User.Language.TODO("failed to create event"), err)
+	if queued {
+		commonData.Info(commonData.User.Language.JournalCreationQueued)
+	} else {
+		commonData.Success(commonData.User.Language.TODO("document created"))
 	}
-
-	commonData.Success(commonData.User.Language.TODO("document created"))
 	server.redirectToReferer(w, r)
 }
 
@@ -179,41 +277,109 @@ func (server *Server) attachJournalHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	baseURL := journalRegex.FindString(url)
-	if baseURL == "" {
+	if err := server.attachJournal(ctx, patient, url); errors.Is(err, errBadJournalURL) {
 		commonData.Error(commonData.User.Language.TODO("bad URL"), err)
-		server.redirectToReferer(w, r)
-		return
+	} else if errors.Is(err, errJournalUnavailable) {
+		commonData.Error(commonData.User.Language.JournalUnavailable, err)
+	} else if err != nil {
+		commonData.Error(commonData.User.Language.TODO("failed to set in DB"), err)
+	} else {
+		commonData.Success(commonData.User.Language.TODO("journal attached"))
 	}
+	server.redirectToReferer(w, r)
+}
 
-	patientData, err := server.Queries.GetPatient(ctx, patient)
+var (
+	errBadJournalURL      = errors.New("not a journal URL")
+	errJournalUnavailable = errors.New("journal can't be opened")
+)
+
+// Attach the journal at the URL to the patient, and index it for search. The
+// journal must exist and be readable, and other problems are shown as warnings.
+func (server *Server) attachJournal(ctx context.Context, patient int32, url string) error {
+	check, err := server.checkAttachableJournal(ctx, patient, url)
 	if err != nil {
-		server.renderError(w, r, commonData, err)
-		return
+		return err
+	}
+
+	if err := server.Transaction(ctx, func(ctx context.Context, q *Queries) error {
+		return storePatientJournal(ctx, q, patient, check)
+	}); err != nil {
+		return err
+	}
+
+	server.indexAttachedJournal(ctx, check.URL)
+	return nil
+}
+
+// Check that the journal at the URL can be attached to the patient. Problems
+// that don't keep it from being attached are shown as warnings.
+func (server *Server) checkAttachableJournal(ctx context.Context, patient int32, url string) (JournalCheck, error) {
+	commonData := MustLoadCommonData(ctx)
+
+	check, err := server.checkJournal(ctx, url)
+	if err != nil {
+		return JournalCheck{}, err
+	}
+	if check.Blocking() {
+		return JournalCheck{}, fmt.Errorf("%w: %s %s", errJournalUnavailable, commonData.User.Language.JournalProblems[check.Problem], check.Detail)
+	}
+	if check.Problem != JournalProblemNone {
+		commonData.Warning(commonData.User.Language.JournalProblems[check.Problem], nil)
+	}
+
+	if duplicates, err := server.journalDuplicates(ctx, patient, check.URL); err != nil {
+		LogCtx(ctx, "looking for other patients with journal %s: %v", check.URL, err)
+	} else if len(duplicates) > 0 {
+		commonData.Warning(commonData.User.Language.JournalAttachDuplicate(SliceToSlice(duplicates, func(p PatientView) string {
+			return p.Name
+		})), nil)
+	}
+	return check, nil
+}
+
+// Store a journal checked by checkAttachableJournal as the patient's journal.
+func storePatientJournal(ctx context.Context, q *Queries, patient int32, check JournalCheck) error {
+	commonData := MustLoadCommonData(ctx)
+	baseURL := check.URL
+
+	patientData, err := q.GetPatient(ctx, patient)
+	if err != nil {
+		return err
 	}
 
-	if tag, err := server.Queries.SetPatientJournal(ctx, SetPatientJournalParams{
+	if tag, err := q.SetPatientJournal(ctx, SetPatientJournalParams{
 		ID: patient,
 		JournalUrl: pgtype.Text{
 			String: baseURL,
 			Valid:  true,
 		},
-	}); err != nil || tag.RowsAffected() == 0 {
-		commonData.Error(commonData.User.Language.TODO("failed to set in DB"), err)
-		server.redirectToReferer(w, r)
-		return
+	}); err != nil {
+		return err
+	} else if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
 	}
 
-	if _, err := server.Queries.AddPatientEvent(ctx, AddPatientEventParams{
+	if _, err := q.AddPatientEvent(ctx, AddPatientEventParams{
 		PatientID: patient,
 		HomeID:    patientData.CurrHomeID.Int32,
 		EventID:   int32(EventJournalAttached),
 		AppuserID: commonData.User.AppuserID,
 		Time:      pgtype.Timestamptz{Time: time.Now(), Valid: true},
 	}); err != nil {
-		commonData.Warning(commonData.User.Language.TODO("failed to create event"), err)
+		return fmt.Errorf("creating event: %w", err)
 	}
 
-	commonData.Success(commonData.User.Language.TODO("journal attached"))
-	server.redirectToReferer(w, r)
+	if check.Checked {
+		if err := q.SetPatientJournalCheck(ctx, SetPatientJournalCheckParams{
+			PatientID:  patient,
+			JournalUrl: baseURL,
+			Problem:    int32(check.Problem),
+			Detail:     check.Detail,
+		}); err != nil {
+			return fmt.Errorf("storing journal check: %w", err)
+		}
+	}
+
+	return nil
 }
@@ -1,3 +1,4 @@
+//go:generate go tool go-enum --no-iota --values
 package main
 
 import (
@@ -6,15 +7,20 @@ import (
 	"net/http"
 )
 
+// Which journals rehabbers can read in search results.
+// ENUM(OwnHomes = 0, AllHomes)
+type JournalVisibility int32
+
 type PrivacyConfig struct {
-	LogDeletionPolicy   int32
-	RevokeConsentPolicy int32
+	LogDeletionPolicy         int32
+	RevokeConsentPolicy       int32
+	RehabberJournalVisibility JournalVisibility
 }
 
 func (server *Server) privacyHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	commonData := MustLoadCommonData(ctx)
-	_ = Privacy(commonData, server.Config.Privacy).Render(ctx, w)
+	_ = Privacy(commonData, server.Config().Privacy).Render(ctx, w)
 }
 
 func (server *Server) postPrivacyHandler(w http.ResponseWriter, r *http.Request) {
@@ -27,7 +33,7 @@ func (server *Server) postPrivacyHandler(w http.ResponseWriter, r *http.Request)
 	if consent {
 		err = server.Queries.SetLoggingConsent(ctx, SetLoggingConsentParams{
 			ID:     commonData.User.AppuserID,
-			Period: server.Config.Privacy.RevokeConsentPolicy,
+			Period: server.Config().Privacy.RevokeConsentPolicy,
 		})
 	} else {
 		err = server.Queries.RevokeLoggingConsent(ctx, commonData.User.AppuserID)
@@ -0,0 +1,63 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version: v0.9.1
+
+// Built By: go install
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// JournalVisibilityOwnHomes is a JournalVisibility of type OwnHomes.
+	JournalVisibilityOwnHomes JournalVisibility = 0
+	// JournalVisibilityAllHomes is a JournalVisibility of type AllHomes.
+	JournalVisibilityAllHomes JournalVisibility = 1
+)
+
+var ErrInvalidJournalVisibility = errors.New("not a valid JournalVisibility")
+
+const _JournalVisibilityName = "OwnHomesAllHomes"
+
+// JournalVisibilityValues returns a list of the values for JournalVisibility
+func JournalVisibilityValues() []JournalVisibility {
+	return []JournalVisibility{
+		JournalVisibilityOwnHomes,
+		JournalVisibilityAllHomes,
+	}
+}
+
+var _JournalVisibilityMap = map[JournalVisibility]string{
+	JournalVisibilityOwnHomes: _JournalVisibilityName[0:8],
+	JournalVisibilityAllHomes: _JournalVisibilityName[8:16],
+}
+
+// String implements the Stringer interface.
+func (x JournalVisibility) String() string {
+	if str, ok := _JournalVisibilityMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("JournalVisibility(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x JournalVisibility) IsValid() bool {
+	_, ok := _JournalVisibilityMap[x]
+	return ok
+}
+
+var _JournalVisibilityValue = map[string]JournalVisibility{
+	_JournalVisibilityName[0:8]:  JournalVisibilityOwnHomes,
+	_JournalVisibilityName[8:16]: JournalVisibilityAllHomes,
+}
+
+// ParseJournalVisibility attempts to convert a string to a JournalVisibility.
+func ParseJournalVisibility(name string) (JournalVisibility, error) {
+	if x, ok := _JournalVisibilityValue[name]; ok {
+		return x, nil
+	}
+	return JournalVisibility(0), fmt.Errorf("%s is %w", name, ErrInvalidJournalVisibility)
+}
@@ -41,6 +41,8 @@ type CommonData struct {
 		canCreateJournal bool
 	}
 	Feedback Feedback
+	// Drive is in use but can't be reached, or is set up wrong
+	GDriveUnavailable bool
 }
 
 func (cd *CommonData) Error(msg string, err error) {
@@ -89,7 +91,7 @@ func (server *Server) getUserViews(ctx context.Context) map[string]UserView {
 				return user.Email, user.ToUserView()
 			})
 		} else {
-			LogCtx(ctx, "GetAppusers failed: %w", err)
+			LogCtx(ctx, "GetAppusers failed: %v", err)
 		}
 	}
 	return commonData.QueryCache.emailToUser
@@ -123,6 +125,8 @@ type UserData struct {
 	HasAvatarURL    bool
 	HasGDriveAccess bool
 	AccessLevel     AccessLevel
+	// Number of notifications the user hasn't looked at yet
+	UnseenNotifications int32
 }
 
 func (u *UserData) HasHomeOrAccess(homeID int32, al AccessLevel) bool {
@@ -13,7 +13,7 @@ func (server *Server) adminRootHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	commonData := MustLoadCommonData(ctx)
 
-	_ = AdminRootPage(commonData).Render(ctx, w)
+	_ = AdminRootPage(commonData, server.Config().Journal.Backend == JournalBackendIDMarkdown).Render(ctx, w)
 }
 
 func (server *Server) postLanguageHandler(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,354 @@
+//go:generate go tool go-enum --no-iota --values
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Settings from config.json that can be changed from the admin pages. The
+// names are the keys in the config_override table.
+//
+// ENUM(JournalFolder = 0, TemplateFile, ExtraJournalFolders, ArchiveFolder, LogDeletionPolicy, RevokeConsentPolicy, RehabberJournalVisibility, SystemBaseURL)
+type RuntimeSetting int32
+
+// The settings shown on /gdrive
+var gdriveRuntimeSettings = []RuntimeSetting{
+	RuntimeSettingJournalFolder,
+	RuntimeSettingTemplateFile,
+	RuntimeSettingExtraJournalFolders,
+	RuntimeSettingArchiveFolder,
+}
+
+var gdriveFolderRegex = regexp.MustCompile(`/folders/([A-Za-z0-9_-]+)`)
+
+// Whether the setting is part of the Drive config, and has to be checked
+// against Drive before it's applied.
+func (s RuntimeSetting) IsGDrive() bool {
+	return slices.Contains(gdriveRuntimeSettings, s)
+}
+
+// The value of the setting in config, as it's shown in the settings form.
+func (s RuntimeSetting) Get(config Config) string {
+	switch s {
+	case RuntimeSettingJournalFolder:
+		return config.GoogleDrive.JournalFolder
+	case RuntimeSettingTemplateFile:
+		return config.GoogleDrive.TemplateFile
+	case RuntimeSettingExtraJournalFolders:
+		return strings.Join(config.GoogleDrive.ExtraJournalFolders, "\n")
+	case RuntimeSettingArchiveFolder:
+		return config.GoogleDrive.ArchiveFolder
+	case RuntimeSettingLogDeletionPolicy:
+		return strconv.Itoa(int(config.Privacy.LogDeletionPolicy))
+	case RuntimeSettingRevokeConsentPolicy:
+		return strconv.Itoa(int(config.Privacy.RevokeConsentPolicy))
+	case RuntimeSettingRehabberJournalVisibility:
+		return strconv.Itoa(int(config.Privacy.RehabberJournalVisibility))
+	case RuntimeSettingSystemBaseURL:
+		return config.SystemBaseURL
+	}
+	return ""
+}
+
+// Validate a value from the settings form, and turn it into the form it's
+// stored in. Drive folders and documents can be given as links.
+func (s RuntimeSetting) Parse(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch s {
+	case RuntimeSettingJournalFolder:
+		return parseGDriveFolderID(value)
+	case RuntimeSettingArchiveFolder:
+		// Journals are left in place without an archive folder
+		if value == "" {
+			return "", nil
+		}
+		return parseGDriveFolderID(value)
+	case RuntimeSettingExtraJournalFolders:
+		var ids []string
+		for _, line := range strings.Fields(strings.ReplaceAll(value, ",", " ")) {
+			id, err := parseGDriveFolderID(line)
+			if err != nil {
+				return "", err
+			}
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+		return strings.Join(ids, "\n"), nil
+	case RuntimeSettingTemplateFile:
+		if id, ok := gdriveDocumentID(value); ok {
+			return id, nil
+		}
+		if !gdriveIDRegex.MatchString(value) {
+			return "", fmt.Errorf("'%s' is not a Google Docs document", value)
+		}
+		return value, nil
+	case RuntimeSettingLogDeletionPolicy, RuntimeSettingRevokeConsentPolicy:
+		days, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || days < 1 {
+			return "", fmt.Errorf("'%s' is not a positive number of days", value)
+		}
+		return strconv.Itoa(int(days)), nil
+	case RuntimeSettingRehabberJournalVisibility:
+		n, err := strconv.Atoi(value)
+		if err != nil || !JournalVisibility(n).IsValid() {
+			return "", fmt.Errorf("invalid journal visibility '%s'", value)
+		}
+		return value, nil
+	case RuntimeSettingSystemBaseURL:
+		u, err := url.Parse(value)
+		if err != nil {
+			return "", err
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("'%s' is not an http or https URL", value)
+		}
+		// Paths are appended to it
+		return strings.TrimRight(value, "/"), nil
+	}
+	return "", fmt.Errorf("unknown setting %d", s)
+}
+
+// Set the setting in config to a value returned by Parse.
+func (s RuntimeSetting) Apply(config *Config, value string) error {
+	switch s {
+	case RuntimeSettingJournalFolder:
+		config.GoogleDrive.JournalFolder = value
+	case RuntimeSettingTemplateFile:
+		config.GoogleDrive.TemplateFile = value
+	case RuntimeSettingExtraJournalFolders:
+		// Replaced rather than modified, since the old config shares the slice
+		config.GoogleDrive.ExtraJournalFolders = strings.Fields(value)
+	case RuntimeSettingArchiveFolder:
+		config.GoogleDrive.ArchiveFolder = value
+	case RuntimeSettingLogDeletionPolicy, RuntimeSettingRevokeConsentPolicy:
+		days, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return err
+		}
+		if s == RuntimeSettingLogDeletionPolicy {
+			config.Privacy.LogDeletionPolicy = int32(days)
+		} else {
+			config.Privacy.RevokeConsentPolicy = int32(days)
+		}
+	case RuntimeSettingRehabberJournalVisibility:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		config.Privacy.RehabberJournalVisibility = JournalVisibility(n)
+	case RuntimeSettingSystemBaseURL:
+		config.SystemBaseURL = value
+	default:
+		return fmt.Errorf("unknown setting %d", s)
+	}
+	return nil
+}
+
+func parseGDriveFolderID(value string) (string, error) {
+	if m := gdriveFolderRegex.FindStringSubmatch(value); m != nil {
+		return m[1], nil
+	}
+	if !gdriveIDRegex.MatchString(value) {
+		return "", fmt.Errorf("'%s' is not a Google Drive folder", value)
+	}
+	return value, nil
+}
+
+// Apply the settings stored in the database on top of config.json. Settings
+// that can't be applied are logged and skipped, so a bad row can't keep bino
+// from starting.
+func applyRuntimeSettings(ctx context.Context, queries *Queries, base Config) (Config, error) {
+	rows, err := queries.GetConfigOverrides(ctx)
+	if err != nil {
+		return base, fmt.Errorf("getting config overrides: %w", err)
+	}
+
+	config := base
+	for _, row := range rows {
+		setting, err := ParseRuntimeSetting(row.Key)
+		if err != nil {
+			log.Printf("ERROR: skipping unknown runtime setting '%s'", row.Key)
+			continue
+		}
+		if err := setting.Apply(&config, row.Value); err != nil {
+			log.Printf("ERROR: skipping runtime setting %s='%s': %v", row.Key, row.Value, err)
+		}
+	}
+	return config, nil
+}
+
+func (server *Server) setConfig(config Config) {
+	server.configMu.Lock()
+	defer server.configMu.Unlock()
+	server.config = config
+}
+
+func (server *Server) getSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	view, err := server.getRuntimeSettingsView(ctx)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	_ = SettingsPage(commonData, view).Render(ctx, w)
+}
+
+func (server *Server) postSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	server.saveRuntimeSettings(w, r, RuntimeSettingValues(), "/admin/settings")
+}
+
+func (server *Server) postGDriveSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	server.saveRuntimeSettings(w, r, gdriveRuntimeSettings, "/gdrive")
+}
+
+// The current value of every setting, and who last changed it.
+func (server *Server) getRuntimeSettingsView(ctx context.Context) (RuntimeSettingsView, error) {
+	rows, err := server.Queries.GetConfigOverrides(ctx)
+	if err != nil {
+		return RuntimeSettingsView{}, err
+	}
+	byKey := map[string]GetConfigOverridesRow{}
+	for _, row := range rows {
+		byKey[row.Key] = row
+	}
+
+	config := server.Config()
+	return RuntimeSettingsView{
+		Settings: SliceToSlice(RuntimeSettingValues(), func(s RuntimeSetting) RuntimeSettingView {
+			view := RuntimeSettingView{
+				Setting: s,
+				Value:   s.Get(config),
+				Default: s.Get(server.baseConfig),
+			}
+			if row, ok := byKey[s.String()]; ok {
+				view.Changed = row.Updated.Time
+				view.ChangedBy = row.UpdatedByName.String
+			}
+			return view
+		}),
+		GDrive: server.GDriveWorker != nil,
+	}, nil
+}
+
+// Save the settings in the form. Settings that aren't in the form are left
+// as they are, and settings set back to their config.json value are removed
+// from the database.
+func (server *Server) saveRuntimeSettings(w http.ResponseWriter, r *http.Request, allowed []RuntimeSetting, redirect string) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+	lang := commonData.User.Language
+
+	if err := r.ParseForm(); err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	server.settingsMu.Lock()
+	defer server.settingsMu.Unlock()
+
+	current := server.Config()
+	config := current
+	changed := map[RuntimeSetting]string{}
+	for _, s := range allowed {
+		if !r.PostForm.Has(s.String()) {
+			continue
+		}
+		// Unchanged values are left alone, so that a value from config.json
+		// that wouldn't pass validation doesn't keep the others from being saved
+		raw := strings.TrimSpace(strings.ReplaceAll(r.PostForm.Get(s.String()), "\r\n", "\n"))
+		if raw == s.Get(current) {
+			continue
+		}
+		value, err := s.Parse(raw)
+		if err != nil {
+			commonData.Error(lang.SettingInvalid(lang.RuntimeSettings[s]), err)
+			server.redirect(w, r, redirect)
+			return
+		}
+		if value == s.Get(current) {
+			continue
+		}
+		if err := s.Apply(&config, value); err != nil {
+			server.renderError(w, r, commonData, err)
+			return
+		}
+		changed[s] = value
+	}
+
+	if len(changed) == 0 {
+		commonData.Info(lang.SettingsUnchanged)
+		server.redirect(w, r, redirect)
+		return
+	}
+
+	gdriveChanged := false
+	for s := range changed {
+		gdriveChanged = gdriveChanged || s.IsGDrive()
+	}
+	if gdriveChanged && server.GDriveWorker != nil {
+		if err := server.GDriveWorker.ValidateConfig(config.GoogleDrive); err != nil {
+			commonData.Error(lang.SettingsGDriveInvalid, err)
+			server.redirect(w, r, redirect)
+			return
+		}
+	}
+
+	appuserID := pgtype.Int4{Int32: commonData.User.AppuserID, Valid: true}
+	if err := server.Transaction(ctx, func(ctx context.Context, q *Queries) error {
+		for s, value := range changed {
+			var err error
+			if value == s.Get(server.baseConfig) {
+				err = q.DeleteConfigOverride(ctx, s.String())
+			} else {
+				err = q.SetConfigOverride(ctx, SetConfigOverrideParams{
+					Key:       s.String(),
+					Value:     value,
+					UpdatedBy: appuserID,
+				})
+			}
+			if err != nil {
+				return fmt.Errorf("saving setting %s: %w", s, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	server.setConfig(config)
+	for s, value := range changed {
+		LogR(r, "changed setting %s from '%s' to '%s'", s, s.Get(current), value)
+	}
+
+	if gdriveChanged && server.GDriveWorker != nil {
+		// Replaces the cached config info, so the new folders and template are
+		// used from the next request
+		if err := server.GDriveWorker.SetConfig(ctx, config.GoogleDrive); err != nil {
+			commonData.Warning(lang.SettingsGDriveRefreshFailed, err)
+			server.redirect(w, r, redirect)
+			return
+		}
+	}
+
+	if _, ok := changed[RuntimeSettingTemplateFile]; ok {
+		commonData.Success(lang.GDriveTemplateUpdated)
+	} else {
+		commonData.Success(lang.SettingsSaved)
+	}
+	server.redirect(w, r, redirect)
+}
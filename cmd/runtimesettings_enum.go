@@ -0,0 +1,93 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version: v0.9.1
+
+// Built By: go install
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// RuntimeSettingJournalFolder is a RuntimeSetting of type JournalFolder.
+	RuntimeSettingJournalFolder RuntimeSetting = 0
+	// RuntimeSettingTemplateFile is a RuntimeSetting of type TemplateFile.
+	RuntimeSettingTemplateFile RuntimeSetting = 1
+	// RuntimeSettingExtraJournalFolders is a RuntimeSetting of type ExtraJournalFolders.
+	RuntimeSettingExtraJournalFolders RuntimeSetting = 2
+	// RuntimeSettingArchiveFolder is a RuntimeSetting of type ArchiveFolder.
+	RuntimeSettingArchiveFolder RuntimeSetting = 3
+	// RuntimeSettingLogDeletionPolicy is a RuntimeSetting of type LogDeletionPolicy.
+	RuntimeSettingLogDeletionPolicy RuntimeSetting = 4
+	// RuntimeSettingRevokeConsentPolicy is a RuntimeSetting of type RevokeConsentPolicy.
+	RuntimeSettingRevokeConsentPolicy RuntimeSetting = 5
+	// RuntimeSettingRehabberJournalVisibility is a RuntimeSetting of type RehabberJournalVisibility.
+	RuntimeSettingRehabberJournalVisibility RuntimeSetting = 6
+	// RuntimeSettingSystemBaseURL is a RuntimeSetting of type SystemBaseURL.
+	RuntimeSettingSystemBaseURL RuntimeSetting = 7
+)
+
+var ErrInvalidRuntimeSetting = errors.New("not a valid RuntimeSetting")
+
+const _RuntimeSettingName = "JournalFolderTemplateFileExtraJournalFoldersArchiveFolderLogDeletionPolicyRevokeConsentPolicyRehabberJournalVisibilitySystemBaseURL"
+
+// RuntimeSettingValues returns a list of the values for RuntimeSetting
+func RuntimeSettingValues() []RuntimeSetting {
+	return []RuntimeSetting{
+		RuntimeSettingJournalFolder,
+		RuntimeSettingTemplateFile,
+		RuntimeSettingExtraJournalFolders,
+		RuntimeSettingArchiveFolder,
+		RuntimeSettingLogDeletionPolicy,
+		RuntimeSettingRevokeConsentPolicy,
+		RuntimeSettingRehabberJournalVisibility,
+		RuntimeSettingSystemBaseURL,
+	}
+}
+
+var _RuntimeSettingMap = map[RuntimeSetting]string{
+	RuntimeSettingJournalFolder:             _RuntimeSettingName[0:13],
+	RuntimeSettingTemplateFile:              _RuntimeSettingName[13:25],
+	RuntimeSettingExtraJournalFolders:       _RuntimeSettingName[25:44],
+	RuntimeSettingArchiveFolder:             _RuntimeSettingName[44:57],
+	RuntimeSettingLogDeletionPolicy:         _RuntimeSettingName[57:74],
+	RuntimeSettingRevokeConsentPolicy:       _RuntimeSettingName[74:93],
+	RuntimeSettingRehabberJournalVisibility: _RuntimeSettingName[93:118],
+	RuntimeSettingSystemBaseURL:             _RuntimeSettingName[118:131],
+}
+
+// String implements the Stringer interface.
+func (x RuntimeSetting) String() string {
+	if str, ok := _RuntimeSettingMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("RuntimeSetting(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x RuntimeSetting) IsValid() bool {
+	_, ok := _RuntimeSettingMap[x]
+	return ok
+}
+
+var _RuntimeSettingValue = map[string]RuntimeSetting{
+	_RuntimeSettingName[0:13]:    RuntimeSettingJournalFolder,
+	_RuntimeSettingName[13:25]:   RuntimeSettingTemplateFile,
+	_RuntimeSettingName[25:44]:   RuntimeSettingExtraJournalFolders,
+	_RuntimeSettingName[44:57]:   RuntimeSettingArchiveFolder,
+	_RuntimeSettingName[57:74]:   RuntimeSettingLogDeletionPolicy,
+	_RuntimeSettingName[74:93]:   RuntimeSettingRevokeConsentPolicy,
+	_RuntimeSettingName[93:118]:  RuntimeSettingRehabberJournalVisibility,
+	_RuntimeSettingName[118:131]: RuntimeSettingSystemBaseURL,
+}
+
+// ParseRuntimeSetting attempts to convert a string to a RuntimeSetting.
+func ParseRuntimeSetting(name string) (RuntimeSetting, error) {
+	if x, ok := _RuntimeSettingValue[name]; ok {
+		return x, nil
+	}
+	return RuntimeSetting(0), fmt.Errorf("%s is %w", name, ErrInvalidRuntimeSetting)
+}
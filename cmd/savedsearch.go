@@ -0,0 +1,317 @@
+//go:generate go tool go-enum --no-iota --values
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ENUM(None = 0, App, Email)
+type SearchAlert int32
+
+const (
+	searchAlertPollInterval   = 5 * time.Minute
+	searchAlertDigestInterval = 24 * time.Hour
+	// Upper bound on notifications per saved search and check
+	searchAlertMaxPages = 5
+)
+
+// Only the parameters that affect which entries match are kept.
+func cleanSavedSearchParams(raw string) (string, error) {
+	values, err := url.ParseQuery(strings.TrimPrefix(raw, "?"))
+	if err != nil {
+		return "", err
+	}
+	if len(values.Get("q")) < 3 {
+		return "", fmt.Errorf("missing search query")
+	}
+	values.Del("page")
+	values.Del("debug-rank")
+	return values.Encode(), nil
+}
+
+func (server *Server) parseSearchAlert(r *http.Request) SearchAlert {
+	commonData := MustLoadCommonData(r.Context())
+
+	value, _ := server.getFormValue(r, "alert")
+	alert, err := ParseSearchAlert(value)
+	if err != nil {
+		return SearchAlertNone
+	}
+	if alert == SearchAlertEmail && !server.Config().Email.Enabled() {
+		commonData.Info(commonData.User.Language.SavedSearchEmailDisabled)
+		return SearchAlertApp
+	}
+	return alert
+}
+
+func (server *Server) postSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	fields, err := server.getFormValues(r, "name", "params")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	params, err := cleanSavedSearchParams(fields["params"])
+	if err != nil {
+		commonData.Error(commonData.User.Language.GenericFailed, err)
+		server.redirectToReferer(w, r)
+		return
+	}
+
+	name := strings.TrimSpace(fields["name"])
+	if name == "" {
+		values, _ := url.ParseQuery(params)
+		name = values.Get("q")
+	}
+
+	if _, err := server.Queries.CreateSavedSearch(ctx, CreateSavedSearchParams{
+		AppuserID: commonData.User.AppuserID,
+		Name:      name,
+		Params:    params,
+		Alert:     int32(server.parseSearchAlert(r)),
+	}); err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	commonData.Success(commonData.User.Language.SavedSearchSaved)
+	server.redirectToReferer(w, r)
+}
+
+func (server *Server) postSavedSearchAlertHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	id, err := server.getPathID(r, "search")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	if err := server.Queries.SetSavedSearchAlert(ctx, SetSavedSearchAlertParams{
+		ID:        id,
+		AppuserID: commonData.User.AppuserID,
+		Alert:     int32(server.parseSearchAlert(r)),
+	}); err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	commonData.Success(commonData.User.Language.GenericSuccess)
+	server.redirectToReferer(w, r)
+}
+
+func (server *Server) postSavedSearchDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	id, err := server.getPathID(r, "search")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	if err := server.Queries.DeleteSavedSearch(ctx, DeleteSavedSearchParams{
+		ID:        id,
+		AppuserID: commonData.User.AppuserID,
+	}); err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	commonData.Success(commonData.User.Language.SavedSearchDeleted)
+	server.redirectToReferer(w, r)
+}
+
+func (server *Server) getNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	notifications, err := server.Queries.GetNotificationsForUser(ctx, commonData.User.AppuserID)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	if err := server.Queries.MarkNotificationsSeen(ctx, commonData.User.AppuserID); err != nil {
+		LogR(r, "marking notifications as seen: %v", err)
+	}
+
+	_ = NotificationsPage(commonData, SliceToSlice(notifications, GetNotificationsForUserRow.ToNotificationView)).Render(ctx, w)
+}
+
+func (server *Server) postNotificationsClearHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	if err := server.Queries.DeleteNotificationsForUser(ctx, commonData.User.AppuserID); err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	server.redirectToReferer(w, r)
+}
+
+// Check saved searches for new matches, and periodically email digests of them.
+func (server *Server) backgroundSearchAlerts(ctx context.Context) {
+	var lastDigest time.Time
+	for {
+		server.checkSearchAlerts(ctx)
+
+		if server.Config().Email.Enabled() && time.Since(lastDigest) > searchAlertDigestInterval {
+			if err := server.sendSearchAlertDigests(ctx); err != nil {
+				log.Printf("ERROR: sending search alert digests: %v", err)
+			}
+			lastDigest = time.Now()
+		}
+
+		select {
+		case <-time.After(searchAlertPollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (server *Server) checkSearchAlerts(ctx context.Context) {
+	searches, err := server.Queries.GetSavedSearchesWithAlerts(ctx)
+	if err != nil {
+		log.Printf("ERROR: getting saved searches: %v", err)
+		return
+	}
+	for _, ss := range searches {
+		if err := server.checkSearchAlert(ctx, ss); err != nil {
+			log.Printf("ERROR: checking saved search %d: %v", ss.ID, err)
+		}
+	}
+}
+
+// Run the saved search as its owner, restricted to entries indexed since the
+// last check, and notify the owner about each match.
+func (server *Server) checkSearchAlert(ctx context.Context, ss GetSavedSearchesWithAlertsRow) error {
+	now := time.Now()
+
+	commonData := &CommonData{
+		BuildKey: server.BuildKey,
+		User: UserData{
+			AppuserID:   ss.AppuserID,
+			DisplayName: ss.DisplayName,
+			Email:       ss.Email,
+			Language:    GetLanguage(ss.LanguageID),
+			AccessLevel: AccessLevel(ss.AccessLevel),
+		},
+	}
+	// The user may have lost access since the search was saved
+	if commonData.User.AccessLevel < RequiredAccessLevel[CapSearch] {
+		return nil
+	}
+	// Needed for journal visibility
+	homes, err := server.Queries.GetHomesForUser(ctx, ss.AppuserID)
+	if err != nil {
+		return err
+	}
+	commonData.User.Homes = homes
+	ctx = WithCommonData(ctx, commonData)
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/search?"+ss.Params, nil)
+	if err != nil {
+		return err
+	}
+	query, _, err := server.parseSearchQuery(r)
+	if err != nil {
+		return err
+	}
+	query.MinIndexed = ss.LastChecked.Time
+
+	var matches []MatchView
+	for page := range int32(searchAlertMaxPages) {
+		query.Page = page
+		result, err := server.runSearch(ctx, query, nil)
+		if err != nil {
+			return err
+		}
+		matches = append(matches, result.PageMatches...)
+		if len(result.PageMatches) < int(pageSize) {
+			break
+		}
+	}
+
+	return server.Transaction(ctx, func(ctx context.Context, q *Queries) error {
+		for _, match := range matches {
+			if err := q.UpsertNotification(ctx, UpsertNotificationParams{
+				AppuserID:     ss.AppuserID,
+				SavedSearchID: ss.ID,
+				Header:        match.HeaderText(),
+				Url:           match.URL,
+			}); err != nil {
+				return err
+			}
+		}
+		return q.SetSavedSearchChecked(ctx, SetSavedSearchCheckedParams{
+			ID:          ss.ID,
+			LastChecked: pgtype.Timestamptz{Time: now, Valid: true},
+		})
+	})
+}
+
+// Email each user with pending notifications a summary of them.
+func (server *Server) sendSearchAlertDigests(ctx context.Context) error {
+	rows, err := server.Queries.GetNotificationsForDigest(ctx)
+	if err != nil {
+		return err
+	}
+
+	byUser := map[int32][]GetNotificationsForDigestRow{}
+	var users []int32
+	for _, row := range rows {
+		if _, ok := byUser[row.AppuserID]; !ok {
+			users = append(users, row.AppuserID)
+		}
+		byUser[row.AppuserID] = append(byUser[row.AppuserID], row)
+	}
+
+	for _, user := range users {
+		notifications := byUser[user]
+		lang := GetLanguage(notifications[0].LanguageID)
+
+		var body strings.Builder
+		fmt.Fprintf(&body, "%s\n", lang.SavedSearchDigestIntro)
+		savedSearch := ""
+		for _, n := range notifications {
+			if n.SavedSearchName != savedSearch {
+				savedSearch = n.SavedSearchName
+				fmt.Fprintf(&body, "\n%s\n", savedSearch)
+			}
+			url := n.Url
+			if strings.HasPrefix(url, "/") {
+				url = server.Config().SystemBaseURL + url
+			}
+			fmt.Fprintf(&body, "- %s: %s\n", n.Header, url)
+		}
+		fmt.Fprintf(&body, "\n%s: %s/notifications\n", lang.Notifications, server.Config().SystemBaseURL)
+
+		to := notifications[0].Email
+		if err := server.Config().Email.Send(to, lang.SavedSearchDigestSubject(len(notifications)), body.String()); err != nil {
+			log.Printf("ERROR: sending search alert digest to %s: %v", to, err)
+			continue
+		}
+
+		if err := server.Queries.SetNotificationsEmailed(ctx, SliceToSlice(notifications, func(n GetNotificationsForDigestRow) int32 {
+			return n.ID
+		})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
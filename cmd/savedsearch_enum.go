@@ -0,0 +1,68 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version: v0.9.1
+
+// Built By: go install
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// SearchAlertNone is a SearchAlert of type None.
+	SearchAlertNone SearchAlert = 0
+	// SearchAlertApp is a SearchAlert of type App.
+	SearchAlertApp SearchAlert = 1
+	// SearchAlertEmail is a SearchAlert of type Email.
+	SearchAlertEmail SearchAlert = 2
+)
+
+var ErrInvalidSearchAlert = errors.New("not a valid SearchAlert")
+
+const _SearchAlertName = "NoneAppEmail"
+
+// SearchAlertValues returns a list of the values for SearchAlert
+func SearchAlertValues() []SearchAlert {
+	return []SearchAlert{
+		SearchAlertNone,
+		SearchAlertApp,
+		SearchAlertEmail,
+	}
+}
+
+var _SearchAlertMap = map[SearchAlert]string{
+	SearchAlertNone:  _SearchAlertName[0:4],
+	SearchAlertApp:   _SearchAlertName[4:7],
+	SearchAlertEmail: _SearchAlertName[7:12],
+}
+
+// String implements the Stringer interface.
+func (x SearchAlert) String() string {
+	if str, ok := _SearchAlertMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("SearchAlert(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x SearchAlert) IsValid() bool {
+	_, ok := _SearchAlertMap[x]
+	return ok
+}
+
+var _SearchAlertValue = map[string]SearchAlert{
+	_SearchAlertName[0:4]:  SearchAlertNone,
+	_SearchAlertName[4:7]:  SearchAlertApp,
+	_SearchAlertName[7:12]: SearchAlertEmail,
+}
+
+// ParseSearchAlert attempts to convert a string to a SearchAlert.
+func ParseSearchAlert(name string) (SearchAlert, error) {
+	if x, ok := _SearchAlertValue[name]; ok {
+		return x, nil
+	}
+	return SearchAlert(0), fmt.Errorf("%s is %w", name, ErrInvalidSearchAlert)
+}
@@ -2,9 +2,12 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -15,13 +18,18 @@ import (
 // ENUM(None = 0, Newer, Older)
 type TimePreference int
 
+// ENUM(Any = 0, Active, Former)
+type ActivityFilter int
+
 const (
 	pageSize = int32(20)
 )
 
 type SearchQuery struct {
-	Mode           string
-	Query          string
+	Mode  string
+	Query string
+	// Query with qualifiers removed, passed on to full text search
+	Text           string
 	TimePreference TimePreference
 	Page           int32
 	MinCreated     int64
@@ -29,6 +37,64 @@ type SearchQuery struct {
 	MinUpdated     int64
 	MaxUpdated     int64
 	DebugRank      bool
+	Language       LanguageID
+	UserID         int32
+	// Whether the query contained qualifiers such as "species:"
+	Qualified bool
+	// Only match entries indexed after this, used for search alerts
+	MinIndexed time.Time
+	BodyAccess SearchBodyAccess
+
+	// Filters, only applied in advanced mode. Species, home and status come
+	// from the patient of an entry, so they exclude entries without one.
+	Namespaces []MatchType
+	SpeciesIDs []int32
+	HomeIDs    []int32
+	Statuses   []Status
+	Activity   ActivityFilter
+}
+
+// Whose journal bodies a user can see in search results. Entries with hidden
+// bodies are only matched and shown by their header.
+type SearchBodyAccess struct {
+	// All bodies are visible
+	All bool
+	// Bodies of entries that are not journals, such as files
+	Other bool
+	// Homes whose patients' journals are visible
+	HomeIDs []int32
+}
+
+// Whether the journal of a patient in the home is visible.
+func (a SearchBodyAccess) JournalVisible(home pgtype.Int4) bool {
+	return a.All || (home.Valid && slices.Contains(a.HomeIDs, home.Int32))
+}
+
+func (server *Server) searchBodyAccess(user *UserData) SearchBodyAccess {
+	switch {
+	case user.AccessLevel >= AccessLevelCoordinator:
+		return SearchBodyAccess{All: true}
+	case user.AccessLevel >= AccessLevelRehabber:
+		if server.Config().Privacy.RehabberJournalVisibility == JournalVisibilityAllHomes {
+			return SearchBodyAccess{All: true}
+		}
+		return SearchBodyAccess{
+			Other:   true,
+			HomeIDs: SliceToSlice(user.Homes, func(h Home) int32 { return h.ID }),
+		}
+	default:
+		return SearchBodyAccess{}
+	}
+}
+
+func (q SearchQuery) activeParam() pgtype.Bool {
+	switch q.Activity {
+	case ActivityFilterActive:
+		return pgtype.Bool{Bool: true, Valid: true}
+	case ActivityFilterFormer:
+		return pgtype.Bool{Bool: false, Valid: true}
+	}
+	return pgtype.Bool{}
 }
 
 type SearchSkipInfo struct {
@@ -59,12 +125,18 @@ func (spi *SearchPatientInfo) IndexableText() string {
 
 func NewBasicSearchParams(q SearchQuery) SearchBasicParams {
 	return SearchBasicParams{
-		WFtsHeader: 1.0,
-		WFtsBody:   1.0,
-		Lang:       "norwegian",
-		Offset:     q.Page * pageSize,
-		Limit:      pageSize,
-		Query:      q.Query,
+		WFtsHeader:  1.0,
+		WFtsBody:    1.0,
+		Lang:        q.Language.Regconfig(),
+		OtherLang:   q.Language.OtherRegconfig(),
+		UserID:      q.UserID,
+		MinIndexed:  pgtype.Timestamptz{Time: q.MinIndexed, Valid: !q.MinIndexed.IsZero()},
+		AllBodies:   q.BodyAccess.All,
+		OtherBodies: q.BodyAccess.Other,
+		BodyHomeIds: q.BodyAccess.HomeIDs,
+		Offset:      q.Page * pageSize,
+		Limit:       pageSize,
+		Query:       q.Text,
 	}
 }
 
@@ -80,8 +152,10 @@ func NewSearchAdvancedParams(q SearchQuery) SearchAdvancedParams {
 	}
 
 	return SearchAdvancedParams{
-		Lang:                "norwegian",
-		Query:               q.Query,
+		Lang:                q.Language.Regconfig(),
+		OtherLang:           q.Language.OtherRegconfig(),
+		UserID:              q.UserID,
+		Query:               q.Text,
 		WFtsHeader:          10.0,
 		WFtsBody:            10.0,
 		WSimHeader:          0.4,
@@ -97,24 +171,206 @@ func NewSearchAdvancedParams(q SearchQuery) SearchAdvancedParams {
 		MaxCreated:          pgtype.Timestamptz{Time: time.Unix(q.MaxCreated, 0), Valid: q.MaxCreated > 0},
 		MinUpdated:          pgtype.Timestamptz{Time: time.Unix(q.MinUpdated, 0), Valid: q.MinUpdated > 0},
 		MaxUpdated:          pgtype.Timestamptz{Time: time.Unix(q.MaxUpdated, 0), Valid: q.MaxUpdated > 0},
+		Namespaces:          SliceToSlice(q.Namespaces, MatchType.String),
+		SpeciesIds:          q.SpeciesIDs,
+		HomeIds:             q.HomeIDs,
+		Statuses:            SliceToSlice(q.Statuses, func(s Status) int32 { return int32(s) }),
+		Active:              q.activeParam(),
+		MinIndexed:          pgtype.Timestamptz{Time: q.MinIndexed, Valid: !q.MinIndexed.IsZero()},
+		AllBodies:           q.BodyAccess.All,
+		OtherBodies:         q.BodyAccess.Other,
+		BodyHomeIds:         q.BodyAccess.HomeIDs,
 	}
 }
 
+func (p SearchAdvancedParams) CountParams() SearchAdvancedCountParams {
+	return SearchAdvancedCountParams{
+		Query:        p.Query,
+		Simthreshold: p.Simthreshold,
+		Lang:         p.Lang,
+		OtherLang:    p.OtherLang,
+		MinCreated:   p.MinCreated,
+		MaxCreated:   p.MaxCreated,
+		MinUpdated:   p.MinUpdated,
+		MaxUpdated:   p.MaxUpdated,
+		Namespaces:   p.Namespaces,
+		SpeciesIds:   p.SpeciesIds,
+		HomeIds:      p.HomeIds,
+		Statuses:     p.Statuses,
+		Active:       p.Active,
+		UserID:       p.UserID,
+		AllBodies:    p.AllBodies,
+		OtherBodies:  p.OtherBodies,
+		BodyHomeIds:  p.BodyHomeIds,
+	}
+}
+
+func (p SearchAdvancedParams) FacetsParams() SearchAdvancedFacetsParams {
+	return SearchAdvancedFacetsParams{
+		Query:        p.Query,
+		Simthreshold: p.Simthreshold,
+		Lang:         p.Lang,
+		OtherLang:    p.OtherLang,
+		MinCreated:   p.MinCreated,
+		MaxCreated:   p.MaxCreated,
+		MinUpdated:   p.MinUpdated,
+		MaxUpdated:   p.MaxUpdated,
+		Namespaces:   p.Namespaces,
+		SpeciesIds:   p.SpeciesIds,
+		HomeIds:      p.HomeIds,
+		Statuses:     p.Statuses,
+		Active:       p.Active,
+		UserID:       p.UserID,
+		AllBodies:    p.AllBodies,
+		OtherBodies:  p.OtherBodies,
+		BodyHomeIds:  p.BodyHomeIds,
+	}
+}
+
+type SearchFacetOption struct {
+	Value    string `json:"value"`
+	Label    string `json:"label"`
+	Count    int32  `json:"count"`
+	Selected bool   `json:"selected"`
+}
+
+type SearchFacets struct {
+	Namespaces []SearchFacetOption `json:"namespaces,omitempty"`
+	Species    []SearchFacetOption `json:"species,omitempty"`
+	Homes      []SearchFacetOption `json:"homes,omitempty"`
+	Statuses   []SearchFacetOption `json:"statuses,omitempty"`
+	Activity   []SearchFacetOption `json:"activity,omitempty"`
+}
+
+func (sf SearchFacets) Empty() bool {
+	return len(sf.Namespaces) == 0 &&
+		len(sf.Species) == 0 &&
+		len(sf.Homes) == 0 &&
+		len(sf.Statuses) == 0 &&
+		len(sf.Activity) == 0
+}
+
+// Build facet options from the counts returned by SearchAdvancedFacets.
+// Options are included if they have matches or are currently selected.
+func (server *Server) buildSearchFacets(ctx context.Context, q SearchQuery, rows []SearchAdvancedFacetsRow) SearchFacets {
+	commonData := MustLoadCommonData(ctx)
+	lang := commonData.User.Language
+
+	counts := map[string]map[string]int32{}
+	for _, row := range rows {
+		if counts[row.Facet] == nil {
+			counts[row.Facet] = map[string]int32{}
+		}
+		counts[row.Facet][row.Value] = row.N
+	}
+
+	option := func(facet, value, label string, selected bool) (SearchFacetOption, bool) {
+		n := counts[facet][value]
+		return SearchFacetOption{
+			Value:    value,
+			Label:    label,
+			Count:    n,
+			Selected: selected,
+		}, n > 0 || selected
+	}
+
+	var out SearchFacets
+	for _, mt := range MatchTypeValues() {
+		if opt, ok := option("namespace", mt.String(), lang.MatchType[mt], slices.Contains(q.Namespaces, mt)); ok {
+			out.Namespaces = append(out.Namespaces, opt)
+		}
+	}
+
+	if species, err := server.Queries.GetSpeciesWithLanguage(ctx, commonData.Lang32()); err == nil {
+		for _, sp := range species {
+			if opt, ok := option("species", strconv.Itoa(int(sp.SpeciesID)), sp.Name, slices.Contains(q.SpeciesIDs, sp.SpeciesID)); ok {
+				out.Species = append(out.Species, opt)
+			}
+		}
+	} else {
+		LogCtx(ctx, "loading species for search facets: %v", err)
+	}
+
+	if homes, err := server.Queries.GetHomes(ctx); err == nil {
+		for _, home := range homes {
+			if opt, ok := option("home", strconv.Itoa(int(home.ID)), home.Name, slices.Contains(q.HomeIDs, home.ID)); ok {
+				out.Homes = append(out.Homes, opt)
+			}
+		}
+	} else {
+		LogCtx(ctx, "loading homes for search facets: %v", err)
+	}
+
+	for _, status := range StatusValues() {
+		if opt, ok := option("status", strconv.Itoa(int(status)), lang.Status[status], slices.Contains(q.Statuses, status)); ok {
+			out.Statuses = append(out.Statuses, opt)
+		}
+	}
+
+	active, _ := option("active", "true", lang.SearchFilterActive, q.Activity == ActivityFilterActive)
+	former, _ := option("active", "false", lang.SearchFilterFormer, q.Activity == ActivityFilterFormer)
+	if active.Count > 0 || former.Count > 0 || q.Activity != ActivityFilterAny {
+		active.Value = ActivityFilterActive.String()
+		former.Value = ActivityFilterFormer.String()
+		out.Activity = []SearchFacetOption{active, former}
+	}
+
+	return out
+}
+
 type SearchResult struct {
 	Query        SearchQuery
 	PageMatches  []MatchView
 	Offset       int32
 	TotalMatches int32
 	Milliseconds int
+	Facets       SearchFacets
+	// Problems with the query that the user should know about
+	Notices []string
+}
+
+func parseIDs(in []string) []int32 {
+	var out []int32
+	for _, s := range in {
+		if v, err := strconv.ParseInt(s, 10, 32); err == nil {
+			out = append(out, int32(v))
+		}
+	}
+	return out
+}
+
+// A search query that can't be run, as opposed to a failure running it
+type searchQueryError struct {
+	err error
+}
+
+func (e searchQueryError) Error() string {
+	return e.err.Error()
+}
+
+func (e searchQueryError) Unwrap() error {
+	return e.err
 }
 
 func (server *Server) doSearch(r *http.Request) (SearchResult, error) {
+	query, notices, err := server.parseSearchQuery(r)
+	if err != nil {
+		return SearchResult{Query: query, Notices: notices}, searchQueryError{err: err}
+	}
+	return server.runSearch(r.Context(), query, notices)
+}
+
+// Read the search form into a query, with qualifiers applied. Returns notices
+// about parts of the query that could not be used.
+func (server *Server) parseSearchQuery(r *http.Request) (SearchQuery, []string, error) {
+	commonData := MustLoadCommonData(r.Context())
+
 	q, err := server.getFormValue(r, "q")
 	if err != nil {
-		return SearchResult{}, err
+		return SearchQuery{}, nil, err
 	}
 	if len(q) < 3 {
-		return SearchResult{}, errors.New("too short")
+		return SearchQuery{}, nil, errors.New("too short")
 	}
 	formValues := server.getOptionalFormValues(
 		r,
@@ -126,6 +382,7 @@ func (server *Server) doSearch(r *http.Request) (SearchResult, error) {
 		"updated-to",
 		"time-preference",
 		"debug-rank",
+		"activity",
 	)
 
 	mode := formValues["mode"]
@@ -137,19 +394,26 @@ func (server *Server) doSearch(r *http.Request) (SearchResult, error) {
 		page = 0
 	}
 	minCreated, maxCreated, minUpdated, maxUpdated := int64(0), int64(0), int64(0), int64(0)
-	if t, err := time.Parse(formValues["created-from"], time.DateOnly); err == nil {
+	if t, err := time.Parse(time.DateOnly, formValues["created-from"]); err == nil {
 		minCreated = t.Unix()
 	}
-	if t, err := time.Parse(formValues["created-to"], time.DateOnly); err == nil {
+	if t, err := time.Parse(time.DateOnly, formValues["created-to"]); err == nil {
 		maxCreated = t.Unix()
 	}
-	if t, err := time.Parse(formValues["updated-from"], time.DateOnly); err == nil {
+	if t, err := time.Parse(time.DateOnly, formValues["updated-from"]); err == nil {
 		minUpdated = t.Unix()
 	}
-	if t, err := time.Parse(formValues["updated-to"], time.DateOnly); err == nil {
+	if t, err := time.Parse(time.DateOnly, formValues["updated-to"]); err == nil {
 		maxUpdated = t.Unix()
 	}
 	timePref, _ := ParseTimePreference(strings.TrimSpace(formValues["time-preference"]))
+	activity, _ := ParseActivityFilter(strings.TrimSpace(formValues["activity"]))
+	var namespaces []MatchType
+	for _, ns := range server.getOptionalFormMultiValue(r, "ns") {
+		if mt, err := ParseMatchType(ns); err == nil {
+			namespaces = append(namespaces, mt)
+		}
+	}
 	query := SearchQuery{
 		Query:          q,
 		Mode:           mode,
@@ -160,51 +424,78 @@ func (server *Server) doSearch(r *http.Request) (SearchResult, error) {
 		MinUpdated:     minUpdated,
 		MaxUpdated:     maxUpdated,
 		DebugRank:      formValues["debug-rank"] != "",
+		Language:       commonData.User.Language.ID,
+		UserID:         commonData.User.AppuserID,
+		BodyAccess:     server.searchBodyAccess(&commonData.User),
+		Namespaces:     namespaces,
+		SpeciesIDs:     parseIDs(server.getOptionalFormMultiValue(r, "species")),
+		HomeIDs:        parseIDs(server.getOptionalFormMultiValue(r, "home")),
+		Statuses: SliceToSlice(parseIDs(server.getOptionalFormMultiValue(r, "status")), func(id int32) Status {
+			return Status(id)
+		}),
+		Activity: activity,
+	}
+	notices := server.applySearchQualifiers(r.Context(), &query)
+	if len(query.SpeciesIDs) > 0 || len(query.HomeIDs) > 0 || len(query.Statuses) > 0 {
+		notices = append(notices, commonData.User.Language.SearchFilterPatientsOnly)
 	}
+	if query.Qualified {
+		query.Mode = "advanced"
+	}
+	return query, notices, nil
+}
 
+func (server *Server) runSearch(ctx context.Context, query SearchQuery, notices []string) (SearchResult, error) {
 	var matches []MatchView
 	var totalMatches int32
 	var offset int32
+	var facets SearchFacets
 	t0 := time.Now()
-	if mode == "advanced" {
+	if query.Mode == "advanced" {
 		searchParams := NewSearchAdvancedParams(query)
-		rows, err := server.Queries.SearchAdvanced(r.Context(), searchParams)
+		rows, err := server.Queries.SearchAdvanced(ctx, searchParams)
 		if err != nil {
-			return SearchResult{Query: query}, err
+			return SearchResult{Query: query, Notices: notices}, err
 		}
 		matches = SliceToSlice(rows, func(in SearchAdvancedRow) MatchView {
-			return in.ToMatchView(q)
+			return in.ToMatchView(query.Text)
 		})
 		if searchParams.Offset > 0 || len(matches) >= int(searchParams.Limit) {
-			totalMatches, err = server.Queries.SearchAdvancedCount(r.Context(), SearchAdvancedCountParams{
-				Query:        query.Query,
-				Simthreshold: searchParams.Simthreshold,
-				Lang:         searchParams.Lang,
-			})
+			totalMatches, err = server.Queries.SearchAdvancedCount(ctx, searchParams.CountParams())
 			if err != nil {
-				LogR(r, "counting: %s", err.Error())
+				LogCtx(ctx, "counting: %s", err.Error())
 				totalMatches = int32(len(matches))
 			}
 		} else {
 			totalMatches = int32(len(matches))
 		}
 		offset = searchParams.Offset
+		if facetRows, err := server.Queries.SearchAdvancedFacets(ctx, searchParams.FacetsParams()); err == nil {
+			facets = server.buildSearchFacets(ctx, query, facetRows)
+		} else {
+			LogCtx(ctx, "computing facets: %s", err.Error())
+		}
 	} else {
 		searchParams := NewBasicSearchParams(query)
-		rows, err := server.Queries.SearchBasic(r.Context(), searchParams)
+		rows, err := server.Queries.SearchBasic(ctx, searchParams)
 		if err != nil {
-			return SearchResult{Query: query}, err
+			return SearchResult{Query: query, Notices: notices}, err
 		}
 		matches = SliceToSlice(rows, func(in SearchBasicRow) MatchView {
 			return in.ToMatchView()
 		})
 		if searchParams.Offset > 0 || len(matches) >= int(searchParams.Limit) {
-			totalMatches, err = server.Queries.SearchBasicCount(r.Context(), SearchBasicCountParams{
-				Query: query.Query,
-				Lang:  searchParams.Lang,
+			totalMatches, err = server.Queries.SearchBasicCount(ctx, SearchBasicCountParams{
+				Query:       query.Text,
+				Lang:        searchParams.Lang,
+				OtherLang:   searchParams.OtherLang,
+				UserID:      searchParams.UserID,
+				AllBodies:   searchParams.AllBodies,
+				OtherBodies: searchParams.OtherBodies,
+				BodyHomeIds: searchParams.BodyHomeIds,
 			})
 			if err != nil {
-				LogR(r, "counting: %s", err.Error())
+				LogCtx(ctx, "counting: %s", err.Error())
 				totalMatches = int32(len(matches))
 			}
 		} else {
@@ -220,6 +511,8 @@ func (server *Server) doSearch(r *http.Request) (SearchResult, error) {
 		TotalMatches: totalMatches,
 		Offset:       offset,
 		Milliseconds: int((elapsed + (time.Millisecond / 2)) / time.Millisecond),
+		Facets:       facets,
+		Notices:      notices,
 	}, nil
 }
 
@@ -230,6 +523,7 @@ func (server *Server) emptySearch(w http.ResponseWriter, r *http.Request, result
 		_ = SearchPage(commonData, result, msg).Render(ctx, w)
 	} else {
 		_ = SearchMatches(commonData, result, msg).Render(ctx, w)
+		_ = SearchFacetFilters(commonData, result, true).Render(ctx, w)
 	}
 }
 
@@ -255,4 +549,124 @@ func (server *Server) searchLiveHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	_ = SearchMatches(commonData, result, commonData.User.Language.GenericNotFound).Render(ctx, w)
+	_ = SearchFacetFilters(commonData, result, true).Render(ctx, w)
+}
+
+// JSON representation of SearchResult for the search API.
+type SearchAPIResult struct {
+	Query        string           `json:"query"`
+	Mode         string           `json:"mode"`
+	Page         int32            `json:"page"`
+	PageSize     int32            `json:"pageSize"`
+	Offset       int32            `json:"offset"`
+	TotalMatches int32            `json:"totalMatches"`
+	TotalPages   int32            `json:"totalPages"`
+	Milliseconds int              `json:"milliseconds"`
+	Namespaces   []string         `json:"namespaces"`
+	Matches      []SearchAPIMatch `json:"matches"`
+	Facets       *SearchFacets    `json:"facets,omitempty"`
+	Notices      []string         `json:"notices,omitempty"`
+	Error        string           `json:"error,omitempty"`
+}
+
+type SearchAPIMatch struct {
+	URL        string           `json:"url"`
+	Namespace  string           `json:"namespace"`
+	Header     []SearchAPIRun   `json:"header"`
+	Fragments  [][]SearchAPIRun `json:"fragments"`
+	Rank       float32          `json:"rank"`
+	JournalURL string           `json:"journalUrl,omitempty"`
+	FolderName string           `json:"folderName,omitempty"`
+	FolderURL  string           `json:"folderUrl,omitempty"`
+}
+
+type SearchAPIRun struct {
+	Text string `json:"text"`
+	Hit  bool   `json:"hit,omitempty"`
+}
+
+func toSearchAPIRuns(runs []HighlightRun) []SearchAPIRun {
+	return SliceToSlice(runs, func(r HighlightRun) SearchAPIRun {
+		return SearchAPIRun{Text: r.Text, Hit: r.Hit}
+	})
+}
+
+func (result SearchResult) ToAPI(baseURL string) SearchAPIResult {
+	out := SearchAPIResult{
+		Query:        result.Query.Query,
+		Mode:         result.Query.Mode,
+		Page:         result.Query.Page,
+		PageSize:     pageSize,
+		Offset:       result.Offset,
+		TotalMatches: result.TotalMatches,
+		TotalPages:   (result.TotalMatches + pageSize - 1) / pageSize,
+		Milliseconds: result.Milliseconds,
+		Namespaces:   SliceToSlice(MatchTypeValues(), MatchType.String),
+		Matches:      []SearchAPIMatch{},
+		Notices:      result.Notices,
+	}
+	if !result.Facets.Empty() {
+		out.Facets = &result.Facets
+	}
+	for _, mv := range result.PageMatches {
+		match := SearchAPIMatch{
+			URL:       mv.URL,
+			Namespace: mv.Type.String(),
+			Header:    toSearchAPIRuns(mv.HeaderRuns),
+			Fragments: SliceToSlice(mv.BodyFragments, func(f HighlightFragment) []SearchAPIRun {
+				return toSearchAPIRuns(f.Runs)
+			}),
+			Rank: mv.Rank,
+		}
+		// Bino-internal URLs are made absolute so that they work outside the browser
+		if strings.HasPrefix(match.URL, "/") {
+			match.URL = baseURL + match.URL
+		}
+		switch mv.Type {
+		case MatchTypePatient:
+			if info := parseJSON[SearchPatientInfo](mv.ExtraData); info != nil {
+				match.JournalURL = info.JournalURL
+				match.FolderName = info.JournalInfo.FolderName
+				match.FolderURL = info.JournalInfo.FolderURL
+			}
+		case MatchTypeJournal:
+			match.JournalURL = mv.URL
+			if info := parseJSON[SearchJournalInfo](mv.ExtraData); info != nil {
+				match.FolderName = info.FolderName
+				match.FolderURL = info.FolderURL
+			}
+		}
+		out.Matches = append(out.Matches, match)
+	}
+	return out
+}
+
+// Same as searchLiveHandler, but returns JSON.
+func (server *Server) searchAPIHandler(w http.ResponseWriter, r *http.Request) {
+	commonData := MustLoadCommonData(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+
+	result, err := server.doSearch(r)
+	out := result.ToAPI(server.Config().SystemBaseURL)
+	status := http.StatusOK
+	if err != nil {
+		logError(r, err)
+		// Only errors in the query are shown, the rest may contain internals
+		if errors.As(err, &searchQueryError{}) {
+			out.Error = err.Error()
+			status = http.StatusBadRequest
+		} else {
+			out.Error = commonData.User.Language.GenericFailed
+			status = http.StatusInternalServerError
+		}
+	}
+
+	bin, err := json.Marshal(out)
+	if err != nil {
+		logError(r, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(status)
+	w.Write(bin)
 }
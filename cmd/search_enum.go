@@ -10,6 +10,63 @@ import (
 	"fmt"
 )
 
+const (
+	// ActivityFilterAny is a ActivityFilter of type Any.
+	ActivityFilterAny ActivityFilter = 0
+	// ActivityFilterActive is a ActivityFilter of type Active.
+	ActivityFilterActive ActivityFilter = 1
+	// ActivityFilterFormer is a ActivityFilter of type Former.
+	ActivityFilterFormer ActivityFilter = 2
+)
+
+var ErrInvalidActivityFilter = errors.New("not a valid ActivityFilter")
+
+const _ActivityFilterName = "AnyActiveFormer"
+
+// ActivityFilterValues returns a list of the values for ActivityFilter
+func ActivityFilterValues() []ActivityFilter {
+	return []ActivityFilter{
+		ActivityFilterAny,
+		ActivityFilterActive,
+		ActivityFilterFormer,
+	}
+}
+
+var _ActivityFilterMap = map[ActivityFilter]string{
+	ActivityFilterAny:    _ActivityFilterName[0:3],
+	ActivityFilterActive: _ActivityFilterName[3:9],
+	ActivityFilterFormer: _ActivityFilterName[9:15],
+}
+
+// String implements the Stringer interface.
+func (x ActivityFilter) String() string {
+	if str, ok := _ActivityFilterMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("ActivityFilter(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x ActivityFilter) IsValid() bool {
+	_, ok := _ActivityFilterMap[x]
+	return ok
+}
+
+var _ActivityFilterValue = map[string]ActivityFilter{
+	_ActivityFilterName[0:3]:  ActivityFilterAny,
+	_ActivityFilterName[3:9]:  ActivityFilterActive,
+	_ActivityFilterName[9:15]: ActivityFilterFormer,
+}
+
+// ParseActivityFilter attempts to convert a string to a ActivityFilter.
+func ParseActivityFilter(name string) (ActivityFilter, error) {
+	if x, ok := _ActivityFilterValue[name]; ok {
+		return x, nil
+	}
+	return ActivityFilter(0), fmt.Errorf("%s is %w", name, ErrInvalidActivityFilter)
+}
+
 const (
 	// TimePreferenceNone is a TimePreference of type None.
 	TimePreferenceNone TimePreference = 0
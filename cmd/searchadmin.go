@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type SearchIndexFolderView struct {
+	Folder GDriveItem
+	Sync   SearchFolderSync
+}
+
+type SearchSkippedView struct {
+	GetSkippedSearchEntriesRow
+	DocumentURL string
+}
+
+type SearchIndexAdminData struct {
+	Stats   []GetSearchIndexStatsRow
+	Skipped []SearchSkippedView
+	Folders []SearchIndexFolderView
+	Sync    SearchSyncState
+}
+
+func (server *Server) getSearchIndexHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	stats, err := server.Queries.GetSearchIndexStats(ctx)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	skipped, err := server.Queries.GetSkippedSearchEntries(ctx)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	syncs, err := server.Queries.GetSearchFolderSyncs(ctx)
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+	syncByFolder := SliceToMap(syncs, func(s SearchFolderSync) (string, SearchFolderSync) { return s.FolderID, s })
+
+	// Missing state just means the indexer hasn't run yet
+	syncID := server.Config().GoogleDrive.DriveBase
+	var folders []GDriveItem
+	if server.GDriveWorker != nil {
+		if info, err := server.GDriveWorker.GetGDriveConfigInfo(); err == nil {
+			folders = append([]GDriveItem{info.JournalFolder}, info.ExtraFolders...)
+		} else {
+			commonData.Warning(commonData.User.Language.GDriveUnavailable, err)
+		}
+	} else {
+		syncID = markdownJournalSyncID
+	}
+	state, _ := server.Queries.GetSearchSyncState(ctx, syncID)
+
+	_ = SearchIndexPage(commonData, SearchIndexAdminData{
+		Stats: stats,
+		Skipped: SliceToSlice(skipped, func(in GetSkippedSearchEntriesRow) SearchSkippedView {
+			return SearchSkippedView{
+				GetSkippedSearchEntriesRow: in,
+				DocumentURL:                skippedEntryDocumentURL(in),
+			}
+		}),
+		Folders: SliceToSlice(folders, func(folder GDriveItem) SearchIndexFolderView {
+			return SearchIndexFolderView{
+				Folder: folder,
+				Sync:   syncByFolder[folder.ID],
+			}
+		}),
+		Sync: state,
+	}).Render(ctx, w)
+}
+
+// The journal document behind a search entry. Patient entries store it in extra data.
+func skippedEntryDocumentURL(row GetSkippedSearchEntriesRow) string {
+	if row.Ns == string(MatchTypePatient) {
+		var info SearchPatientInfo
+		if err := json.Unmarshal([]byte(row.ExtraData.String), &info); err == nil {
+			return info.JournalURL
+		}
+		return ""
+	}
+	return row.AssociatedUrl.String
+}
+
+func (server *Server) postSearchReindexHandler(w http.ResponseWriter, r *http.Request) {
+	server.requestSearchReindex(w, r, searchReindexRequest{})
+}
+
+func (server *Server) postSearchReindexFolderHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	folder, err := server.getPathValue(r, "folder")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	// Only the journal folders are indexed
+	if server.GDriveWorker != nil {
+		folders, err := server.GDriveWorker.searchIndexFolders()
+		if err != nil {
+			commonData.Error(commonData.User.Language.GenericFailed, err)
+			server.redirectToReferer(w, r)
+			return
+		}
+		if _, ok := folders[folder]; !ok {
+			commonData.Error(commonData.User.Language.SearchIndexNotJournalFolder, nil)
+			server.redirectToReferer(w, r)
+			return
+		}
+	}
+
+	server.requestSearchReindex(w, r, searchReindexRequest{FolderID: folder})
+}
+
+func (server *Server) postSearchReindexDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	url, err := server.getFormValue(r, "url")
+	if err != nil {
+		server.renderError(w, r, commonData, err)
+		return
+	}
+
+	// Uploaded files are indexed by bino, so do it right away
+	if fileID, ok := parseFileURL(url); ok {
+		if err := server.reindexUploadedFile(ctx, fileID); errors.Is(err, errNotIndexed) {
+			commonData.Error(commonData.User.Language.SearchIndexNotIndexedURL, nil)
+		} else if err != nil {
+			commonData.Error(commonData.User.Language.GenericFailed, err)
+		} else {
+			commonData.Success(commonData.User.Language.GenericSuccess)
+		}
+		server.redirectToReferer(w, r)
+		return
+	}
+
+	baseURL := parseJournalURL(url)
+	if baseURL == "" {
+		commonData.Error(commonData.User.Language.SearchIndexNotIndexedURL, nil)
+		server.redirectToReferer(w, r)
+		return
+	}
+
+	// Journals stored in bino are quick to index, so do it right away
+	if _, ok := parseMarkdownJournalURL(baseURL); ok {
+		if err := server.indexJournal(ctx, baseURL); err != nil {
+			commonData.Error(commonData.User.Language.GenericFailed, err)
+		} else {
+			commonData.Success(commonData.User.Language.GenericSuccess)
+		}
+		server.redirectToReferer(w, r)
+		return
+	}
+
+	server.requestSearchReindex(w, r, searchReindexRequest{
+		FileID: strings.TrimPrefix(baseURL, "https://docs.google.com/document/d/"),
+	})
+}
+
+func (server *Server) requestSearchReindex(w http.ResponseWriter, r *http.Request, req searchReindexRequest) {
+	ctx := r.Context()
+	commonData := MustLoadCommonData(ctx)
+
+	// Everything in bino is reindexed along with Drive: Markdown journals by
+	// listing them all again, and uploaded files in the background, including
+	// those that were skipped
+	if req == (searchReindexRequest{}) {
+		if err := server.Queries.ResetSearchSyncPageToken(ctx, markdownJournalSyncID); err != nil {
+			commonData.Error(commonData.User.Language.GenericFailed, err)
+			server.redirectToReferer(w, r)
+			return
+		}
+		go server.indexFiles(context.WithoutCancel(ctx), true)
+	}
+
+	if server.GDriveWorker == nil {
+		commonData.Success(commonData.User.Language.SearchIndexReindexQueued)
+		server.redirectToReferer(w, r)
+		return
+	}
+
+	if server.GDriveWorker.RequestSearchReindex(req) {
+		commonData.Success(commonData.User.Language.SearchIndexReindexQueued)
+	} else {
+		commonData.Warning(commonData.User.Language.SearchIndexReindexQueueFull, nil)
+	}
+
+	server.redirectToReferer(w, r)
+}
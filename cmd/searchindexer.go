@@ -6,39 +6,280 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const (
+	searchIndexPollInterval     = time.Minute
+	searchIndexFullSyncInterval = time.Hour * 24
+)
+
+// The queries used to keep the search index in sync. Implemented by Queries,
+// and can be faked together with GDriveClient.
+type searchIndexStore interface {
+	GetSearchSyncState(ctx context.Context, driveID string) (SearchSyncState, error)
+	ResetSearchSyncPageToken(ctx context.Context, driveID string) error
+	SetSearchSyncFull(ctx context.Context, arg SetSearchSyncFullParams) error
+	SetSearchSyncIncremental(ctx context.Context, arg SetSearchSyncIncrementalParams) error
+	SetSearchFolderSynced(ctx context.Context, arg SetSearchFolderSyncedParams) error
+	SetSearchFolderSyncError(ctx context.Context, arg SetSearchFolderSyncErrorParams) error
+	GetPatientsByJournalURL(ctx context.Context, lookup string) ([]int32, error)
+	GetSearchUpdatedTime(ctx context.Context, arg GetSearchUpdatedTimeParams) (pgtype.Timestamptz, error)
+	UpsertSearchEntry(ctx context.Context, arg UpsertSearchEntryParams) error
+	UpsertSkippedSearchEntry(ctx context.Context, arg UpsertSkippedSearchEntryParams) error
+	UpdateSearchMetadata(ctx context.Context, arg UpdateSearchMetadataParams) (pgconn.CommandTag, error)
+	DeleteSearchEntry(ctx context.Context, arg DeleteSearchEntryParams) error
+}
+
+// Request to re-read documents regardless of whether they have changed.
+// Leave both fields empty to reindex everything.
+type searchReindexRequest struct {
+	FolderID string
+	FileID   string
+}
+
 func (w *GDriveWorker) searchIndexWorker(ctx context.Context) {
 	for {
-		if err := w.searchIndexAll(ctx); err != nil {
-			log.Printf("ERROR: %v", err)
+		// Skipped while Drive is unavailable
+		if w.Health().OK() {
+			if err := w.searchIndexSync(ctx); err != nil {
+				log.Printf("ERROR: %v", err)
+			}
+		}
+		select {
+		case req := <-w.searchReindex:
+			if err := w.searchIndexForced(ctx, req); err != nil {
+				log.Printf("ERROR: reindexing %+v: %v", req, err)
+			}
+		case <-time.After(searchIndexPollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Queue a forced reindex. Returns false if too many requests are already waiting.
+func (w *GDriveWorker) RequestSearchReindex(req searchReindexRequest) bool {
+	select {
+	case w.searchReindex <- req:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *GDriveWorker) searchIndexForced(ctx context.Context, req searchReindexRequest) error {
+	switch {
+	case req.FileID != "":
+		file, err := w.GetFile(req.FileID)
+		if err != nil {
+			return fmt.Errorf("getting file: %w", err)
+		}
+		folders, err := w.searchIndexFolders()
+		if err != nil {
+			return err
+		}
+		for _, folder := range folders {
+			if slices.Contains(file.Parents, folder.ID) {
+				return w.searchIndexFile(ctx, folder, file, true)
+			}
+		}
+		return fmt.Errorf("%s is not in a journal folder", file.Name)
+	case req.FolderID != "":
+		return w.searchIndexFolder(ctx, req.FolderID, true)
+	default:
+		return w.searchIndexFull(ctx, true)
+	}
+}
+
+// The folders that are indexed, by ID.
+func (w *GDriveWorker) searchIndexFolders() (map[string]GDriveItem, error) {
+	info, err := w.GetGDriveConfigInfo()
+	if err != nil {
+		return nil, err
+	}
+	folders := map[string]GDriveItem{
+		info.JournalFolder.ID: info.JournalFolder,
+	}
+	for _, folder := range info.ExtraFolders {
+		folders[folder.ID] = folder
+	}
+	archive, err := w.searchArchiveFolders()
+	if err != nil {
+		return nil, err
+	}
+	for _, folder := range archive {
+		folders[folder.ID] = folder
+	}
+	return folders, nil
+}
+
+// The archive folder and its year folders, where journals are moved at
+// checkout. They're indexed so that later edits to the journal are found.
+func (w *GDriveWorker) searchArchiveFolders() ([]GDriveItem, error) {
+	cfg := w.Config()
+	if cfg.ArchiveFolder == "" {
+		return nil, nil
+	}
+	archive, err := w.client.GetFile(cfg.ArchiveFolder)
+	if err != nil {
+		return nil, fmt.Errorf("getting archive folder: %w", err)
+	}
+	folders := []GDriveItem{archive}
+	if cfg.ArchivePerYear {
+		years, err := w.client.ListFolders(cfg.ArchiveFolder)
+		if err != nil {
+			return nil, fmt.Errorf("listing archive folders: %w", err)
 		}
-		time.Sleep(time.Minute * 10)
+		folders = append(folders, years...)
 	}
+	return folders, nil
 }
 
-func (w *GDriveWorker) searchIndexAll(ctx context.Context) error {
-	w.searchIndexFolder(ctx, w.cfg.JournalFolder)
-	for _, folder := range w.cfg.ExtraJournalFolders {
-		w.searchIndexFolder(ctx, folder)
+// Process the changes since the last sync. Falls back to reconciling all
+// folders if there is no position in the changes feed or a full sync is due.
+func (w *GDriveWorker) searchIndexSync(ctx context.Context) error {
+	state, err := w.searchStore.GetSearchSyncState(ctx, w.Config().DriveBase)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("loading search sync state: %w", err)
+	}
+
+	if state.PageToken == "" || !state.LastFullSync.Valid || time.Since(state.LastFullSync.Time) > searchIndexFullSyncInterval {
+		return w.searchIndexFull(ctx, false)
+	}
+
+	folders, err := w.searchIndexFolders()
+	if err != nil {
+		return err
+	}
+
+	if err := w.searchIndexChanges(ctx, folders, state.PageToken); err != nil {
+		// Start over with a full sync next time
+		if err := w.searchStore.ResetSearchSyncPageToken(ctx, w.Config().DriveBase); err != nil {
+			log.Printf("resetting search sync page token: %v", err)
+		}
+		return fmt.Errorf("processing changes: %w", err)
+	}
+	return nil
+}
+
+func (w *GDriveWorker) searchIndexFull(ctx context.Context, force bool) error {
+	// Get the token before listing, so that changes made during the listing are picked up next time
+	pageToken, err := w.client.GetStartPageToken()
+	if err != nil {
+		return fmt.Errorf("getting start page token: %w", err)
+	}
+
+	if err := w.searchIndexAll(ctx, force); err != nil {
+		return err
+	}
+
+	return w.searchStore.SetSearchSyncFull(ctx, SetSearchSyncFullParams{
+		DriveID:   w.Config().DriveBase,
+		PageToken: pageToken,
+	})
+}
+
+// Index every folder, even if some of them fail. Fails if any of them did, so
+// that the sync state isn't moved past documents that weren't indexed.
+func (w *GDriveWorker) searchIndexAll(ctx context.Context, force bool) error {
+	var errs []error
+	folders := append([]string{w.Config().JournalFolder}, w.Config().ExtraJournalFolders...)
+	if archive, err := w.searchArchiveFolders(); err == nil {
+		folders = append(folders, SliceToSlice(archive, func(folder GDriveItem) string {
+			return folder.ID
+		})...)
+	} else {
+		errs = append(errs, err)
+	}
+	for _, folder := range folders {
+		if err := w.searchIndexFolder(ctx, folder, force); err != nil {
+			errs = append(errs, fmt.Errorf("indexing folder %s: %w", folder, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Process the changes from pageToken on, for documents in or leaving folders.
+func (w *GDriveWorker) searchIndexChanges(ctx context.Context, folders map[string]GDriveItem, pageToken string) error {
+	n := 0
+	for {
+		changes, err := w.client.ListChanges(pageToken)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes.Changes {
+			if err := w.searchIndexChange(ctx, folders, change); err != nil {
+				log.Printf("ERROR (%s): %s", change.FileID, err.Error())
+			}
+		}
+		n += len(changes.Changes)
+
+		if changes.NewStartPageToken != "" {
+			pageToken = changes.NewStartPageToken
+			break
+		}
+		if changes.NextPageToken == "" {
+			return errors.New("changes list has neither next page token nor new start page token")
+		}
+		pageToken = changes.NextPageToken
+	}
+
+	if n > 0 {
+		log.Printf("Processed %d changes for search index", n)
+	}
+
+	return w.searchStore.SetSearchSyncIncremental(ctx, SetSearchSyncIncrementalParams{
+		DriveID:   w.Config().DriveBase,
+		PageToken: pageToken,
+	})
+}
+
+// Index a single changed file if it's a document in one of the journal
+// folders, and remove it if it's no longer in any of them.
+func (w *GDriveWorker) searchIndexChange(ctx context.Context, folders map[string]GDriveItem, change GDriveChange) error {
+	if change.Removed {
+		return w.searchIndexFile(ctx, GDriveItem{}, GDriveItem{
+			ID:      change.FileID,
+			Valid:   true,
+			Trashed: true,
+		}, false)
+	}
+	if !change.IsDocument {
+		return nil
+	}
+	for _, parent := range change.Item.Parents {
+		if folder, ok := folders[parent]; ok {
+			return w.searchIndexFile(ctx, folder, change.Item, false)
+		}
+	}
+	// Moved out of the journal folders. Only the journal entry is removed:
+	// the entry of a patient is kept as long as the patient links to the
+	// journal, as it does when the journal is archived.
+	if err := w.searchStore.DeleteSearchEntry(ctx, DeleteSearchEntryParams{
+		Namespace:     "journal",
+		AssociatedUrl: pgtype.Text{String: change.Item.DocumentURL(), Valid: true},
+	}); err != nil {
+		return fmt.Errorf("deleting search entry: %w", err)
 	}
 	return nil
 }
 
 func (w *GDriveWorker) listFiles(ctx context.Context, folderID string) (ListFilesResult, error) {
-	res, err := w.ListFiles(ListFilesParams{
+	res, err := w.client.ListFiles(ListFilesParams{
 		Parent: folderID,
 	})
 	if err != nil {
 		return ListFilesResult{}, err
 	}
 	for res.NextPageToken != "" {
-		if page, err := w.ListFiles(ListFilesParams{
+		if page, err := w.client.ListFiles(ListFilesParams{
 			Parent:    folderID,
 			PageToken: res.NextPageToken,
 		}); err == nil {
@@ -51,23 +292,35 @@ func (w *GDriveWorker) listFiles(ctx context.Context, folderID string) (ListFile
 	return res, nil
 }
 
-func (w *GDriveWorker) searchIndexFolder(ctx context.Context, folderID string) error {
+func (w *GDriveWorker) searchIndexFolder(ctx context.Context, folderID string, force bool) error {
 	res, err := w.listFiles(ctx, folderID)
 	if err != nil {
+		if err := w.searchStore.SetSearchFolderSyncError(ctx, SetSearchFolderSyncErrorParams{
+			FolderID:  folderID,
+			LastError: pgtype.Text{String: err.Error(), Valid: true},
+		}); err != nil {
+			log.Printf("recording sync error for %s: %v", folderID, err)
+		}
 		return err
 	}
 
 	log.Printf("START: converting %d files for %s", len(res.Files), res.Folder.Name)
 	for _, file := range res.Files {
-		if err := w.searchIndexFile(ctx, res.Folder, file); err != nil {
+		if err := w.searchIndexFile(ctx, res.Folder, file, force); err != nil {
 			log.Printf("ERROR (%s): %s", file.Name, err.Error())
 		}
 	}
 	log.Printf("DONE: converting files for %s", res.Folder.Name)
-	return nil
+
+	return w.searchStore.SetSearchFolderSynced(ctx, SetSearchFolderSyncedParams{
+		FolderID:   folderID,
+		FolderName: res.Folder.Name,
+		NFiles:     int32(len(res.Files)),
+	})
 }
 
-func (w *GDriveWorker) searchIndexFile(ctx context.Context, folder, file GDriveItem) error {
+// Index a document, unless the search entry is already up to date and force is false.
+func (w *GDriveWorker) searchIndexFile(ctx context.Context, folder, file GDriveItem, force bool) error {
 	// Initialize to be used in the extraData field
 	journalInfo := SearchJournalInfo{
 		FolderURL:  folder.FolderURL(),
@@ -75,7 +328,7 @@ func (w *GDriveWorker) searchIndexFile(ctx context.Context, folder, file GDriveI
 	}
 
 	// Get info
-	info, err := w.getSearchIndexInfo(ctx, file, journalInfo)
+	info, err := w.getSearchIndexInfo(ctx, folder, file, journalInfo)
 	if err != nil {
 		return fmt.Errorf("fetching info for patient: %w", err)
 	}
@@ -83,7 +336,7 @@ func (w *GDriveWorker) searchIndexFile(ctx context.Context, folder, file GDriveI
 	// Delete trashed files
 	if file.Trashed {
 		if info.didSearchEntryExist() {
-			if err := w.g.Queries.DeleteSearchEntry(ctx, DeleteSearchEntryParams{
+			if err := w.searchStore.DeleteSearchEntry(ctx, DeleteSearchEntryParams{
 				Namespace:     info.namespace,
 				AssociatedUrl: info.urlField,
 			}); err != nil {
@@ -94,9 +347,9 @@ func (w *GDriveWorker) searchIndexFile(ctx context.Context, folder, file GDriveI
 	}
 
 	// If search-entry is synced, only update extra-data
-	if info.didSearchEntryExist() && !file.ModifiedTime.After(info.dbUpdatedField.Time) {
+	if !force && info.didSearchEntryExist() && !file.ModifiedTime.After(info.dbUpdatedField.Time) {
 		if info.extraDataField.Valid {
-			tag, err := w.g.Queries.UpdateSearchMetadata(ctx, UpdateSearchMetadataParams{
+			tag, err := w.searchStore.UpdateSearchMetadata(ctx, UpdateSearchMetadataParams{
 				Namespace:     info.namespace,
 				AssociatedUrl: info.urlField,
 				ExtraData:     info.extraDataField,
@@ -112,10 +365,10 @@ func (w *GDriveWorker) searchIndexFile(ctx context.Context, folder, file GDriveI
 	}
 
 	// Read the document
-	journal, err := w.g.ReadDocument(file.ID)
+	journal, err := w.client.ReadDocument(file.ID)
 	if err != nil {
 		// On failure, create a skipped entry. It won't show up in search, but we also won't try to read the document later.
-		if err := w.g.Queries.UpsertSkippedSearchEntry(ctx, UpsertSkippedSearchEntryParams{
+		if err := w.searchStore.UpsertSkippedSearchEntry(ctx, UpsertSkippedSearchEntryParams{
 			Namespace:     info.namespace,
 			AssociatedUrl: info.urlField,
 			Updated:       info.fileUpdatedField,
@@ -123,6 +376,7 @@ func (w *GDriveWorker) searchIndexFile(ctx context.Context, folder, file GDriveI
 			Header:        info.headerField,
 			Lang:          info.language,
 			ExtraData:     info.extraDataField,
+			SkipReason:    pgtype.Text{String: err.Error(), Valid: true},
 
 			Body: pgtype.Text{String: info.extraDataText, Valid: true},
 		}); err != nil {
@@ -131,8 +385,15 @@ func (w *GDriveWorker) searchIndexFile(ctx context.Context, folder, file GDriveI
 		return fmt.Errorf("%w (created skipped entry)", err)
 	}
 
+	// Without a configured language for the folder, guess it from the contents
+	if _, configured := w.Config().FolderLanguages[folder.ID]; !configured {
+		if lang, ok := DetectLanguage(journal.Content); ok {
+			info.language = lang.Regconfig()
+		}
+	}
+
 	// Create valid search entry
-	if err := w.g.Queries.UpsertSearchEntry(ctx, UpsertSearchEntryParams{
+	if err := w.searchStore.UpsertSearchEntry(ctx, UpsertSearchEntryParams{
 		Namespace:     info.namespace,
 		AssociatedUrl: info.urlField,
 		Updated:       info.fileUpdatedField,
@@ -146,6 +407,12 @@ func (w *GDriveWorker) searchIndexFile(ctx context.Context, folder, file GDriveI
 		return fmt.Errorf("creating entry: %w", err)
 	}
 
+	if w.journalCfg.ParseData && info.patientID != 0 {
+		if err := importJournalData(ctx, w.queries, info.patientID, file.DocumentURL(), journal.Content); err != nil {
+			log.Printf("%s: importing journal data: %v", file.Name, err)
+		}
+	}
+
 	return nil
 }
 
@@ -159,17 +426,19 @@ type searchIndexInfo struct {
 	createdField     pgtype.Timestamptz
 	headerField      pgtype.Text
 	language         string
+	// Set if the journal is attached to a single patient
+	patientID int32
 }
 
 func (sii *searchIndexInfo) didSearchEntryExist() bool {
 	return sii.dbUpdatedField.Valid
 }
 
-func (w *GDriveWorker) getSearchIndexInfo(ctx context.Context, file GDriveItem, journalInfo SearchJournalInfo) (searchIndexInfo, error) {
+func (w *GDriveWorker) getSearchIndexInfo(ctx context.Context, folder, file GDriveItem, journalInfo SearchJournalInfo) (searchIndexInfo, error) {
 	var out searchIndexInfo
 
 	// See if there are existing patients
-	ids, err := w.g.Queries.GetPatientsByJournalURL(ctx, file.ID)
+	ids, err := w.searchStore.GetPatientsByJournalURL(ctx, file.ID)
 	if err != nil {
 		return searchIndexInfo{}, fmt.Errorf("querying patients by journal URL: %w", err)
 	}
@@ -183,6 +452,7 @@ func (w *GDriveWorker) getSearchIndexInfo(ctx context.Context, file GDriveItem,
 	if len(ids) == 1 {
 		out.namespace = "patient"
 		out.urlField.String = PatientURL(ids[0])
+		out.patientID = ids[0]
 		extraData = &SearchPatientInfo{
 			JournalInfo: journalInfo,
 			JournalURL:  file.DocumentURL(),
@@ -198,7 +468,7 @@ func (w *GDriveWorker) getSearchIndexInfo(ctx context.Context, file GDriveItem,
 	}
 
 	// Get updated-time
-	if updatedField, err := w.g.Queries.GetSearchUpdatedTime(ctx, GetSearchUpdatedTimeParams{
+	if updatedField, err := w.searchStore.GetSearchUpdatedTime(ctx, GetSearchUpdatedTimeParams{
 		Namespace:     out.namespace,
 		AssociatedUrl: out.urlField,
 	}); err == nil {
@@ -217,7 +487,10 @@ func (w *GDriveWorker) getSearchIndexInfo(ctx context.Context, file GDriveItem,
 
 	out.fileUpdatedField = pgtype.Timestamptz{Time: file.ModifiedTime, Valid: !file.ModifiedTime.IsZero()}
 	out.headerField = pgtype.Text{String: file.Name, Valid: true}
-	out.language = "norwegian"
+	out.language = LanguageIDNO.Regconfig()
+	if lang, ok := w.Config().FolderLanguages[folder.ID]; ok {
+		out.language = lang.Regconfig()
+	}
 
 	return out, nil
 }
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// A Drive with documents and a changes feed, paged by token.
+type fakeGDriveClient struct {
+	files map[string]GDriveItem
+	// IDs of the files that are folders
+	folders map[string]bool
+	content map[string]string
+	changes map[string]GDriveChanges
+	// The start page token returned by GetStartPageToken
+	startPageToken string
+}
+
+func (c *fakeGDriveClient) GetFile(id string) (GDriveItem, error) {
+	if file, ok := c.files[id]; ok {
+		return file, nil
+	}
+	return GDriveItem{}, fmt.Errorf("file %s not found", id)
+}
+
+func (c *fakeGDriveClient) ReadDocument(id string) (GDriveJournal, error) {
+	content, ok := c.content[id]
+	if !ok {
+		return GDriveJournal{}, fmt.Errorf("document %s not found", id)
+	}
+	return GDriveJournal{Item: c.files[id], Content: content}, nil
+}
+
+func (c *fakeGDriveClient) ListFiles(params ListFilesParams) (ListFilesResult, error) {
+	folder, ok := c.files[params.Parent]
+	if !ok {
+		return ListFilesResult{}, fmt.Errorf("folder %s not found", params.Parent)
+	}
+	res := ListFilesResult{Folder: folder}
+	for _, file := range c.files {
+		if slices.Contains(file.Parents, params.Parent) && !c.folders[file.ID] {
+			res.Files = append(res.Files, file)
+		}
+	}
+	return res, nil
+}
+
+func (c *fakeGDriveClient) ListFolders(parent string) ([]GDriveItem, error) {
+	var out []GDriveItem
+	for _, file := range c.files {
+		if slices.Contains(file.Parents, parent) && c.folders[file.ID] {
+			out = append(out, file)
+		}
+	}
+	return out, nil
+}
+
+func (c *fakeGDriveClient) GetStartPageToken() (string, error) {
+	return c.startPageToken, nil
+}
+
+func (c *fakeGDriveClient) ListChanges(pageToken string) (GDriveChanges, error) {
+	changes, ok := c.changes[pageToken]
+	if !ok {
+		return GDriveChanges{}, fmt.Errorf("invalid page token %s", pageToken)
+	}
+	return changes, nil
+}
+
+func (c *fakeGDriveClient) ListRevisions(id string) ([]GDriveRevision, error) {
+	return nil, nil
+}
+
+type fakeSearchEntry struct {
+	Body    string
+	Updated time.Time
+	Skipped bool
+}
+
+// The search table, keyed by namespace and associated URL.
+type fakeSearchIndexStore struct {
+	entries map[string]fakeSearchEntry
+	// Patients by journal ID
+	patients  map[string][]int32
+	pageToken string
+}
+
+func fakeSearchKey(namespace string, url pgtype.Text) string {
+	return namespace + " " + url.String
+}
+
+func (s *fakeSearchIndexStore) GetSearchSyncState(ctx context.Context, driveID string) (SearchSyncState, error) {
+	return SearchSyncState{DriveID: driveID, PageToken: s.pageToken}, nil
+}
+
+func (s *fakeSearchIndexStore) ResetSearchSyncPageToken(ctx context.Context, driveID string) error {
+	s.pageToken = ""
+	return nil
+}
+
+func (s *fakeSearchIndexStore) SetSearchSyncFull(ctx context.Context, arg SetSearchSyncFullParams) error {
+	s.pageToken = arg.PageToken
+	return nil
+}
+
+func (s *fakeSearchIndexStore) SetSearchSyncIncremental(ctx context.Context, arg SetSearchSyncIncrementalParams) error {
+	s.pageToken = arg.PageToken
+	return nil
+}
+
+func (s *fakeSearchIndexStore) SetSearchFolderSynced(ctx context.Context, arg SetSearchFolderSyncedParams) error {
+	return nil
+}
+
+func (s *fakeSearchIndexStore) SetSearchFolderSyncError(ctx context.Context, arg SetSearchFolderSyncErrorParams) error {
+	return nil
+}
+
+func (s *fakeSearchIndexStore) GetPatientsByJournalURL(ctx context.Context, lookup string) ([]int32, error) {
+	return s.patients[lookup], nil
+}
+
+func (s *fakeSearchIndexStore) GetSearchUpdatedTime(ctx context.Context, arg GetSearchUpdatedTimeParams) (pgtype.Timestamptz, error) {
+	entry, ok := s.entries[fakeSearchKey(arg.Namespace, arg.AssociatedUrl)]
+	if !ok {
+		return pgtype.Timestamptz{}, pgx.ErrNoRows
+	}
+	return pgtype.Timestamptz{Time: entry.Updated, Valid: true}, nil
+}
+
+func (s *fakeSearchIndexStore) UpsertSearchEntry(ctx context.Context, arg UpsertSearchEntryParams) error {
+	s.entries[fakeSearchKey(arg.Namespace, arg.AssociatedUrl)] = fakeSearchEntry{
+		Body:    arg.Body.String,
+		Updated: arg.Updated.Time,
+	}
+	return nil
+}
+
+func (s *fakeSearchIndexStore) UpsertSkippedSearchEntry(ctx context.Context, arg UpsertSkippedSearchEntryParams) error {
+	s.entries[fakeSearchKey(arg.Namespace, arg.AssociatedUrl)] = fakeSearchEntry{
+		Body:    arg.Body.String,
+		Updated: arg.Updated.Time,
+		Skipped: true,
+	}
+	return nil
+}
+
+func (s *fakeSearchIndexStore) UpdateSearchMetadata(ctx context.Context, arg UpdateSearchMetadataParams) (pgconn.CommandTag, error) {
+	if _, ok := s.entries[fakeSearchKey(arg.Namespace, arg.AssociatedUrl)]; !ok {
+		return pgconn.NewCommandTag("UPDATE 0"), nil
+	}
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (s *fakeSearchIndexStore) DeleteSearchEntry(ctx context.Context, arg DeleteSearchEntryParams) error {
+	delete(s.entries, fakeSearchKey(arg.Namespace, arg.AssociatedUrl))
+	return nil
+}
+
+func newFakeSearchIndexWorker(client *fakeGDriveClient, store *fakeSearchIndexStore) *GDriveWorker {
+	return &GDriveWorker{
+		cfg: GDriveConfig{
+			FolderLanguages: map[string]LanguageID{
+				"journals": LanguageIDNO,
+			},
+		},
+		cfgMu:        &sync.RWMutex{},
+		client:       client,
+		searchStore:  store,
+		cachedInfoMu: &sync.Mutex{},
+	}
+}
+
+func TestSearchIndexChanges(t *testing.T) {
+	modified := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	folders := map[string]GDriveItem{
+		"journals": {ID: "journals", Name: "Journals", Valid: true},
+	}
+	doc := func(id string, parents ...string) GDriveItem {
+		return GDriveItem{
+			ID:           id,
+			Name:         id,
+			Valid:        true,
+			ModifiedTime: modified,
+			CreatedTime:  modified,
+			Parents:      parents,
+		}
+	}
+	journalKey := func(id string) string {
+		return fakeSearchKey("journal", pgtype.Text{String: GDriveDocumentURL(id)})
+	}
+	patientKey := func(id int32) string {
+		return fakeSearchKey("patient", pgtype.Text{String: PatientURL(id)})
+	}
+
+	for _, tc := range []struct {
+		name     string
+		existing []string
+		patients map[string][]int32
+		changes  []GDriveChange
+		// The keys of the entries after the changes
+		want []string
+	}{
+		{
+			name:    "new document in journal folder",
+			changes: []GDriveChange{{FileID: "doc1", Item: doc("doc1", "journals"), IsDocument: true}},
+			want:    []string{journalKey("doc1")},
+		},
+		{
+			name:     "new document attached to patient",
+			patients: map[string][]int32{"doc1": {7}},
+			changes:  []GDriveChange{{FileID: "doc1", Item: doc("doc1", "journals"), IsDocument: true}},
+			want:     []string{patientKey(7)},
+		},
+		{
+			name:    "document outside journal folders",
+			changes: []GDriveChange{{FileID: "doc1", Item: doc("doc1", "elsewhere"), IsDocument: true}},
+		},
+		{
+			name:    "not a document",
+			changes: []GDriveChange{{FileID: "pdf1", Item: doc("pdf1", "journals")}},
+		},
+		{
+			name:     "trashed",
+			existing: []string{journalKey("doc1"), journalKey("doc2")},
+			changes: []GDriveChange{{FileID: "doc1", IsDocument: true, Item: func() GDriveItem {
+				item := doc("doc1", "journals")
+				item.Trashed = true
+				return item
+			}()}},
+			want: []string{journalKey("doc2")},
+		},
+		{
+			name:     "removed",
+			existing: []string{journalKey("doc1"), journalKey("doc2")},
+			changes:  []GDriveChange{{FileID: "doc1", Removed: true}},
+			want:     []string{journalKey("doc2")},
+		},
+		{
+			name:     "moved out of journal folders",
+			existing: []string{journalKey("doc1"), journalKey("doc2")},
+			changes:  []GDriveChange{{FileID: "doc1", Item: doc("doc1", "elsewhere"), IsDocument: true}},
+			want:     []string{journalKey("doc2")},
+		},
+		{
+			name:     "patient journal moved out of journal folders",
+			existing: []string{patientKey(7)},
+			patients: map[string][]int32{"doc1": {7}},
+			changes:  []GDriveChange{{FileID: "doc1", Item: doc("doc1", "archive"), IsDocument: true}},
+			want:     []string{patientKey(7)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &fakeGDriveClient{
+				files:   map[string]GDriveItem{},
+				content: map[string]string{},
+				changes: map[string]GDriveChanges{
+					"1": {Changes: tc.changes, NewStartPageToken: "2"},
+				},
+			}
+			for _, change := range tc.changes {
+				client.files[change.FileID] = change.Item
+				client.content[change.FileID] = "Journal for " + change.FileID
+			}
+			store := &fakeSearchIndexStore{
+				entries:   map[string]fakeSearchEntry{},
+				patients:  tc.patients,
+				pageToken: "1",
+			}
+			for _, key := range tc.existing {
+				store.entries[key] = fakeSearchEntry{Updated: modified.Add(-time.Hour)}
+			}
+
+			w := newFakeSearchIndexWorker(client, store)
+			if err := w.searchIndexChanges(context.Background(), folders, "1"); err != nil {
+				t.Fatalf("searchIndexChanges: %v", err)
+			}
+
+			var got []string
+			for key := range store.entries {
+				got = append(got, key)
+			}
+			slices.Sort(got)
+			slices.Sort(tc.want)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("entries = %v, want %v", got, tc.want)
+			}
+			if store.pageToken != "2" {
+				t.Errorf("page token = %q, want %q", store.pageToken, "2")
+			}
+		})
+	}
+}
+
+func TestSearchIndexChangesPages(t *testing.T) {
+	folders := map[string]GDriveItem{
+		"journals": {ID: "journals", Name: "Journals", Valid: true},
+	}
+	item := func(id string) GDriveItem {
+		return GDriveItem{ID: id, Name: id, Valid: true, ModifiedTime: time.Now(), Parents: []string{"journals"}}
+	}
+	client := &fakeGDriveClient{
+		files: map[string]GDriveItem{"doc1": item("doc1"), "doc2": item("doc2")},
+		content: map[string]string{
+			"doc1": "first",
+			"doc2": "second",
+		},
+		changes: map[string]GDriveChanges{
+			"1":  {Changes: []GDriveChange{{FileID: "doc1", Item: item("doc1"), IsDocument: true}}, NextPageToken: "1b"},
+			"1b": {Changes: []GDriveChange{{FileID: "doc2", Item: item("doc2"), IsDocument: true}}, NewStartPageToken: "2"},
+		},
+	}
+	store := &fakeSearchIndexStore{entries: map[string]fakeSearchEntry{}, pageToken: "1"}
+
+	w := newFakeSearchIndexWorker(client, store)
+	if err := w.searchIndexChanges(context.Background(), folders, "1"); err != nil {
+		t.Fatalf("searchIndexChanges: %v", err)
+	}
+	if len(store.entries) != 2 {
+		t.Errorf("got %d entries, want 2", len(store.entries))
+	}
+	if store.pageToken != "2" {
+		t.Errorf("page token = %q, want %q", store.pageToken, "2")
+	}
+
+	// A page with neither token is an error, and the position isn't saved
+	client.changes["2"] = GDriveChanges{}
+	if err := w.searchIndexChanges(context.Background(), folders, "2"); err == nil {
+		t.Error("expected an error for a page without tokens")
+	}
+	if store.pageToken != "2" {
+		t.Errorf("page token = %q, want %q", store.pageToken, "2")
+	}
+}
+
+// A folder that can't be listed fails the full sync, and the position in the
+// changes feed isn't moved past the documents in it
+func TestSearchIndexFullFolderError(t *testing.T) {
+	client := &fakeGDriveClient{
+		files: map[string]GDriveItem{
+			"journals": {ID: "journals", Name: "Journals", Valid: true},
+		},
+		content:        map[string]string{},
+		startPageToken: "5",
+	}
+	store := &fakeSearchIndexStore{entries: map[string]fakeSearchEntry{}, pageToken: "1"}
+
+	w := newFakeSearchIndexWorker(client, store)
+	w.cfg.JournalFolder = "journals"
+	w.cfg.ExtraJournalFolders = []string{"missing"}
+	if err := w.searchIndexFull(context.Background(), false); err == nil {
+		t.Error("expected an error for a folder that can't be listed")
+	}
+	if store.pageToken != "1" {
+		t.Errorf("page token = %q, want %q", store.pageToken, "1")
+	}
+
+	w.cfg.ExtraJournalFolders = nil
+	if err := w.searchIndexFull(context.Background(), false); err != nil {
+		t.Fatalf("searchIndexFull: %v", err)
+	}
+	if store.pageToken != "5" {
+		t.Errorf("page token = %q, want %q", store.pageToken, "5")
+	}
+}
+
+// Journals moved to the archive at checkout are still indexed, including in
+// the year folders
+func TestSearchIndexArchive(t *testing.T) {
+	modified := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	item := func(id string, parents ...string) GDriveItem {
+		return GDriveItem{ID: id, Name: id, Valid: true, ModifiedTime: modified, Parents: parents}
+	}
+	client := &fakeGDriveClient{
+		files: map[string]GDriveItem{
+			"journals": item("journals"),
+			"archive":  item("archive"),
+			"2025":     item("2025", "archive"),
+			"doc1":     item("doc1", "journals"),
+			"doc2":     item("doc2", "archive"),
+			"doc3":     item("doc3", "2025"),
+		},
+		folders: map[string]bool{"journals": true, "archive": true, "2025": true},
+		content: map[string]string{
+			"doc1": "first",
+			"doc2": "second",
+			"doc3": "third",
+		},
+		changes:        map[string]GDriveChanges{},
+		startPageToken: "5",
+	}
+	store := &fakeSearchIndexStore{
+		entries:  map[string]fakeSearchEntry{},
+		patients: map[string][]int32{"doc1": {7}},
+	}
+	patientKey := fakeSearchKey("patient", pgtype.Text{String: PatientURL(7)})
+
+	w := newFakeSearchIndexWorker(client, store)
+	w.cfg.JournalFolder = "journals"
+	w.cfg.ArchiveFolder = "archive"
+	w.cfg.ArchivePerYear = true
+	if err := w.searchIndexFull(context.Background(), false); err != nil {
+		t.Fatalf("searchIndexFull: %v", err)
+	}
+	for _, key := range []string{
+		patientKey,
+		fakeSearchKey("journal", pgtype.Text{String: GDriveDocumentURL("doc2")}),
+		fakeSearchKey("journal", pgtype.Text{String: GDriveDocumentURL("doc3")}),
+	} {
+		if _, ok := store.entries[key]; !ok {
+			t.Errorf("missing entry %s", key)
+		}
+	}
+
+	// An edit after the journal was archived updates the entry of the patient
+	client.content["doc1"] = "edited after checkout"
+	folders := map[string]GDriveItem{"journals": client.files["journals"]}
+	archive, err := w.searchArchiveFolders()
+	if err != nil {
+		t.Fatalf("searchArchiveFolders: %v", err)
+	}
+	for _, folder := range archive {
+		folders[folder.ID] = folder
+	}
+	doc1 := client.files["doc1"]
+	doc1.ModifiedTime = modified.Add(time.Hour)
+	doc1.Parents = []string{"2025"}
+	client.files["doc1"] = doc1
+	client.changes["5"] = GDriveChanges{Changes: []GDriveChange{{FileID: "doc1", Item: doc1, IsDocument: true}}, NewStartPageToken: "6"}
+	if err := w.searchIndexChanges(context.Background(), folders, "5"); err != nil {
+		t.Fatalf("searchIndexChanges: %v", err)
+	}
+	if got := store.entries[patientKey].Body; !strings.Contains(got, "edited after checkout") {
+		t.Errorf("patient entry body = %q, want the edited journal", got)
+	}
+}
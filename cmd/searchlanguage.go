@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Common function words that rarely appear in the other language. Used to
+// guess the language of a document when the folder doesn't specify one.
+var searchLanguageStopwords = map[LanguageID][]string{
+	LanguageIDNO: {
+		"og", "ikke", "det", "er", "en", "et", "som", "på", "med", "av",
+		"til", "har", "jeg", "vi", "fra", "ble", "skal", "kan", "hun", "han",
+		"etter", "også", "dag", "ut", "får", "veldig", "spiser", "fikk",
+	},
+	LanguageIDEN: {
+		"and", "not", "the", "is", "a", "an", "that", "on", "with", "of",
+		"to", "has", "we", "from", "was", "will", "can", "she", "he",
+		"after", "also", "day", "out", "gets", "very", "eats", "got",
+	},
+}
+
+// DetectLanguage guesses the language of a text by counting stopwords. The
+// second return value is false if the text doesn't clearly favor a language.
+func DetectLanguage(text string) (LanguageID, bool) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	lookup := map[string][]LanguageID{}
+	for id, stopwords := range searchLanguageStopwords {
+		for _, w := range stopwords {
+			lookup[w] = append(lookup[w], id)
+		}
+	}
+
+	counts := map[LanguageID]int{}
+	for _, w := range words {
+		for _, id := range lookup[w] {
+			counts[id]++
+		}
+	}
+
+	best, bestCount, total := LanguageID(0), 0, 0
+	for id, n := range counts {
+		total += n
+		if n > bestCount {
+			best, bestCount = id, n
+		}
+	}
+	// Require a clear majority and a minimum amount of evidence
+	if bestCount < 5 || bestCount*3 < total*2 {
+		return 0, false
+	}
+	return best, true
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Qualifiers that can be written in the search box, e.g. "species:kråke".
+// Each qualifier has a canonical name followed by aliases.
+var searchQualifiers = [][]string{
+	{"species", "art"},
+	{"home", "hjem"},
+	{"status"},
+	{"ns", "type"},
+	{"after", "etter"},
+	{"before", "før"},
+}
+
+func canonicalSearchQualifier(name string) (string, bool) {
+	name = strings.ToLower(name)
+	for _, names := range searchQualifiers {
+		if slices.Contains(names, name) {
+			return names[0], true
+		}
+	}
+	return "", false
+}
+
+func searchQualifierNames() []string {
+	return SliceToSlice(searchQualifiers, func(names []string) string { return names[0] })
+}
+
+type searchToken struct {
+	Qualifier string
+	Value     string
+	Raw       string
+}
+
+// Split a query into whitespace-separated tokens, keeping quoted phrases together.
+func tokenizeSearchQuery(query string) []searchToken {
+	var tokens []searchToken
+	var b strings.Builder
+	inQuote := false
+	flush := func() {
+		if b.Len() > 0 {
+			tokens = append(tokens, newSearchToken(b.String()))
+			b.Reset()
+		}
+	}
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			b.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuote:
+			flush()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+func newSearchToken(raw string) searchToken {
+	key, value, found := strings.Cut(raw, ":")
+	if !found || key == "" || value == "" || strings.HasPrefix(value, "//") {
+		return searchToken{Raw: raw}
+	}
+	for _, r := range key {
+		if !unicode.IsLetter(r) {
+			return searchToken{Raw: raw}
+		}
+	}
+	return searchToken{
+		Qualifier: strings.ToLower(key),
+		Value:     strings.Trim(value, `"`),
+		Raw:       raw,
+	}
+}
+
+// Pick the candidates whose label equals the value, or failing that, starts with it.
+func matchSearchQualifierValue[T any](value string, candidates []T, labels func(T) []string) []T {
+	value = strings.ToLower(value)
+	var exact, prefix []T
+	for _, c := range candidates {
+		for _, label := range labels(c) {
+			label = strings.ToLower(strings.TrimLeftFunc(label, func(r rune) bool { return !unicode.IsLetter(r) }))
+			if label == value {
+				exact = append(exact, c)
+				break
+			}
+			if strings.HasPrefix(label, value) {
+				prefix = append(prefix, c)
+				break
+			}
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return prefix
+}
+
+// Extract qualifiers from the query into filters on q, and set q.Text to the
+// remaining free text. Returns messages about qualifiers that could not be used.
+func (server *Server) applySearchQualifiers(ctx context.Context, q *SearchQuery) []string {
+	commonData := MustLoadCommonData(ctx)
+	lang := commonData.User.Language
+
+	var notices []string
+	var text []string
+	for _, tok := range tokenizeSearchQuery(q.Query) {
+		if tok.Qualifier == "" {
+			text = append(text, tok.Raw)
+			continue
+		}
+		qualifier, ok := canonicalSearchQualifier(tok.Qualifier)
+		if !ok {
+			notices = append(notices, lang.SearchUnknownQualifier(tok.Qualifier, searchQualifierNames()))
+			text = append(text, tok.Raw)
+			continue
+		}
+
+		found := true
+		switch qualifier {
+		case "species":
+			species, err := server.Queries.GetSpeciesWithLanguage(ctx, commonData.Lang32())
+			if err != nil {
+				LogCtx(ctx, "loading species for search qualifier: %v", err)
+			}
+			matches := matchSearchQualifierValue(tok.Value, species, func(sp GetSpeciesWithLanguageRow) []string {
+				return []string{sp.Name}
+			})
+			q.SpeciesIDs = append(q.SpeciesIDs, SliceToSlice(matches, func(sp GetSpeciesWithLanguageRow) int32 { return sp.SpeciesID })...)
+			found = len(matches) > 0
+		case "home":
+			homes, err := server.Queries.GetHomes(ctx)
+			if err != nil {
+				LogCtx(ctx, "loading homes for search qualifier: %v", err)
+			}
+			matches := matchSearchQualifierValue(tok.Value, homes, func(h Home) []string {
+				return []string{h.Name}
+			})
+			q.HomeIDs = append(q.HomeIDs, SliceToSlice(matches, func(h Home) int32 { return h.ID })...)
+			found = len(matches) > 0
+		case "status":
+			matches := matchSearchQualifierValue(tok.Value, StatusValues(), func(s Status) []string {
+				return []string{s.String(), NO.Status[s], EN.Status[s]}
+			})
+			q.Statuses = append(q.Statuses, matches...)
+			found = len(matches) > 0
+		case "ns":
+			matches := matchSearchQualifierValue(tok.Value, MatchTypeValues(), func(mt MatchType) []string {
+				return []string{mt.String(), NO.MatchType[mt], EN.MatchType[mt]}
+			})
+			q.Namespaces = append(q.Namespaces, matches...)
+			found = len(matches) > 0
+		case "after", "before":
+			t, err := time.Parse(time.DateOnly, tok.Value)
+			if err != nil {
+				notices = append(notices, lang.SearchQualifierInvalidDate(tok.Qualifier, tok.Value))
+				continue
+			}
+			if qualifier == "after" {
+				q.MinCreated = t.Unix()
+			} else {
+				q.MaxCreated = t.Unix()
+			}
+		}
+		if !found {
+			notices = append(notices, lang.SearchQualifierNoMatch(tok.Qualifier, tok.Value))
+		}
+		q.Qualified = true
+	}
+	q.Text = strings.Join(text, " ")
+	return notices
+}
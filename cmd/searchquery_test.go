@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTokenizeSearchQuery(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []searchToken
+	}{
+		{in: "", want: nil},
+		{in: "  kråke  ", want: []searchToken{{Raw: "kråke"}}},
+		{in: "skadet kråke", want: []searchToken{{Raw: "skadet"}, {Raw: "kråke"}}},
+		{in: `"skadet vinge" kråke`, want: []searchToken{{Raw: `"skadet vinge"`}, {Raw: "kråke"}}},
+		{in: "species:kråke", want: []searchToken{{Qualifier: "species", Value: "kråke", Raw: "species:kråke"}}},
+		{in: "Art:Kråke", want: []searchToken{{Qualifier: "art", Value: "Kråke", Raw: "Art:Kråke"}}},
+		{in: `hjem:"Ola Nordmann" vinge`, want: []searchToken{
+			{Qualifier: "hjem", Value: "Ola Nordmann", Raw: `hjem:"Ola Nordmann"`},
+			{Raw: "vinge"},
+		}},
+		{in: "før:2025-03-14", want: []searchToken{{Qualifier: "før", Value: "2025-03-14", Raw: "før:2025-03-14"}}},
+		{in: "https://example.com", want: []searchToken{{Raw: "https://example.com"}}},
+		{in: "kl:", want: []searchToken{{Raw: "kl:"}}},
+		{in: ":kråke", want: []searchToken{{Raw: ":kråke"}}},
+		{in: "12:30", want: []searchToken{{Raw: "12:30"}}},
+		{in: `"species:kråke"`, want: []searchToken{{Raw: `"species:kråke"`}}},
+	} {
+		got := tokenizeSearchQuery(tc.in)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("tokenizeSearchQuery(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestCanonicalSearchQualifier(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{in: "species", want: "species", ok: true},
+		{in: "art", want: "species", ok: true},
+		{in: "ART", want: "species", ok: true},
+		{in: "hjem", want: "home", ok: true},
+		{in: "type", want: "ns", ok: true},
+		{in: "etter", want: "after", ok: true},
+		{in: "før", want: "before", ok: true},
+		{in: "FØR", want: "before", ok: true},
+		{in: "farge", ok: false},
+	} {
+		got, ok := canonicalSearchQualifier(tc.in)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("canonicalSearchQualifier(%q) = %q, %v, want %q, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+// Only qualifiers that don't look anything up in the database are covered.
+func TestApplySearchQualifiers(t *testing.T) {
+	date := func(year int, month time.Month, day int) int64 {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix()
+	}
+	for _, tc := range []struct {
+		in      string
+		want    SearchQuery
+		notices []string
+	}{
+		{
+			in:   "skadet kråke",
+			want: SearchQuery{Text: "skadet kråke"},
+		},
+		{
+			in:   `etter:2025-03-01 før:"2025-03-14" vinge`,
+			want: SearchQuery{Text: "vinge", MinCreated: date(2025, 3, 1), MaxCreated: date(2025, 3, 14), Qualified: true},
+		},
+		{
+			in:   "type:fil status:released",
+			want: SearchQuery{Namespaces: []MatchType{MatchTypeFile}, Statuses: []Status{StatusReleased}, Qualified: true},
+		},
+		{
+			in:   "TYPE:journal status:sluppet",
+			want: SearchQuery{Namespaces: []MatchType{MatchTypeJournal}, Statuses: []Status{StatusReleased}, Qualified: true},
+		},
+		{
+			in:      "after:14.03.2025 vinge",
+			want:    SearchQuery{Text: "vinge"},
+			notices: []string{EN.SearchQualifierInvalidDate("after", "14.03.2025")},
+		},
+		{
+			in:      "farge:svart kråke",
+			want:    SearchQuery{Text: "farge:svart kråke"},
+			notices: []string{EN.SearchUnknownQualifier("farge", searchQualifierNames())},
+		},
+		{
+			in:      "status:flyvende",
+			want:    SearchQuery{Qualified: true},
+			notices: []string{EN.SearchQualifierNoMatch("status", "flyvende")},
+		},
+	} {
+		ctx := WithCommonData(context.Background(), &CommonData{User: UserData{Language: EN}})
+		server := &Server{}
+		q := SearchQuery{Query: tc.in}
+		notices := server.applySearchQualifiers(ctx, &q)
+
+		tc.want.Query = tc.in
+		if q.Text != tc.want.Text ||
+			q.MinCreated != tc.want.MinCreated ||
+			q.MaxCreated != tc.want.MaxCreated ||
+			q.Qualified != tc.want.Qualified ||
+			!slices.Equal(q.Namespaces, tc.want.Namespaces) ||
+			!slices.Equal(q.Statuses, tc.want.Statuses) {
+			t.Errorf("applySearchQualifiers(%q) gave %+v, want %+v", tc.in, q, tc.want)
+		}
+		if !slices.Equal(notices, tc.notices) {
+			t.Errorf("applySearchQualifiers(%q) gave notices %q, want %q", tc.in, notices, tc.notices)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"slices"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/coreos/go-oidc"
@@ -33,10 +34,28 @@ type Server struct {
 	OAuthConfig   *oauth2.Config
 	TokenVerifier *oidc.IDTokenVerifier
 	GDriveWorker  *GDriveWorker
+	Journals      JournalBackend
 	FileBackend   FileBackend
 	Runtime       RuntimeInfo
 	BuildKey      string
-	Config        Config
+
+	// config.json, before the settings changed from the admin pages are applied
+	baseConfig Config
+	config     Config
+	configMu   *sync.RWMutex
+	// Held while settings are saved, from reading the config until the new
+	// one is applied, so that concurrent saves don't undo each other
+	settingsMu *sync.Mutex
+	// Held while uploaded files are indexed, so that a reindex requested from
+	// the admin page doesn't run alongside another
+	indexFilesMu *sync.Mutex
+}
+
+// The current config, including settings changed from the admin pages.
+func (server *Server) Config() Config {
+	server.configMu.RLock()
+	defer server.configMu.RUnlock()
+	return server.config
 }
 
 type AuthConfig struct {
@@ -90,7 +109,7 @@ func getStatusCode(err error) int {
 	return http.StatusInternalServerError
 }
 
-func startServer(ctx context.Context, conn *pgxpool.Pool, queries *Queries, gdriveWorker *GDriveWorker, config Config, buildKey string) error {
+func startServer(ctx context.Context, conn *pgxpool.Pool, queries *Queries, gdriveWorker *GDriveWorker, journals JournalBackend, files FileBackend, baseConfig, config Config, buildKey string) error {
 	sessionKey, err := os.ReadFile(config.Auth.SessionKeyLocation)
 	if err != nil {
 		return err
@@ -122,6 +141,7 @@ func startServer(ctx context.Context, conn *pgxpool.Pool, queries *Queries, gdri
 		Queries:      queries,
 		Cookies:      cookies,
 		GDriveWorker: gdriveWorker,
+		Journals:     journals,
 		OAuthConfig: &oauth2.Config{
 			ClientID:     c.Web.ClientID,
 			ClientSecret: c.Web.ClientSecret,
@@ -136,9 +156,13 @@ func startServer(ctx context.Context, conn *pgxpool.Pool, queries *Queries, gdri
 			PublicIP:    fetchPublicIP(),
 			TimeStarted: time.Now(),
 		},
-		FileBackend: NewLocalFileStorage(ctx, "file", "tmp"),
-		BuildKey:    buildKey,
-		Config:      config,
+		FileBackend:  files,
+		BuildKey:     buildKey,
+		baseConfig:   baseConfig,
+		config:       config,
+		configMu:     &sync.RWMutex{},
+		settingsMu:   &sync.Mutex{},
+		indexFilesMu: &sync.Mutex{},
 	}
 
 	mux := http.NewServeMux()
@@ -190,16 +214,24 @@ func startServer(ctx context.Context, conn *pgxpool.Pool, queries *Queries, gdri
 	mux.Handle("GET /import", loggedInHandler(server.getImportHandler, CapUseImportTool))
 	mux.Handle("GET /search", loggedInHandler(server.searchHandler, CapSearch))
 	mux.Handle("GET /search/live", loggedInHandler(server.searchLiveHandler, CapSearch))
+	mux.Handle("GET /search/json", loggedInHandler(server.searchAPIHandler, CapSearch))
+	mux.Handle("GET /notifications", loggedInHandler(server.getNotificationsHandler, CapSearch))
 	mux.Handle("GET /file", loggedInHandler(server.filePage, CapUploadFile))
 	mux.Handle("GET /editor", loggedInHandler(server.editor, CapEditWiki))
+	mux.Handle("GET /journals", loggedInHandler(server.getJournalsHandler, CapCreatePatientJournal))
+	mux.Handle("GET /journal/{journal}", loggedInHandler(server.getJournalHandler, CapCreatePatientJournal))
 	// Forms
 	mux.Handle("POST /checkin", loggedInHandler(server.postCheckinHandler, CapCheckInPatient))
 	mux.Handle("POST /privacy", loggedInHandler(server.postPrivacyHandler, CapSetOwnPreferences))
 	mux.Handle("POST /patient/{patient}/move", loggedInHandler(server.movePatientHandler, CapManageOwnPatients))
+	mux.Handle("POST /patient/{patient}/readmit", loggedInHandler(server.postReadmitHandler, CapManageOwnPatients))
 	mux.Handle("POST /patient/{patient}/checkout", loggedInHandler(server.postCheckoutHandler, CapManageOwnPatients))
 	mux.Handle("POST /patient/{patient}/set-name", loggedInHandler(server.postSetNameHandler, CapManageOwnPatients))
 	mux.Handle("POST /patient/{patient}/create-journal", loggedInHandler(server.createJournalHandler, CapCreatePatientJournal))
 	mux.Handle("POST /patient/{patient}/attach-journal", loggedInHandler(server.attachJournalHandler, CapManageOwnPatients))
+	mux.Handle("GET /patient/{patient}/check-journal", loggedInHandler(server.ajaxCheckJournalHandler, CapManageOwnPatients))
+	mux.Handle("GET /patient/{patient}/journal-history", loggedInHandler(server.ajaxJournalHistoryHandler, CapViewAllActivePatients))
+	mux.Handle("POST /journal/{journal}", loggedInHandler(server.postJournalHandler, CapCreatePatientJournal))
 	mux.Handle("POST /event/{event}/set-note", loggedInHandler(server.postEventSetNoteHandler, CapManageOwnPatients))
 	mux.Handle("POST /home/{home}/set-capacity", loggedInHandler(server.setCapacityHandler, CapManageOwnHomes))
 	mux.Handle("POST /home/{home}/add-unavailable", loggedInHandler(server.addHomeUnavailablePeriodHandler, CapManageOwnHomes))
@@ -216,12 +248,17 @@ func startServer(ctx context.Context, conn *pgxpool.Pool, queries *Queries, gdri
 	mux.Handle("GET /calendar/away", loggedInHandler(server.ajaxCalendarAwayHandler, CapViewCalendar))
 	mux.Handle("GET /calendar/patientevents", loggedInHandler(server.ajaxCalendarPatientEventsHandler, CapViewCalendar))
 	mux.Handle("GET /import/validation", loggedInHandler(server.ajaxImportValidateHandler, CapViewCalendar))
+	mux.Handle("GET /import/progress", loggedInHandler(server.ajaxImportProgressHandler, CapUseImportTool))
 	// Filepond
 	mux.Handle("POST /file/filepond", loggedInHandler(server.filepondProcess, CapUploadFile))
 	mux.Handle("DELETE /file/filepond", loggedInHandler(server.imageFilepondRevert, CapUploadFile))
 	mux.Handle("GET /file/filepond/{id}", loggedInHandler(server.imageFilepondRestore, CapUploadFile))
 	mux.Handle("POST /file/submit", loggedInHandler(server.filepondSubmit, CapUploadFile))
 	mux.Handle("POST /file/{id}/delete", loggedInHandler(server.fileDelete, CapUploadFile))
+	mux.Handle("POST /saved-search", loggedInHandler(server.postSavedSearchHandler, CapSearch))
+	mux.Handle("POST /saved-search/{search}/alert", loggedInHandler(server.postSavedSearchAlertHandler, CapSearch))
+	mux.Handle("POST /saved-search/{search}/delete", loggedInHandler(server.postSavedSearchDeleteHandler, CapSearch))
+	mux.Handle("POST /notifications/clear", loggedInHandler(server.postNotificationsClearHandler, CapSearch))
 
 	//// CONTENT MANAGEMENT
 	// Pages
@@ -235,26 +272,55 @@ func startServer(ctx context.Context, conn *pgxpool.Pool, queries *Queries, gdri
 	// Ajax
 	mux.Handle("POST /species", loggedInHandler(server.postSpeciesHandler, CapManageSpecies))
 	mux.Handle("PUT /species", loggedInHandler(server.putSpeciesHandler, CapManageSpecies))
+	mux.Handle("POST /species/{species}/journal-template", loggedInHandler(server.postSpeciesJournalTemplateHandler, CapManageJournalTemplates))
+	mux.Handle("GET /journal-templates", loggedInHandler(server.getJournalTemplatesHandler, CapManageJournalTemplates))
+	mux.Handle("POST /journal-templates", loggedInHandler(server.postJournalTemplateHandler, CapManageJournalTemplates))
+	mux.Handle("POST /journal-templates/{template}/validate", loggedInHandler(server.postJournalTemplateValidateHandler, CapManageJournalTemplates))
+	mux.Handle("POST /journal-templates/{template}/delete", loggedInHandler(server.postJournalTemplateDeleteHandler, CapManageJournalTemplates))
+	mux.Handle("GET /orphan-journals", loggedInHandler(server.getOrphanJournalsHandler, CapManageOrphanJournals))
+	mux.Handle("POST /orphan-journals/create-patient", loggedInHandler(server.postOrphanJournalCreatePatientHandler, CapManageOrphanJournals))
+	mux.Handle("POST /orphan-journals/attach", loggedInHandler(server.postOrphanJournalAttachHandler, CapManageOrphanJournals))
+	mux.Handle("GET /journal-import/review", loggedInHandler(server.getJournalImportReviewHandler, CapManageAllPatients))
+	mux.Handle("POST /journal-import/review/{review}/accept", loggedInHandler(server.postJournalImportReviewAcceptHandler, CapManageAllPatients))
+	mux.Handle("POST /journal-import/review/{review}/reject", loggedInHandler(server.postJournalImportReviewRejectHandler, CapManageAllPatients))
 
 	//// ADMIN
 	// Pages
 	mux.Handle("GET /gdrive", loggedInHandler(server.getGDriveHandler, CapViewGDriveSettings))
+	mux.Handle("POST /gdrive/recheck", loggedInHandler(server.postGDriveRecheckHandler, CapManageGDriveSettings))
+	mux.Handle("GET /gdrive/tasks", loggedInHandler(server.getGDriveTasksHandler, CapViewGDriveSettings))
 	mux.Handle("GET /user/{user}/confirm-scrub", loggedInHandler(server.userConfirmScrubHandler, CapDeleteUsers))
 	mux.Handle("GET /user/{user}/confirm-nuke", loggedInHandler(server.userConfirmNukeHandler, CapDeleteUsers))
 	mux.Handle("GET /debug", loggedInHandler(server.debugHandler, CapDebug))
+	mux.Handle("GET /search-index", loggedInHandler(server.getSearchIndexHandler, CapManageSearchIndex))
+	mux.Handle("GET /admin/settings", loggedInHandler(server.getSettingsHandler, CapManageSettings))
 	// Forms
 	mux.Handle("POST /user/{user}/scrub", loggedInHandler(server.userDoScrubHandler, CapDeleteUsers))
 	mux.Handle("POST /user/{user}/nuke", loggedInHandler(server.userDoNukeHandler, CapDeleteUsers))
 	mux.Handle("POST /gdrive/invite/{email}", loggedInHandler(server.gdriveInviteUserHandler, CapInviteToGDrive))
+	mux.Handle("GET /gdrive/permissions", loggedInHandler(server.getGDrivePermissionsHandler, CapInviteToGDrive))
+	mux.Handle("POST /gdrive/permissions/apply", loggedInHandler(server.postGDrivePermissionsApplyHandler, CapInviteToGDrive))
+	mux.Handle("POST /gdrive/settings", loggedInHandler(server.postGDriveSettingsHandler, CapManageGDriveSettings))
+	mux.Handle("POST /admin/settings", loggedInHandler(server.postSettingsHandler, CapManageSettings))
+	mux.Handle("POST /gdrive/tasks/{task}/retry", loggedInHandler(server.postGDriveTaskRetryHandler, CapManageGDriveSettings))
 	mux.Handle("POST /invite", loggedInHandler(server.inviteHandler, CapInviteToBino))
 	mux.Handle("POST /invite/{email}", loggedInHandler(server.inviteHandler, CapInviteToBino))
 	mux.Handle("POST /invite/{id}/delete", loggedInHandler(server.inviteDeleteHandler, CapInviteToBino))
+	mux.Handle("POST /search-index/reindex", loggedInHandler(server.postSearchReindexHandler, CapManageSearchIndex))
+	mux.Handle("POST /search-index/reindex/folder/{folder}", loggedInHandler(server.postSearchReindexFolderHandler, CapManageSearchIndex))
+	mux.Handle("POST /search-index/reindex/document", loggedInHandler(server.postSearchReindexDocumentHandler, CapManageSearchIndex))
 
 	//// FALLBACK
 	// Pages
 	mux.Handle("GET /", chainf(server.fourOhFourHandler, requiresLogin...))  // TODO: should be public
 	mux.Handle("POST /", chainf(server.fourOhFourHandler, requiresLogin...)) // TODO: should be public
 
+	go server.backgroundIndexFiles(ctx)
+	go server.backgroundSearchAlerts(ctx)
+	if markdown, ok := journals.(*MarkdownJournalBackend); ok {
+		go server.backgroundIndexJournals(ctx, markdown)
+	}
+
 	go func() {
 		handler := chain(mux, withRecover)
 		srv := &http.Server{
@@ -330,6 +396,11 @@ func (server *Server) getFormMultiValue(r *http.Request, field string) ([]string
 	return values, nil
 }
 
+func (server *Server) getOptionalFormMultiValue(r *http.Request, field string) []string {
+	values, _ := server.getFormMultiValue(r, field)
+	return values
+}
+
 func (server *Server) getFormValue(r *http.Request, field string) (string, error) {
 	values, err := server.getFormMultiValue(r, field)
 	if err != nil {
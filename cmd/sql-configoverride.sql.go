@@ -0,0 +1,86 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: sql-configoverride.sql
+
+package main
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteConfigOverride = `-- name: DeleteConfigOverride :exec
+DELETE FROM config_override
+WHERE key = $1
+`
+
+func (q *Queries) DeleteConfigOverride(ctx context.Context, key string) error {
+	_, err := q.db.Exec(ctx, deleteConfigOverride, key)
+	return err
+}
+
+const getConfigOverrides = `-- name: GetConfigOverrides :many
+SELECT
+  rs.key, rs.value, rs.updated, rs.updated_by,
+  au.display_name AS updated_by_name
+FROM config_override AS rs
+LEFT JOIN appuser AS au
+  ON au.id = rs.updated_by
+ORDER BY rs.key
+`
+
+type GetConfigOverridesRow struct {
+	Key           string
+	Value         string
+	Updated       pgtype.Timestamptz
+	UpdatedBy     pgtype.Int4
+	UpdatedByName pgtype.Text
+}
+
+func (q *Queries) GetConfigOverrides(ctx context.Context) ([]GetConfigOverridesRow, error) {
+	rows, err := q.db.Query(ctx, getConfigOverrides)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetConfigOverridesRow
+	for rows.Next() {
+		var i GetConfigOverridesRow
+		if err := rows.Scan(
+			&i.Key,
+			&i.Value,
+			&i.Updated,
+			&i.UpdatedBy,
+			&i.UpdatedByName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setConfigOverride = `-- name: SetConfigOverride :exec
+INSERT INTO config_override (key, value, updated_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (key) DO UPDATE
+SET value = EXCLUDED.value,
+    updated = NOW(),
+    updated_by = EXCLUDED.updated_by
+`
+
+type SetConfigOverrideParams struct {
+	Key       string
+	Value     string
+	UpdatedBy pgtype.Int4
+}
+
+func (q *Queries) SetConfigOverride(ctx context.Context, arg SetConfigOverrideParams) error {
+	_, err := q.db.Exec(ctx, setConfigOverride, arg.Key, arg.Value, arg.UpdatedBy)
+	return err
+}
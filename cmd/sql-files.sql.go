@@ -11,6 +11,54 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addPatientJournalArchive = `-- name: AddPatientJournalArchive :one
+WITH f AS (
+  INSERT
+  INTO file
+    (uuid, accessibility, creator, created, filename, mimetype, size)
+  VALUES
+    ($4, $5, $6, $7, $8, $9, $10)
+  RETURNING id
+)
+INSERT
+INTO patient_journal_archive
+  (patient_id, file_id, journal_url, home_id)
+SELECT $1, f.id, $2, $3
+FROM f
+RETURNING file_id
+`
+
+type AddPatientJournalArchiveParams struct {
+	PatientID     int32
+	JournalUrl    string
+	HomeID        pgtype.Int4
+	Uuid          string
+	Accessibility int32
+	Creator       int32
+	Created       pgtype.Timestamptz
+	Filename      string
+	Mimetype      string
+	Size          int64
+}
+
+func (q *Queries) AddPatientJournalArchive(ctx context.Context, arg AddPatientJournalArchiveParams) (int32, error) {
+	row := q.db.QueryRow(ctx, addPatientJournalArchive,
+		arg.PatientID,
+		arg.JournalUrl,
+		arg.HomeID,
+		arg.Uuid,
+		arg.Accessibility,
+		arg.Creator,
+		arg.Created,
+		arg.Filename,
+		arg.Mimetype,
+		arg.Size,
+	)
+	var file_id int32
+	err := row.Scan(&file_id)
+	return file_id, err
+}
+
 const deregisterFile = `-- name: DeregisterFile :exec
 DELETE
 FROM file
@@ -87,6 +135,77 @@ func (q *Queries) GetFilesForUser(ctx context.Context, arg GetFilesForUserParams
 	return items, nil
 }
 
+const getPatientJournalArchiveByFile = `-- name: GetPatientJournalArchiveByFile :one
+SELECT id, patient_id, file_id, journal_url, home_id, created
+FROM patient_journal_archive
+WHERE file_id = $1
+`
+
+func (q *Queries) GetPatientJournalArchiveByFile(ctx context.Context, fileID int32) (PatientJournalArchive, error) {
+	row := q.db.QueryRow(ctx, getPatientJournalArchiveByFile, fileID)
+	var i PatientJournalArchive
+	err := row.Scan(
+		&i.ID,
+		&i.PatientID,
+		&i.FileID,
+		&i.JournalUrl,
+		&i.HomeID,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getPatientJournalArchives = `-- name: GetPatientJournalArchives :many
+SELECT pja.home_id, f.id, f.uuid, f.creator, f.created, f.accessibility, f.filename, f.mimetype, f.size
+FROM patient_journal_archive AS pja
+JOIN file AS f
+  ON f.id = pja.file_id
+WHERE pja.patient_id = $1
+ORDER BY f.created DESC
+`
+
+type GetPatientJournalArchivesRow struct {
+	HomeID        pgtype.Int4
+	ID            int32
+	Uuid          string
+	Creator       int32
+	Created       pgtype.Timestamptz
+	Accessibility int32
+	Filename      string
+	Mimetype      string
+	Size          int64
+}
+
+func (q *Queries) GetPatientJournalArchives(ctx context.Context, patientID int32) ([]GetPatientJournalArchivesRow, error) {
+	rows, err := q.db.Query(ctx, getPatientJournalArchives, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPatientJournalArchivesRow
+	for rows.Next() {
+		var i GetPatientJournalArchivesRow
+		if err := rows.Scan(
+			&i.HomeID,
+			&i.ID,
+			&i.Uuid,
+			&i.Creator,
+			&i.Created,
+			&i.Accessibility,
+			&i.Filename,
+			&i.Mimetype,
+			&i.Size,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const registerFile = `-- name: RegisterFile :one
 INSERT
 INTO file
@@ -0,0 +1,455 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: sql-gdrivetask.sql
+
+package main
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const addImportBatch = `-- name: AddImportBatch :one
+INSERT INTO import_batch (appuser_id)
+VALUES ($1)
+RETURNING id
+`
+
+func (q *Queries) AddImportBatch(ctx context.Context, appuserID int32) (int32, error) {
+	row := q.db.QueryRow(ctx, addImportBatch, appuserID)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const addImportBatchPatients = `-- name: AddImportBatchPatients :exec
+INSERT INTO import_batch_patient (batch_id, patient_id)
+SELECT $1::INT, UNNEST($2::INT[])
+`
+
+type AddImportBatchPatientsParams struct {
+	BatchID    int32
+	PatientIds []int32
+}
+
+func (q *Queries) AddImportBatchPatients(ctx context.Context, arg AddImportBatchPatientsParams) error {
+	_, err := q.db.Exec(ctx, addImportBatchPatients, arg.BatchID, arg.PatientIds)
+	return err
+}
+
+const claimGDriveTask = `-- name: ClaimGDriveTask :one
+UPDATE gdrive_task
+SET status   = 1,
+    attempts = attempts + 1,
+    updated  = NOW()
+WHERE id = (
+    SELECT id
+    FROM gdrive_task
+    WHERE status = 0
+      AND next_attempt <= NOW()
+    ORDER BY next_attempt, id
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, type, payload, idempotency_key, status, attempts, next_attempt, last_error, result, patient_id, appuser_id, created, updated, rerun
+`
+
+func (q *Queries) ClaimGDriveTask(ctx context.Context) (GdriveTask, error) {
+	row := q.db.QueryRow(ctx, claimGDriveTask)
+	var i GdriveTask
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.IdempotencyKey,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttempt,
+		&i.LastError,
+		&i.Result,
+		&i.PatientID,
+		&i.AppuserID,
+		&i.Created,
+		&i.Updated,
+		&i.Rerun,
+	)
+	return i, err
+}
+
+const deleteOldImportBatches = `-- name: DeleteOldImportBatches :exec
+DELETE FROM import_batch
+WHERE created < NOW() - INTERVAL '1 day'
+`
+
+// Batches are only shown while the import-request cookie lives, which is an
+// hour
+func (q *Queries) DeleteOldImportBatches(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteOldImportBatches)
+	return err
+}
+
+const enqueueGDriveTask = `-- name: EnqueueGDriveTask :one
+INSERT INTO gdrive_task (type, payload, idempotency_key, patient_id, appuser_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (idempotency_key) DO UPDATE SET
+    type         = EXCLUDED.type,
+    payload      = EXCLUDED.payload,
+    patient_id   = EXCLUDED.patient_id,
+    appuser_id   = EXCLUDED.appuser_id,
+    -- A running task keeps running, and runs again when it's finished
+    rerun        = gdrive_task.status = 1,
+    status       = CASE WHEN gdrive_task.status = 1 THEN 1 ELSE 0 END,
+    attempts     = 0,
+    next_attempt = NOW(),
+    last_error   = NULL,
+    result       = NULL,
+    created      = NOW(),
+    updated      = NOW()
+  -- Finished tasks can be run again, and running tasks if asked to. Pending
+  -- tasks are left alone.
+  WHERE gdrive_task.status IN (2, 3)
+     OR ($6::BOOLEAN AND gdrive_task.status = 1)
+RETURNING id, type, payload, idempotency_key, status, attempts, next_attempt, last_error, result, patient_id, appuser_id, created, updated, rerun
+`
+
+type EnqueueGDriveTaskParams struct {
+	Type           int32
+	Payload        string
+	IdempotencyKey string
+	PatientID      pgtype.Int4
+	AppuserID      pgtype.Int4
+	RerunRunning   bool
+}
+
+func (q *Queries) EnqueueGDriveTask(ctx context.Context, arg EnqueueGDriveTaskParams) (GdriveTask, error) {
+	row := q.db.QueryRow(ctx, enqueueGDriveTask,
+		arg.Type,
+		arg.Payload,
+		arg.IdempotencyKey,
+		arg.PatientID,
+		arg.AppuserID,
+		arg.RerunRunning,
+	)
+	var i GdriveTask
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.IdempotencyKey,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttempt,
+		&i.LastError,
+		&i.Result,
+		&i.PatientID,
+		&i.AppuserID,
+		&i.Created,
+		&i.Updated,
+		&i.Rerun,
+	)
+	return i, err
+}
+
+const getGDriveTaskByKey = `-- name: GetGDriveTaskByKey :one
+SELECT id, type, payload, idempotency_key, status, attempts, next_attempt, last_error, result, patient_id, appuser_id, created, updated, rerun
+FROM gdrive_task
+WHERE idempotency_key = $1
+`
+
+func (q *Queries) GetGDriveTaskByKey(ctx context.Context, idempotencyKey string) (GdriveTask, error) {
+	row := q.db.QueryRow(ctx, getGDriveTaskByKey, idempotencyKey)
+	var i GdriveTask
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.IdempotencyKey,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttempt,
+		&i.LastError,
+		&i.Result,
+		&i.PatientID,
+		&i.AppuserID,
+		&i.Created,
+		&i.Updated,
+		&i.Rerun,
+	)
+	return i, err
+}
+
+const getGDriveTasks = `-- name: GetGDriveTasks :many
+SELECT
+  t.id, t.type, t.payload, t.idempotency_key, t.status, t.attempts, t.next_attempt, t.last_error, t.result, t.patient_id, t.appuser_id, t.created, t.updated, t.rerun,
+  COALESCE(p.name, '')::TEXT AS patient_name,
+  COALESCE(a.display_name, '')::TEXT AS appuser_name
+FROM gdrive_task AS t
+LEFT JOIN patient AS p ON p.id = t.patient_id
+LEFT JOIN appuser AS a ON a.id = t.appuser_id
+WHERE t.status IN (0, 1, 3)
+   OR t.updated > NOW() - INTERVAL '7 days'
+ORDER BY t.status = 2, t.updated DESC
+LIMIT 500
+`
+
+type GetGDriveTasksRow struct {
+	ID             int32
+	Type           int32
+	Payload        string
+	IdempotencyKey string
+	Status         int32
+	Attempts       int32
+	NextAttempt    pgtype.Timestamptz
+	LastError      pgtype.Text
+	Result         pgtype.Text
+	PatientID      pgtype.Int4
+	AppuserID      pgtype.Int4
+	Created        pgtype.Timestamptz
+	Updated        pgtype.Timestamptz
+	Rerun          bool
+	PatientName    string
+	AppuserName    string
+}
+
+func (q *Queries) GetGDriveTasks(ctx context.Context) ([]GetGDriveTasksRow, error) {
+	rows, err := q.db.Query(ctx, getGDriveTasks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGDriveTasksRow
+	for rows.Next() {
+		var i GetGDriveTasksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Payload,
+			&i.IdempotencyKey,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttempt,
+			&i.LastError,
+			&i.Result,
+			&i.PatientID,
+			&i.AppuserID,
+			&i.Created,
+			&i.Updated,
+			&i.Rerun,
+			&i.PatientName,
+			&i.AppuserName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getJournalCreationProgress = `-- name: GetJournalCreationProgress :many
+SELECT
+  p.id,
+  p.name,
+  COALESCE(p.journal_url, '')::TEXT AS journal_url,
+  t.status,
+  t.attempts,
+  t.next_attempt,
+  t.last_error
+FROM import_batch AS b
+JOIN import_batch_patient AS bp ON bp.batch_id = b.id
+JOIN patient AS p ON p.id = bp.patient_id
+LEFT JOIN gdrive_task AS t
+  ON t.patient_id = p.id
+ AND t.type = $1
+WHERE b.id = $2
+  AND b.appuser_id = $3
+ORDER BY p.id
+`
+
+type GetJournalCreationProgressParams struct {
+	TaskType  int32
+	BatchID   int32
+	AppuserID int32
+}
+
+type GetJournalCreationProgressRow struct {
+	ID          int32
+	Name        string
+	JournalUrl  string
+	Status      pgtype.Int4
+	Attempts    pgtype.Int4
+	NextAttempt pgtype.Timestamptz
+	LastError   pgtype.Text
+}
+
+func (q *Queries) GetJournalCreationProgress(ctx context.Context, arg GetJournalCreationProgressParams) ([]GetJournalCreationProgressRow, error) {
+	rows, err := q.db.Query(ctx, getJournalCreationProgress, arg.TaskType, arg.BatchID, arg.AppuserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetJournalCreationProgressRow
+	for rows.Next() {
+		var i GetJournalCreationProgressRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.JournalUrl,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttempt,
+			&i.LastError,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnfinishedGDriveTasksForPatients = `-- name: GetUnfinishedGDriveTasksForPatients :many
+SELECT
+  patient_id::INT AS patient_id,
+  type,
+  status,
+  last_error
+FROM gdrive_task
+WHERE patient_id = ANY($1::INT[])
+  AND status IN (0, 1, 3)
+`
+
+type GetUnfinishedGDriveTasksForPatientsRow struct {
+	PatientID int32
+	Type      int32
+	Status    int32
+	LastError pgtype.Text
+}
+
+func (q *Queries) GetUnfinishedGDriveTasksForPatients(ctx context.Context, patientIds []int32) ([]GetUnfinishedGDriveTasksForPatientsRow, error) {
+	rows, err := q.db.Query(ctx, getUnfinishedGDriveTasksForPatients, patientIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUnfinishedGDriveTasksForPatientsRow
+	for rows.Next() {
+		var i GetUnfinishedGDriveTasksForPatientsRow
+		if err := rows.Scan(
+			&i.PatientID,
+			&i.Type,
+			&i.Status,
+			&i.LastError,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resetRunningGDriveTasks = `-- name: ResetRunningGDriveTasks :exec
+UPDATE gdrive_task
+SET status  = 0,
+    rerun   = FALSE,
+    updated = NOW()
+WHERE status = 1
+`
+
+func (q *Queries) ResetRunningGDriveTasks(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, resetRunningGDriveTasks)
+	return err
+}
+
+const retryGDriveTask = `-- name: RetryGDriveTask :execrows
+UPDATE gdrive_task
+SET status       = 0,
+    attempts     = 0,
+    next_attempt = NOW(),
+    updated      = NOW()
+WHERE id = $1
+  AND status = 3
+`
+
+func (q *Queries) RetryGDriveTask(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, retryGDriveTask, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setGDriveTaskDone = `-- name: SetGDriveTaskDone :exec
+UPDATE gdrive_task
+SET status       = CASE WHEN rerun THEN 0 ELSE 2 END,
+    attempts     = CASE WHEN rerun THEN 0 ELSE attempts END,
+    next_attempt = NOW(),
+    rerun        = FALSE,
+    result       = $1,
+    last_error   = NULL,
+    updated      = NOW()
+WHERE id = $2
+`
+
+type SetGDriveTaskDoneParams struct {
+	Result pgtype.Text
+	ID     int32
+}
+
+// Tasks queued again while running go back to pending
+func (q *Queries) SetGDriveTaskDone(ctx context.Context, arg SetGDriveTaskDoneParams) error {
+	_, err := q.db.Exec(ctx, setGDriveTaskDone, arg.Result, arg.ID)
+	return err
+}
+
+const setGDriveTaskFailed = `-- name: SetGDriveTaskFailed :exec
+UPDATE gdrive_task
+SET status       = CASE WHEN rerun THEN 0 ELSE 3 END,
+    attempts     = CASE WHEN rerun THEN 0 ELSE attempts END,
+    next_attempt = NOW(),
+    rerun        = FALSE,
+    last_error   = $1,
+    updated      = NOW()
+WHERE id = $2
+`
+
+type SetGDriveTaskFailedParams struct {
+	LastError pgtype.Text
+	ID        int32
+}
+
+// Tasks queued again while running get another go with the new payload
+func (q *Queries) SetGDriveTaskFailed(ctx context.Context, arg SetGDriveTaskFailedParams) error {
+	_, err := q.db.Exec(ctx, setGDriveTaskFailed, arg.LastError, arg.ID)
+	return err
+}
+
+const setGDriveTaskRetry = `-- name: SetGDriveTaskRetry :exec
+UPDATE gdrive_task
+SET status       = 0,
+    rerun        = FALSE,
+    next_attempt = $1,
+    last_error   = $2,
+    updated      = NOW()
+WHERE id = $3
+`
+
+type SetGDriveTaskRetryParams struct {
+	NextAttempt pgtype.Timestamptz
+	LastError   pgtype.Text
+	ID          int32
+}
+
+func (q *Queries) SetGDriveTaskRetry(ctx context.Context, arg SetGDriveTaskRetryParams) error {
+	_, err := q.db.Exec(ctx, setGDriveTaskRetry, arg.NextAttempt, arg.LastError, arg.ID)
+	return err
+}
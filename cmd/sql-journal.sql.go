@@ -0,0 +1,406 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: sql-journal.sql
+
+package main
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createMarkdownJournal = `-- name: CreateMarkdownJournal :one
+INSERT INTO journal (title, content)
+VALUES ($1, $2)
+RETURNING id, title, content, created, updated, updated_by, version, change_xid
+`
+
+type CreateMarkdownJournalParams struct {
+	Title   string
+	Content string
+}
+
+func (q *Queries) CreateMarkdownJournal(ctx context.Context, arg CreateMarkdownJournalParams) (Journal, error) {
+	row := q.db.QueryRow(ctx, createMarkdownJournal, arg.Title, arg.Content)
+	var i Journal
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Content,
+		&i.Created,
+		&i.Updated,
+		&i.UpdatedBy,
+		&i.Version,
+		&i.ChangeXid,
+	)
+	return i, err
+}
+
+const getActivePatientJournals = `-- name: GetActivePatientJournals :many
+SELECT
+  p.id,
+  p.journal_url::TEXT AS journal_url
+FROM patient AS p
+WHERE p.curr_home_id IS NOT NULL
+  AND p.journal_url IS NOT NULL
+  AND p.journal_url <> ''
+ORDER BY p.id
+`
+
+type GetActivePatientJournalsRow struct {
+	ID         int32
+	JournalUrl string
+}
+
+func (q *Queries) GetActivePatientJournals(ctx context.Context) ([]GetActivePatientJournalsRow, error) {
+	rows, err := q.db.Query(ctx, getActivePatientJournals)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetActivePatientJournalsRow
+	for rows.Next() {
+		var i GetActivePatientJournalsRow
+		if err := rows.Scan(&i.ID, &i.JournalUrl); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMarkdownJournal = `-- name: GetMarkdownJournal :one
+SELECT
+  j.id, j.title, j.content, j.created, j.updated, j.updated_by, j.version, j.change_xid,
+  COALESCE(a.display_name, '')::TEXT AS updated_by_name
+FROM journal AS j
+LEFT JOIN appuser AS a ON a.id = j.updated_by
+WHERE j.id = $1
+`
+
+type GetMarkdownJournalRow struct {
+	ID            int32
+	Title         string
+	Content       string
+	Created       pgtype.Timestamptz
+	Updated       pgtype.Timestamptz
+	UpdatedBy     pgtype.Int4
+	Version       int32
+	ChangeXid     int64
+	UpdatedByName string
+}
+
+func (q *Queries) GetMarkdownJournal(ctx context.Context, id int32) (GetMarkdownJournalRow, error) {
+	row := q.db.QueryRow(ctx, getMarkdownJournal, id)
+	var i GetMarkdownJournalRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Content,
+		&i.Created,
+		&i.Updated,
+		&i.UpdatedBy,
+		&i.Version,
+		&i.ChangeXid,
+		&i.UpdatedByName,
+	)
+	return i, err
+}
+
+const getMarkdownJournalChangeCursor = `-- name: GetMarkdownJournalChangeCursor :one
+SELECT pg_snapshot_xmin(pg_current_snapshot())::TEXT::BIGINT AS cursor
+`
+
+// Every transaction before this one has committed or aborted
+func (q *Queries) GetMarkdownJournalChangeCursor(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, getMarkdownJournalChangeCursor)
+	var cursor int64
+	err := row.Scan(&cursor)
+	return cursor, err
+}
+
+const getMarkdownJournals = `-- name: GetMarkdownJournals :many
+SELECT
+  j.id,
+  j.title,
+  j.created,
+  j.updated
+FROM journal AS j
+ORDER BY j.updated DESC
+`
+
+type GetMarkdownJournalsRow struct {
+	ID      int32
+	Title   string
+	Created pgtype.Timestamptz
+	Updated pgtype.Timestamptz
+}
+
+func (q *Queries) GetMarkdownJournals(ctx context.Context) ([]GetMarkdownJournalsRow, error) {
+	rows, err := q.db.Query(ctx, getMarkdownJournals)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMarkdownJournalsRow
+	for rows.Next() {
+		var i GetMarkdownJournalsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMarkdownJournalsChangedSince = `-- name: GetMarkdownJournalsChangedSince :many
+SELECT id, title, content, created, updated, updated_by, version, change_xid
+FROM journal
+WHERE change_xid >= $1::BIGINT
+ORDER BY change_xid, id
+`
+
+func (q *Queries) GetMarkdownJournalsChangedSince(ctx context.Context, since int64) ([]Journal, error) {
+	rows, err := q.db.Query(ctx, getMarkdownJournalsChangedSince, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Journal
+	for rows.Next() {
+		var i Journal
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			&i.Created,
+			&i.Updated,
+			&i.UpdatedBy,
+			&i.Version,
+			&i.ChangeXid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrphanJournals = `-- name: GetOrphanJournals :many
+SELECT
+  s.associated_url::TEXT       AS url,
+  COALESCE(s.header, '')::TEXT AS header,
+  s.created,
+  s.updated,
+  s.extra_data
+FROM search AS s
+WHERE s.ns = 'journal'
+  AND NOT EXISTS (
+    SELECT 1
+    FROM patient AS p
+    WHERE p.journal_url = s.associated_url
+  )
+ORDER BY s.created DESC NULLS LAST, s.associated_url
+`
+
+type GetOrphanJournalsRow struct {
+	Url       string
+	Header    string
+	Created   pgtype.Timestamptz
+	Updated   pgtype.Timestamptz
+	ExtraData pgtype.Text
+}
+
+func (q *Queries) GetOrphanJournals(ctx context.Context) ([]GetOrphanJournalsRow, error) {
+	rows, err := q.db.Query(ctx, getOrphanJournals)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOrphanJournalsRow
+	for rows.Next() {
+		var i GetOrphanJournalsRow
+		if err := rows.Scan(
+			&i.Url,
+			&i.Header,
+			&i.Created,
+			&i.Updated,
+			&i.ExtraData,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPatientJournalCheck = `-- name: GetPatientJournalCheck :one
+SELECT pjc.patient_id, pjc.journal_url, pjc.problem, pjc.detail, pjc.checked
+FROM patient_journal_check AS pjc
+INNER JOIN patient AS p
+  ON p.id = pjc.patient_id
+ AND p.journal_url = pjc.journal_url
+WHERE pjc.patient_id = $1
+`
+
+func (q *Queries) GetPatientJournalCheck(ctx context.Context, patientID int32) (PatientJournalCheck, error) {
+	row := q.db.QueryRow(ctx, getPatientJournalCheck, patientID)
+	var i PatientJournalCheck
+	err := row.Scan(
+		&i.PatientID,
+		&i.JournalUrl,
+		&i.Problem,
+		&i.Detail,
+		&i.Checked,
+	)
+	return i, err
+}
+
+const getPatientsWithJournalProblems = `-- name: GetPatientsWithJournalProblems :many
+SELECT
+  pjc.patient_id, pjc.journal_url, pjc.problem, pjc.detail, pjc.checked,
+  p.name
+FROM patient_journal_check AS pjc
+INNER JOIN patient AS p
+  ON p.id = pjc.patient_id
+ AND p.journal_url = pjc.journal_url
+WHERE pjc.problem <> 0
+  AND p.curr_home_id IS NOT NULL
+ORDER BY pjc.checked DESC
+`
+
+type GetPatientsWithJournalProblemsRow struct {
+	PatientID  int32
+	JournalUrl string
+	Problem    int32
+	Detail     string
+	Checked    pgtype.Timestamptz
+	Name       string
+}
+
+func (q *Queries) GetPatientsWithJournalProblems(ctx context.Context) ([]GetPatientsWithJournalProblemsRow, error) {
+	rows, err := q.db.Query(ctx, getPatientsWithJournalProblems)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPatientsWithJournalProblemsRow
+	for rows.Next() {
+		var i GetPatientsWithJournalProblemsRow
+		if err := rows.Scan(
+			&i.PatientID,
+			&i.JournalUrl,
+			&i.Problem,
+			&i.Detail,
+			&i.Checked,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setMarkdownJournalTitle = `-- name: SetMarkdownJournalTitle :exec
+UPDATE journal
+SET title      = $1,
+    updated    = NOW(),
+    version    = version + 1,
+    change_xid = pg_current_xact_id()::TEXT::BIGINT
+WHERE id = $2
+  AND title <> $1
+`
+
+type SetMarkdownJournalTitleParams struct {
+	Title string
+	ID    int32
+}
+
+func (q *Queries) SetMarkdownJournalTitle(ctx context.Context, arg SetMarkdownJournalTitleParams) error {
+	_, err := q.db.Exec(ctx, setMarkdownJournalTitle, arg.Title, arg.ID)
+	return err
+}
+
+const setPatientJournalCheck = `-- name: SetPatientJournalCheck :exec
+INSERT INTO patient_journal_check (patient_id, journal_url, problem, detail, checked)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (patient_id) DO UPDATE SET
+    journal_url = EXCLUDED.journal_url,
+    problem     = EXCLUDED.problem,
+    detail      = EXCLUDED.detail,
+    checked     = EXCLUDED.checked
+`
+
+type SetPatientJournalCheckParams struct {
+	PatientID  int32
+	JournalUrl string
+	Problem    int32
+	Detail     string
+}
+
+func (q *Queries) SetPatientJournalCheck(ctx context.Context, arg SetPatientJournalCheckParams) error {
+	_, err := q.db.Exec(ctx, setPatientJournalCheck,
+		arg.PatientID,
+		arg.JournalUrl,
+		arg.Problem,
+		arg.Detail,
+	)
+	return err
+}
+
+const updateMarkdownJournal = `-- name: UpdateMarkdownJournal :execrows
+UPDATE journal
+SET title      = $1,
+    content    = $2,
+    updated    = NOW(),
+    updated_by = $3,
+    version    = version + 1,
+    change_xid = pg_current_xact_id()::TEXT::BIGINT
+WHERE id = $4
+  AND version = $5
+`
+
+type UpdateMarkdownJournalParams struct {
+	Title     string
+	Content   string
+	UpdatedBy pgtype.Int4
+	ID        int32
+	Version   int32
+}
+
+func (q *Queries) UpdateMarkdownJournal(ctx context.Context, arg UpdateMarkdownJournalParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateMarkdownJournal,
+		arg.Title,
+		arg.Content,
+		arg.UpdatedBy,
+		arg.ID,
+		arg.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
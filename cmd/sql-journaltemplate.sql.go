@@ -0,0 +1,193 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: sql-journaltemplate.sql
+
+package main
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const addJournalTemplate = `-- name: AddJournalTemplate :one
+INSERT INTO journal_template (name, gdrive_file)
+VALUES ($1, $2)
+RETURNING id
+`
+
+type AddJournalTemplateParams struct {
+	Name       string
+	GdriveFile string
+}
+
+func (q *Queries) AddJournalTemplate(ctx context.Context, arg AddJournalTemplateParams) (int32, error) {
+	row := q.db.QueryRow(ctx, addJournalTemplate, arg.Name, arg.GdriveFile)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const deleteJournalTemplate = `-- name: DeleteJournalTemplate :execrows
+DELETE FROM journal_template
+WHERE id = $1
+`
+
+func (q *Queries) DeleteJournalTemplate(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteJournalTemplate, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSpeciesJournalTemplate = `-- name: DeleteSpeciesJournalTemplate :exec
+DELETE FROM species_journal_template
+WHERE species_id = $1
+`
+
+func (q *Queries) DeleteSpeciesJournalTemplate(ctx context.Context, speciesID int32) error {
+	_, err := q.db.Exec(ctx, deleteSpeciesJournalTemplate, speciesID)
+	return err
+}
+
+const getJournalTemplate = `-- name: GetJournalTemplate :one
+SELECT id, name, gdrive_file, variables, validated, validation_error, created
+FROM journal_template
+WHERE id = $1
+`
+
+func (q *Queries) GetJournalTemplate(ctx context.Context, id int32) (JournalTemplate, error) {
+	row := q.db.QueryRow(ctx, getJournalTemplate, id)
+	var i JournalTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.GdriveFile,
+		&i.Variables,
+		&i.Validated,
+		&i.ValidationError,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getJournalTemplateForSpecies = `-- name: GetJournalTemplateForSpecies :one
+SELECT jt.id, jt.name, jt.gdrive_file, jt.variables, jt.validated, jt.validation_error, jt.created
+FROM journal_template AS jt
+  INNER JOIN species_journal_template AS sjt ON sjt.template_id = jt.id
+WHERE sjt.species_id = $1
+  AND jt.validation_error IS NULL
+`
+
+// Templates that failed validation are skipped, so the default template is used instead
+func (q *Queries) GetJournalTemplateForSpecies(ctx context.Context, speciesID int32) (JournalTemplate, error) {
+	row := q.db.QueryRow(ctx, getJournalTemplateForSpecies, speciesID)
+	var i JournalTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.GdriveFile,
+		&i.Variables,
+		&i.Validated,
+		&i.ValidationError,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getJournalTemplates = `-- name: GetJournalTemplates :many
+SELECT id, name, gdrive_file, variables, validated, validation_error, created
+FROM journal_template
+ORDER BY name
+`
+
+func (q *Queries) GetJournalTemplates(ctx context.Context) ([]JournalTemplate, error) {
+	rows, err := q.db.Query(ctx, getJournalTemplates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []JournalTemplate
+	for rows.Next() {
+		var i JournalTemplate
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.GdriveFile,
+			&i.Variables,
+			&i.Validated,
+			&i.ValidationError,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSpeciesJournalTemplates = `-- name: GetSpeciesJournalTemplates :many
+SELECT species_id, template_id
+FROM species_journal_template
+`
+
+func (q *Queries) GetSpeciesJournalTemplates(ctx context.Context) ([]SpeciesJournalTemplate, error) {
+	rows, err := q.db.Query(ctx, getSpeciesJournalTemplates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SpeciesJournalTemplate
+	for rows.Next() {
+		var i SpeciesJournalTemplate
+		if err := rows.Scan(&i.SpeciesID, &i.TemplateID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setJournalTemplateValidation = `-- name: SetJournalTemplateValidation :exec
+UPDATE journal_template
+SET variables        = $1,
+    validated        = NOW(),
+    validation_error = $2
+WHERE id = $3
+`
+
+type SetJournalTemplateValidationParams struct {
+	Variables       []string
+	ValidationError pgtype.Text
+	ID              int32
+}
+
+func (q *Queries) SetJournalTemplateValidation(ctx context.Context, arg SetJournalTemplateValidationParams) error {
+	_, err := q.db.Exec(ctx, setJournalTemplateValidation, arg.Variables, arg.ValidationError, arg.ID)
+	return err
+}
+
+const setSpeciesJournalTemplate = `-- name: SetSpeciesJournalTemplate :exec
+INSERT INTO species_journal_template (species_id, template_id)
+VALUES ($1, $2)
+ON CONFLICT (species_id) DO UPDATE SET
+    template_id = EXCLUDED.template_id
+`
+
+type SetSpeciesJournalTemplateParams struct {
+	SpeciesID  int32
+	TemplateID int32
+}
+
+func (q *Queries) SetSpeciesJournalTemplate(ctx context.Context, arg SetSpeciesJournalTemplateParams) error {
+	_, err := q.db.Exec(ctx, setSpeciesJournalTemplate, arg.SpeciesID, arg.TemplateID)
+	return err
+}
@@ -0,0 +1,286 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: sql-measurement.sql
+
+package main
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const addJournalImportReview = `-- name: AddJournalImportReview :exec
+INSERT INTO journal_import_review (patient_id, kind, problem, raw_row, source_url)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (patient_id, kind, raw_row) DO NOTHING
+`
+
+type AddJournalImportReviewParams struct {
+	PatientID int32
+	Kind      int32
+	Problem   int32
+	RawRow    string
+	SourceUrl string
+}
+
+func (q *Queries) AddJournalImportReview(ctx context.Context, arg AddJournalImportReviewParams) error {
+	_, err := q.db.Exec(ctx, addJournalImportReview,
+		arg.PatientID,
+		arg.Kind,
+		arg.Problem,
+		arg.RawRow,
+		arg.SourceUrl,
+	)
+	return err
+}
+
+const addPatientMeasurement = `-- name: AddPatientMeasurement :exec
+INSERT INTO patient_measurement (patient_id, kind, measured, value, note, source_url, source_raw, reviewed)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (patient_id, source_url, kind, source_raw) DO UPDATE SET
+    measured = EXCLUDED.measured,
+    value    = EXCLUDED.value,
+    note     = EXCLUDED.note,
+    reviewed = EXCLUDED.reviewed
+WHERE EXCLUDED.reviewed
+   OR NOT patient_measurement.reviewed
+`
+
+type AddPatientMeasurementParams struct {
+	PatientID int32
+	Kind      int32
+	Measured  pgtype.Date
+	Value     float64
+	Note      string
+	SourceUrl string
+	SourceRaw pgtype.Text
+	Reviewed  bool
+}
+
+// Measurements accepted in a review are not replaced by imports
+func (q *Queries) AddPatientMeasurement(ctx context.Context, arg AddPatientMeasurementParams) error {
+	_, err := q.db.Exec(ctx, addPatientMeasurement,
+		arg.PatientID,
+		arg.Kind,
+		arg.Measured,
+		arg.Value,
+		arg.Note,
+		arg.SourceUrl,
+		arg.SourceRaw,
+		arg.Reviewed,
+	)
+	return err
+}
+
+const countPendingJournalImportReviewsForPatient = `-- name: CountPendingJournalImportReviewsForPatient :one
+SELECT COUNT(*)
+FROM journal_import_review
+WHERE patient_id = $1
+  AND status = 0
+`
+
+func (q *Queries) CountPendingJournalImportReviewsForPatient(ctx context.Context, patientID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countPendingJournalImportReviewsForPatient, patientID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteRemovedJournalImportReviews = `-- name: DeleteRemovedJournalImportReviews :exec
+DELETE FROM journal_import_review AS jir
+WHERE jir.patient_id = $1
+  AND jir.source_url = $2
+  AND jir.status = 0
+  AND NOT EXISTS (
+    SELECT 1
+    FROM (
+      SELECT UNNEST($3::INT[]) AS kind, UNNEST($4::TEXT[]) AS raw_row
+    ) AS r
+    WHERE r.kind = jir.kind
+      AND r.raw_row = jir.raw_row
+  )
+`
+
+type DeleteRemovedJournalImportReviewsParams struct {
+	PatientID int32
+	SourceUrl string
+	Kinds     []int32
+	RawRows   []string
+}
+
+// Pending reviews of rows that are no longer in the journal
+func (q *Queries) DeleteRemovedJournalImportReviews(ctx context.Context, arg DeleteRemovedJournalImportReviewsParams) error {
+	_, err := q.db.Exec(ctx, deleteRemovedJournalImportReviews,
+		arg.PatientID,
+		arg.SourceUrl,
+		arg.Kinds,
+		arg.RawRows,
+	)
+	return err
+}
+
+const deleteRemovedPatientMeasurements = `-- name: DeleteRemovedPatientMeasurements :exec
+DELETE FROM patient_measurement AS pm
+WHERE pm.patient_id = $1
+  AND pm.source_url = $2
+  AND NOT pm.reviewed
+  AND NOT EXISTS (
+    SELECT 1
+    FROM (
+      SELECT UNNEST($3::INT[]) AS kind, UNNEST($4::TEXT[]) AS raw_row
+    ) AS r
+    WHERE r.kind = pm.kind
+      AND r.raw_row = pm.source_raw
+  )
+`
+
+type DeleteRemovedPatientMeasurementsParams struct {
+	PatientID int32
+	SourceUrl string
+	Kinds     []int32
+	RawRows   []string
+}
+
+// Imported measurements whose row is no longer in the journal
+func (q *Queries) DeleteRemovedPatientMeasurements(ctx context.Context, arg DeleteRemovedPatientMeasurementsParams) error {
+	_, err := q.db.Exec(ctx, deleteRemovedPatientMeasurements,
+		arg.PatientID,
+		arg.SourceUrl,
+		arg.Kinds,
+		arg.RawRows,
+	)
+	return err
+}
+
+const getJournalImportReview = `-- name: GetJournalImportReview :one
+SELECT id, patient_id, kind, problem, raw_row, source_url, status, created
+FROM journal_import_review
+WHERE id = $1
+`
+
+func (q *Queries) GetJournalImportReview(ctx context.Context, id int32) (JournalImportReview, error) {
+	row := q.db.QueryRow(ctx, getJournalImportReview, id)
+	var i JournalImportReview
+	err := row.Scan(
+		&i.ID,
+		&i.PatientID,
+		&i.Kind,
+		&i.Problem,
+		&i.RawRow,
+		&i.SourceUrl,
+		&i.Status,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getPatientMeasurements = `-- name: GetPatientMeasurements :many
+SELECT id, patient_id, kind, measured, value, note, source_url, created, source_raw, reviewed
+FROM patient_measurement
+WHERE patient_id = $1
+ORDER BY measured, kind, id
+`
+
+func (q *Queries) GetPatientMeasurements(ctx context.Context, patientID int32) ([]PatientMeasurement, error) {
+	rows, err := q.db.Query(ctx, getPatientMeasurements, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PatientMeasurement
+	for rows.Next() {
+		var i PatientMeasurement
+		if err := rows.Scan(
+			&i.ID,
+			&i.PatientID,
+			&i.Kind,
+			&i.Measured,
+			&i.Value,
+			&i.Note,
+			&i.SourceUrl,
+			&i.Created,
+			&i.SourceRaw,
+			&i.Reviewed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPendingJournalImportReviews = `-- name: GetPendingJournalImportReviews :many
+SELECT jir.id, jir.patient_id, jir.kind, jir.problem, jir.raw_row, jir.source_url, jir.status, jir.created, p.name AS patient_name
+FROM journal_import_review AS jir
+INNER JOIN patient AS p
+  ON p.id = jir.patient_id
+WHERE jir.status = 0
+ORDER BY jir.created, jir.id
+`
+
+type GetPendingJournalImportReviewsRow struct {
+	ID          int32
+	PatientID   int32
+	Kind        int32
+	Problem     int32
+	RawRow      string
+	SourceUrl   string
+	Status      int32
+	Created     pgtype.Timestamptz
+	PatientName string
+}
+
+func (q *Queries) GetPendingJournalImportReviews(ctx context.Context) ([]GetPendingJournalImportReviewsRow, error) {
+	rows, err := q.db.Query(ctx, getPendingJournalImportReviews)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPendingJournalImportReviewsRow
+	for rows.Next() {
+		var i GetPendingJournalImportReviewsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PatientID,
+			&i.Kind,
+			&i.Problem,
+			&i.RawRow,
+			&i.SourceUrl,
+			&i.Status,
+			&i.Created,
+			&i.PatientName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setJournalImportReviewStatus = `-- name: SetJournalImportReviewStatus :execrows
+UPDATE journal_import_review
+SET status = $1
+WHERE id = $2
+  AND status = 0
+`
+
+type SetJournalImportReviewStatusParams struct {
+	Status int32
+	ID     int32
+}
+
+func (q *Queries) SetJournalImportReviewStatus(ctx context.Context, arg SetJournalImportReviewStatusParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setJournalImportReviewStatus, arg.Status, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -13,16 +13,18 @@ import (
 )
 
 const addPatient = `-- name: AddPatient :one
-INSERT INTO patient (species_id, name, curr_home_id, status, time_checkin)
-VALUES ($1, $2, $3, $4, NOW())
+INSERT INTO patient (species_id, name, curr_home_id, status, finder, intake_cause, time_checkin)
+VALUES ($1, $2, $3, $4, $5, $6, NOW())
 RETURNING id
 `
 
 type AddPatientParams struct {
-	SpeciesID  int32
-	Name       string
-	CurrHomeID pgtype.Int4
-	Status     int32
+	SpeciesID   int32
+	Name        string
+	CurrHomeID  pgtype.Int4
+	Status      int32
+	Finder      string
+	IntakeCause string
 }
 
 func (q *Queries) AddPatient(ctx context.Context, arg AddPatientParams) (int32, error) {
@@ -31,6 +33,8 @@ func (q *Queries) AddPatient(ctx context.Context, arg AddPatientParams) (int32,
 		arg.Name,
 		arg.CurrHomeID,
 		arg.Status,
+		arg.Finder,
+		arg.IntakeCause,
 	)
 	var id int32
 	err := row.Scan(&id)
@@ -157,7 +161,7 @@ func (q *Queries) GetActivePatients(ctx context.Context, languageID int32) ([]Ge
 }
 
 const getCurrentPatientsForHome = `-- name: GetCurrentPatientsForHome :many
-SELECT p.id, p.species_id, p.curr_home_id, p.name, p.status, p.journal_url, p.sort_order, p.time_checkin, p.time_checkout, sl.name AS species_name FROM patient AS p
+SELECT p.id, p.species_id, p.curr_home_id, p.name, p.status, p.journal_url, p.sort_order, p.time_checkin, p.time_checkout, p.finder, p.intake_cause, p.journal_template, sl.name AS species_name FROM patient AS p
 JOIN species_language AS sl
   ON sl.species_id = p.species_id
 WHERE p.curr_home_id = $1
@@ -171,16 +175,19 @@ type GetCurrentPatientsForHomeParams struct {
 }
 
 type GetCurrentPatientsForHomeRow struct {
-	ID           int32
-	SpeciesID    int32
-	CurrHomeID   pgtype.Int4
-	Name         string
-	Status       int32
-	JournalUrl   pgtype.Text
-	SortOrder    int32
-	TimeCheckin  pgtype.Timestamptz
-	TimeCheckout pgtype.Timestamptz
-	SpeciesName  string
+	ID              int32
+	SpeciesID       int32
+	CurrHomeID      pgtype.Int4
+	Name            string
+	Status          int32
+	JournalUrl      pgtype.Text
+	SortOrder       int32
+	TimeCheckin     pgtype.Timestamptz
+	TimeCheckout    pgtype.Timestamptz
+	Finder          string
+	IntakeCause     string
+	JournalTemplate pgtype.Text
+	SpeciesName     string
 }
 
 func (q *Queries) GetCurrentPatientsForHome(ctx context.Context, arg GetCurrentPatientsForHomeParams) ([]GetCurrentPatientsForHomeRow, error) {
@@ -202,6 +209,9 @@ func (q *Queries) GetCurrentPatientsForHome(ctx context.Context, arg GetCurrentP
 			&i.SortOrder,
 			&i.TimeCheckin,
 			&i.TimeCheckout,
+			&i.Finder,
+			&i.IntakeCause,
+			&i.JournalTemplate,
 			&i.SpeciesName,
 		); err != nil {
 			return nil, err
@@ -273,7 +283,7 @@ func (q *Queries) GetFormerPatients(ctx context.Context, languageID int32) ([]Ge
 }
 
 const getPatient = `-- name: GetPatient :one
-SELECT id, species_id, curr_home_id, name, status, journal_url, sort_order, time_checkin, time_checkout FROM patient
+SELECT id, species_id, curr_home_id, name, status, journal_url, sort_order, time_checkin, time_checkout, finder, intake_cause, journal_template FROM patient
 WHERE id = $1
 `
 
@@ -290,12 +300,48 @@ func (q *Queries) GetPatient(ctx context.Context, id int32) (Patient, error) {
 		&i.SortOrder,
 		&i.TimeCheckin,
 		&i.TimeCheckout,
+		&i.Finder,
+		&i.IntakeCause,
+		&i.JournalTemplate,
 	)
 	return i, err
 }
 
+const getPatientHomesByJournalURL = `-- name: GetPatientHomesByJournalURL :many
+SELECT
+  p.journal_url::TEXT AS journal_url,
+  p.curr_home_id
+FROM patient AS p
+WHERE p.journal_url = ANY($1::TEXT[])
+`
+
+type GetPatientHomesByJournalURLRow struct {
+	JournalUrl string
+	CurrHomeID pgtype.Int4
+}
+
+func (q *Queries) GetPatientHomesByJournalURL(ctx context.Context, journalUrls []string) ([]GetPatientHomesByJournalURLRow, error) {
+	rows, err := q.db.Query(ctx, getPatientHomesByJournalURL, journalUrls)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPatientHomesByJournalURLRow
+	for rows.Next() {
+		var i GetPatientHomesByJournalURLRow
+		if err := rows.Scan(&i.JournalUrl, &i.CurrHomeID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPatientWithSpecies = `-- name: GetPatientWithSpecies :one
-SELECT p.id, p.species_id, p.curr_home_id, p.name, p.status, p.journal_url, p.sort_order, p.time_checkin, p.time_checkout, sl.name AS species_name FROM patient AS p
+SELECT p.id, p.species_id, p.curr_home_id, p.name, p.status, p.journal_url, p.sort_order, p.time_checkin, p.time_checkout, p.finder, p.intake_cause, p.journal_template, sl.name AS species_name FROM patient AS p
 JOIN species_language AS sl
   ON sl.species_id = p.species_id
 WHERE p.id = $1
@@ -308,16 +354,19 @@ type GetPatientWithSpeciesParams struct {
 }
 
 type GetPatientWithSpeciesRow struct {
-	ID           int32
-	SpeciesID    int32
-	CurrHomeID   pgtype.Int4
-	Name         string
-	Status       int32
-	JournalUrl   pgtype.Text
-	SortOrder    int32
-	TimeCheckin  pgtype.Timestamptz
-	TimeCheckout pgtype.Timestamptz
-	SpeciesName  string
+	ID              int32
+	SpeciesID       int32
+	CurrHomeID      pgtype.Int4
+	Name            string
+	Status          int32
+	JournalUrl      pgtype.Text
+	SortOrder       int32
+	TimeCheckin     pgtype.Timestamptz
+	TimeCheckout    pgtype.Timestamptz
+	Finder          string
+	IntakeCause     string
+	JournalTemplate pgtype.Text
+	SpeciesName     string
 }
 
 func (q *Queries) GetPatientWithSpecies(ctx context.Context, arg GetPatientWithSpeciesParams) (GetPatientWithSpeciesRow, error) {
@@ -333,6 +382,9 @@ func (q *Queries) GetPatientWithSpecies(ctx context.Context, arg GetPatientWithS
 		&i.SortOrder,
 		&i.TimeCheckin,
 		&i.TimeCheckout,
+		&i.Finder,
+		&i.IntakeCause,
+		&i.JournalTemplate,
 		&i.SpeciesName,
 	)
 	return i, err
@@ -365,6 +417,134 @@ func (q *Queries) GetPatientsByJournalURL(ctx context.Context, lookup string) ([
 	return items, nil
 }
 
+const getPatientsWithJournalURL = `-- name: GetPatientsWithJournalURL :many
+SELECT
+  p.id
+FROM patient AS p
+WHERE p.journal_url = $1
+`
+
+func (q *Queries) GetPatientsWithJournalURL(ctx context.Context, journalUrl pgtype.Text) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getPatientsWithJournalURL, journalUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSimilarPatients = `-- name: GetSimilarPatients :many
+WITH target AS (
+  SELECT p.species_id, s.similarity_excerpt
+  FROM patient AS p
+  JOIN search AS s
+    ON s.patient_id = p.id
+   AND s.ns = 'patient'
+   AND NOT s.skipped
+  WHERE p.id = $2::int
+)
+SELECT
+  i.id, i.name, i.status, i.time_checkin, i.time_checkout, i.species, i.same_species, i.similarity,
+  (i.similarity + CASE WHEN i.same_species THEN $1::real ELSE 0 END)::real AS score
+FROM (
+  SELECT
+    p.id,
+    p.name,
+    p.status,
+    p.time_checkin,
+    p.time_checkout,
+    COALESCE(sl.name, '???') AS species,
+    (p.species_id = t.species_id) AS same_species,
+    similarity(s.similarity_excerpt, t.similarity_excerpt)::real AS similarity
+  FROM target AS t
+  JOIN search AS s
+    ON s.ns = 'patient'
+   AND NOT s.skipped
+   AND s.patient_id <> $2::int
+   AND s.similarity_excerpt % t.similarity_excerpt
+  JOIN patient AS p
+    ON p.id = s.patient_id
+  LEFT JOIN species_language AS sl
+    ON sl.species_id = p.species_id
+   AND sl.language_id = $3
+  WHERE p.curr_home_id IS NULL
+    AND p.status <> ALL($4::int[])
+) i
+ORDER BY score DESC
+LIMIT $5::int
+`
+
+type GetSimilarPatientsParams struct {
+	SpeciesWeight    float32
+	PatientID        int32
+	LanguageID       int32
+	ExcludedStatuses []int32
+	ResultLimit      int32
+}
+
+type GetSimilarPatientsRow struct {
+	ID           int32
+	Name         string
+	Status       int32
+	TimeCheckin  pgtype.Timestamptz
+	TimeCheckout pgtype.Timestamptz
+	Species      string
+	SameSpecies  bool
+	Similarity   float32
+	Score        float32
+}
+
+// Former patients whose journals resemble the journal of the given patient,
+// with a bonus for being the same species. Journals are compared by their
+// excerpts, and only those above the similarity threshold set with
+// SetSimilarityThreshold are found by the trigram index.
+func (q *Queries) GetSimilarPatients(ctx context.Context, arg GetSimilarPatientsParams) ([]GetSimilarPatientsRow, error) {
+	rows, err := q.db.Query(ctx, getSimilarPatients,
+		arg.SpeciesWeight,
+		arg.PatientID,
+		arg.LanguageID,
+		arg.ExcludedStatuses,
+		arg.ResultLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSimilarPatientsRow
+	for rows.Next() {
+		var i GetSimilarPatientsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Status,
+			&i.TimeCheckin,
+			&i.TimeCheckout,
+			&i.Species,
+			&i.SameSpecies,
+			&i.Similarity,
+			&i.Score,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const movePatient = `-- name: MovePatient :exec
 UPDATE patient
 SET curr_home_id = $2
@@ -383,7 +563,8 @@ func (q *Queries) MovePatient(ctx context.Context, arg MovePatientParams) error
 
 const setPatientJournal = `-- name: SetPatientJournal :execresult
 UPDATE patient
-SET journal_url = $2
+SET journal_url = $2,
+    journal_template = CASE WHEN journal_url IS DISTINCT FROM $2 THEN NULL ELSE journal_template END
 WHERE id = $1
 `
 
@@ -392,10 +573,33 @@ type SetPatientJournalParams struct {
 	JournalUrl pgtype.Text
 }
 
+// The template is only known for journals created by bino
 func (q *Queries) SetPatientJournal(ctx context.Context, arg SetPatientJournalParams) (pgconn.CommandTag, error) {
 	return q.db.Exec(ctx, setPatientJournal, arg.ID, arg.JournalUrl)
 }
 
+const setPatientJournalIfMissing = `-- name: SetPatientJournalIfMissing :execrows
+UPDATE patient
+SET journal_url = $1,
+    journal_template = $2
+WHERE id = $3
+  AND (journal_url IS NULL OR journal_url = '')
+`
+
+type SetPatientJournalIfMissingParams struct {
+	JournalUrl      pgtype.Text
+	JournalTemplate pgtype.Text
+	ID              int32
+}
+
+func (q *Queries) SetPatientJournalIfMissing(ctx context.Context, arg SetPatientJournalIfMissingParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setPatientJournalIfMissing, arg.JournalUrl, arg.JournalTemplate, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const setPatientName = `-- name: SetPatientName :exec
 UPDATE patient
 SET name = $2
@@ -428,6 +632,16 @@ func (q *Queries) SetPatientStatus(ctx context.Context, arg SetPatientStatusPara
 	return err
 }
 
+const setSimilarityThreshold = `-- name: SetSimilarityThreshold :exec
+SELECT set_config('pg_trgm.similarity_threshold', $1::text, true)
+`
+
+// The threshold of the % operator for the rest of the transaction
+func (q *Queries) SetSimilarityThreshold(ctx context.Context, threshold string) error {
+	_, err := q.db.Exec(ctx, setSimilarityThreshold, threshold)
+	return err
+}
+
 const updatePatientSortOrder = `-- name: UpdatePatientSortOrder :exec
 UPDATE patient as p
 SET sort_order = v.sort_order
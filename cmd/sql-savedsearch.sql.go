@@ -0,0 +1,380 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: sql-savedsearch.sql
+
+package main
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countUnseenNotifications = `-- name: CountUnseenNotifications :one
+SELECT COUNT(*)::int
+FROM notification
+WHERE appuser_id = $1
+  AND NOT seen
+`
+
+func (q *Queries) CountUnseenNotifications(ctx context.Context, appuserID int32) (int32, error) {
+	row := q.db.QueryRow(ctx, countUnseenNotifications, appuserID)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const createSavedSearch = `-- name: CreateSavedSearch :one
+INSERT INTO saved_search (appuser_id, name, params, alert)
+VALUES ($1, $2, $3, $4)
+RETURNING id
+`
+
+type CreateSavedSearchParams struct {
+	AppuserID int32
+	Name      string
+	Params    string
+	Alert     int32
+}
+
+func (q *Queries) CreateSavedSearch(ctx context.Context, arg CreateSavedSearchParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createSavedSearch,
+		arg.AppuserID,
+		arg.Name,
+		arg.Params,
+		arg.Alert,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const deleteNotificationsForUser = `-- name: DeleteNotificationsForUser :exec
+DELETE FROM notification
+WHERE appuser_id = $1
+`
+
+func (q *Queries) DeleteNotificationsForUser(ctx context.Context, appuserID int32) error {
+	_, err := q.db.Exec(ctx, deleteNotificationsForUser, appuserID)
+	return err
+}
+
+const deleteSavedSearch = `-- name: DeleteSavedSearch :exec
+DELETE FROM saved_search
+WHERE id = $1
+  AND appuser_id = $2
+`
+
+type DeleteSavedSearchParams struct {
+	ID        int32
+	AppuserID int32
+}
+
+func (q *Queries) DeleteSavedSearch(ctx context.Context, arg DeleteSavedSearchParams) error {
+	_, err := q.db.Exec(ctx, deleteSavedSearch, arg.ID, arg.AppuserID)
+	return err
+}
+
+const getNotificationsForDigest = `-- name: GetNotificationsForDigest :many
+SELECT
+  n.id, n.appuser_id, n.saved_search_id, n.header, n.url, n.created, n.seen, n.emailed,
+  ss.name AS saved_search_name,
+  au.email,
+  COALESCE(al.language_id, 1)::int AS language_id
+FROM notification AS n
+JOIN saved_search AS ss
+  ON ss.id = n.saved_search_id
+JOIN appuser AS au
+  ON au.id = n.appuser_id
+LEFT JOIN appuser_language AS al
+  ON al.appuser_id = au.id
+WHERE ss.alert = 2
+  AND NOT n.emailed
+  AND NOT n.seen
+ORDER BY n.appuser_id, ss.name, n.created
+`
+
+type GetNotificationsForDigestRow struct {
+	ID              int32
+	AppuserID       int32
+	SavedSearchID   int32
+	Header          string
+	Url             string
+	Created         pgtype.Timestamptz
+	Seen            bool
+	Emailed         bool
+	SavedSearchName string
+	Email           string
+	LanguageID      int32
+}
+
+// Unseen notifications that have not been emailed, for searches with email alerts
+func (q *Queries) GetNotificationsForDigest(ctx context.Context) ([]GetNotificationsForDigestRow, error) {
+	rows, err := q.db.Query(ctx, getNotificationsForDigest)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNotificationsForDigestRow
+	for rows.Next() {
+		var i GetNotificationsForDigestRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppuserID,
+			&i.SavedSearchID,
+			&i.Header,
+			&i.Url,
+			&i.Created,
+			&i.Seen,
+			&i.Emailed,
+			&i.SavedSearchName,
+			&i.Email,
+			&i.LanguageID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationsForUser = `-- name: GetNotificationsForUser :many
+SELECT n.id, n.appuser_id, n.saved_search_id, n.header, n.url, n.created, n.seen, n.emailed, ss.name AS saved_search_name, ss.params AS saved_search_params
+FROM notification AS n
+JOIN saved_search AS ss
+  ON ss.id = n.saved_search_id
+WHERE n.appuser_id = $1
+ORDER BY n.created DESC
+LIMIT 200
+`
+
+type GetNotificationsForUserRow struct {
+	ID                int32
+	AppuserID         int32
+	SavedSearchID     int32
+	Header            string
+	Url               string
+	Created           pgtype.Timestamptz
+	Seen              bool
+	Emailed           bool
+	SavedSearchName   string
+	SavedSearchParams string
+}
+
+func (q *Queries) GetNotificationsForUser(ctx context.Context, appuserID int32) ([]GetNotificationsForUserRow, error) {
+	rows, err := q.db.Query(ctx, getNotificationsForUser, appuserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNotificationsForUserRow
+	for rows.Next() {
+		var i GetNotificationsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppuserID,
+			&i.SavedSearchID,
+			&i.Header,
+			&i.Url,
+			&i.Created,
+			&i.Seen,
+			&i.Emailed,
+			&i.SavedSearchName,
+			&i.SavedSearchParams,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSavedSearchesForUser = `-- name: GetSavedSearchesForUser :many
+SELECT id, appuser_id, name, params, alert, created, last_checked
+FROM saved_search
+WHERE appuser_id = $1
+ORDER BY name
+`
+
+func (q *Queries) GetSavedSearchesForUser(ctx context.Context, appuserID int32) ([]SavedSearch, error) {
+	rows, err := q.db.Query(ctx, getSavedSearchesForUser, appuserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SavedSearch
+	for rows.Next() {
+		var i SavedSearch
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppuserID,
+			&i.Name,
+			&i.Params,
+			&i.Alert,
+			&i.Created,
+			&i.LastChecked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSavedSearchesWithAlerts = `-- name: GetSavedSearchesWithAlerts :many
+SELECT
+  ss.id, ss.appuser_id, ss.name, ss.params, ss.alert, ss.created, ss.last_checked,
+  au.display_name,
+  au.email,
+  au.access_level,
+  COALESCE(al.language_id, 1)::int AS language_id
+FROM saved_search AS ss
+JOIN appuser AS au
+  ON au.id = ss.appuser_id
+LEFT JOIN appuser_language AS al
+  ON al.appuser_id = au.id
+WHERE ss.alert > 0
+ORDER BY ss.id
+`
+
+type GetSavedSearchesWithAlertsRow struct {
+	ID          int32
+	AppuserID   int32
+	Name        string
+	Params      string
+	Alert       int32
+	Created     pgtype.Timestamptz
+	LastChecked pgtype.Timestamptz
+	DisplayName string
+	Email       string
+	AccessLevel int32
+	LanguageID  int32
+}
+
+func (q *Queries) GetSavedSearchesWithAlerts(ctx context.Context) ([]GetSavedSearchesWithAlertsRow, error) {
+	rows, err := q.db.Query(ctx, getSavedSearchesWithAlerts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSavedSearchesWithAlertsRow
+	for rows.Next() {
+		var i GetSavedSearchesWithAlertsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppuserID,
+			&i.Name,
+			&i.Params,
+			&i.Alert,
+			&i.Created,
+			&i.LastChecked,
+			&i.DisplayName,
+			&i.Email,
+			&i.AccessLevel,
+			&i.LanguageID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markNotificationsSeen = `-- name: MarkNotificationsSeen :exec
+UPDATE notification
+SET seen = TRUE
+WHERE appuser_id = $1
+  AND NOT seen
+`
+
+func (q *Queries) MarkNotificationsSeen(ctx context.Context, appuserID int32) error {
+	_, err := q.db.Exec(ctx, markNotificationsSeen, appuserID)
+	return err
+}
+
+const setNotificationsEmailed = `-- name: SetNotificationsEmailed :exec
+UPDATE notification
+SET emailed = TRUE
+WHERE id = ANY($1::int[])
+`
+
+func (q *Queries) SetNotificationsEmailed(ctx context.Context, ids []int32) error {
+	_, err := q.db.Exec(ctx, setNotificationsEmailed, ids)
+	return err
+}
+
+const setSavedSearchAlert = `-- name: SetSavedSearchAlert :exec
+UPDATE saved_search
+SET alert = $1,
+    last_checked = CASE WHEN alert = 0 THEN NOW() ELSE last_checked END
+WHERE id = $2
+  AND appuser_id = $3
+`
+
+type SetSavedSearchAlertParams struct {
+	Alert     int32
+	ID        int32
+	AppuserID int32
+}
+
+// Matches indexed before the alert was turned on are not reported
+func (q *Queries) SetSavedSearchAlert(ctx context.Context, arg SetSavedSearchAlertParams) error {
+	_, err := q.db.Exec(ctx, setSavedSearchAlert, arg.Alert, arg.ID, arg.AppuserID)
+	return err
+}
+
+const setSavedSearchChecked = `-- name: SetSavedSearchChecked :exec
+UPDATE saved_search
+SET last_checked = $1
+WHERE id = $2
+`
+
+type SetSavedSearchCheckedParams struct {
+	LastChecked pgtype.Timestamptz
+	ID          int32
+}
+
+func (q *Queries) SetSavedSearchChecked(ctx context.Context, arg SetSavedSearchCheckedParams) error {
+	_, err := q.db.Exec(ctx, setSavedSearchChecked, arg.LastChecked, arg.ID)
+	return err
+}
+
+const upsertNotification = `-- name: UpsertNotification :exec
+INSERT INTO notification (appuser_id, saved_search_id, header, url)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (saved_search_id, url) DO UPDATE SET
+    header  = EXCLUDED.header,
+    created = NOW(),
+    seen    = FALSE,
+    emailed = FALSE
+`
+
+type UpsertNotificationParams struct {
+	AppuserID     int32
+	SavedSearchID int32
+	Header        string
+	Url           string
+}
+
+func (q *Queries) UpsertNotification(ctx context.Context, arg UpsertNotificationParams) error {
+	_, err := q.db.Exec(ctx, upsertNotification,
+		arg.AppuserID,
+		arg.SavedSearchID,
+		arg.Header,
+		arg.Url,
+	)
+	return err
+}
@@ -29,6 +29,191 @@ func (q *Queries) DeleteSearchEntry(ctx context.Context, arg DeleteSearchEntryPa
 	return err
 }
 
+const getJournalArchiveFiles = `-- name: GetJournalArchiveFiles :many
+SELECT f.id, f.uuid, f.creator, f.created, f.accessibility, f.filename, f.mimetype, f.size
+FROM file AS f
+JOIN patient_journal_archive AS pja
+  ON pja.file_id = f.id
+ORDER BY f.id
+`
+
+func (q *Queries) GetJournalArchiveFiles(ctx context.Context) ([]File, error) {
+	rows, err := q.db.Query(ctx, getJournalArchiveFiles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []File
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uuid,
+			&i.Creator,
+			&i.Created,
+			&i.Accessibility,
+			&i.Filename,
+			&i.Mimetype,
+			&i.Size,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSearchEntryBody = `-- name: GetSearchEntryBody :one
+SELECT
+  COALESCE(body, '')::TEXT AS body,
+  updated,
+  extra_data
+FROM search
+WHERE ns = $1
+  AND associated_url = $2
+  AND NOT skipped
+`
+
+type GetSearchEntryBodyParams struct {
+	Namespace     string
+	AssociatedUrl pgtype.Text
+}
+
+type GetSearchEntryBodyRow struct {
+	Body      string
+	Updated   pgtype.Timestamptz
+	ExtraData pgtype.Text
+}
+
+func (q *Queries) GetSearchEntryBody(ctx context.Context, arg GetSearchEntryBodyParams) (GetSearchEntryBodyRow, error) {
+	row := q.db.QueryRow(ctx, getSearchEntryBody, arg.Namespace, arg.AssociatedUrl)
+	var i GetSearchEntryBodyRow
+	err := row.Scan(&i.Body, &i.Updated, &i.ExtraData)
+	return i, err
+}
+
+const getSearchFolderSyncs = `-- name: GetSearchFolderSyncs :many
+SELECT folder_id, folder_name, last_sync, n_files, last_error
+FROM search_folder_sync
+`
+
+func (q *Queries) GetSearchFolderSyncs(ctx context.Context) ([]SearchFolderSync, error) {
+	rows, err := q.db.Query(ctx, getSearchFolderSyncs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchFolderSync
+	for rows.Next() {
+		var i SearchFolderSync
+		if err := rows.Scan(
+			&i.FolderID,
+			&i.FolderName,
+			&i.LastSync,
+			&i.NFiles,
+			&i.LastError,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSearchIndexStats = `-- name: GetSearchIndexStats :many
+SELECT
+  ns,
+  COUNT(*)::int                               AS n,
+  (COUNT(*) FILTER (WHERE skipped))::int      AS n_skipped,
+  MAX(updated)::timestamptz                   AS last_updated
+FROM search
+GROUP BY ns
+ORDER BY ns
+`
+
+type GetSearchIndexStatsRow struct {
+	Ns          string
+	N           int32
+	NSkipped    int32
+	LastUpdated pgtype.Timestamptz
+}
+
+func (q *Queries) GetSearchIndexStats(ctx context.Context) ([]GetSearchIndexStatsRow, error) {
+	rows, err := q.db.Query(ctx, getSearchIndexStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSearchIndexStatsRow
+	for rows.Next() {
+		var i GetSearchIndexStatsRow
+		if err := rows.Scan(
+			&i.Ns,
+			&i.N,
+			&i.NSkipped,
+			&i.LastUpdated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSearchSyncState = `-- name: GetSearchSyncState :one
+SELECT drive_id, page_token, last_incremental_sync, last_full_sync
+FROM search_sync_state
+WHERE drive_id = $1
+`
+
+func (q *Queries) GetSearchSyncState(ctx context.Context, driveID string) (SearchSyncState, error) {
+	row := q.db.QueryRow(ctx, getSearchSyncState, driveID)
+	var i SearchSyncState
+	err := row.Scan(
+		&i.DriveID,
+		&i.PageToken,
+		&i.LastIncrementalSync,
+		&i.LastFullSync,
+	)
+	return i, err
+}
+
+const getSearchURLs = `-- name: GetSearchURLs :many
+SELECT associated_url::TEXT
+FROM search
+WHERE ns = $1
+  AND associated_url IS NOT NULL
+`
+
+func (q *Queries) GetSearchURLs(ctx context.Context, namespace string) ([]string, error) {
+	rows, err := q.db.Query(ctx, getSearchURLs, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var associated_url string
+		if err := rows.Scan(&associated_url); err != nil {
+			return nil, err
+		}
+		items = append(items, associated_url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getSearchUpdatedTime = `-- name: GetSearchUpdatedTime :one
 SELECT updated
 FROM search
@@ -48,9 +233,114 @@ func (q *Queries) GetSearchUpdatedTime(ctx context.Context, arg GetSearchUpdated
 	return updated, err
 }
 
+const getSkippedSearchEntries = `-- name: GetSkippedSearchEntries :many
+SELECT
+  ns,
+  associated_url,
+  COALESCE(header, '')::text      AS header,
+  updated,
+  COALESCE(skip_reason, '')::text AS skip_reason,
+  extra_data
+FROM search
+WHERE skipped
+ORDER BY updated DESC
+`
+
+type GetSkippedSearchEntriesRow struct {
+	Ns            string
+	AssociatedUrl pgtype.Text
+	Header        string
+	Updated       pgtype.Timestamptz
+	SkipReason    string
+	ExtraData     pgtype.Text
+}
+
+func (q *Queries) GetSkippedSearchEntries(ctx context.Context) ([]GetSkippedSearchEntriesRow, error) {
+	rows, err := q.db.Query(ctx, getSkippedSearchEntries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSkippedSearchEntriesRow
+	for rows.Next() {
+		var i GetSkippedSearchEntriesRow
+		if err := rows.Scan(
+			&i.Ns,
+			&i.AssociatedUrl,
+			&i.Header,
+			&i.Updated,
+			&i.SkipReason,
+			&i.ExtraData,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUploadedFiles = `-- name: GetUploadedFiles :many
+SELECT f.id, f.uuid, f.creator, f.created, f.accessibility, f.filename, f.mimetype, f.size
+FROM file AS f
+WHERE NOT EXISTS (
+  SELECT 1
+  FROM patient_journal_archive AS pja
+  WHERE pja.file_id = f.id
+)
+ORDER BY f.id
+`
+
+// Files uploaded by users, without the journal archives, which only users
+// allowed to read the journal can see
+func (q *Queries) GetUploadedFiles(ctx context.Context) ([]File, error) {
+	rows, err := q.db.Query(ctx, getUploadedFiles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []File
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uuid,
+			&i.Creator,
+			&i.Created,
+			&i.Accessibility,
+			&i.Filename,
+			&i.Mimetype,
+			&i.Size,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resetSearchSyncPageToken = `-- name: ResetSearchSyncPageToken :exec
+UPDATE search_sync_state
+SET page_token = ''
+WHERE drive_id = $1
+`
+
+func (q *Queries) ResetSearchSyncPageToken(ctx context.Context, driveID string) error {
+	_, err := q.db.Exec(ctx, resetSearchSyncPageToken, driveID)
+	return err
+}
+
 const searchAdvanced = `-- name: SearchAdvanced :many
-WITH q AS (
-  SELECT websearch_to_tsquery($8::regconfig, $9::text) AS qry
+WITH qs AS (
+  SELECT
+    websearch_to_tsquery($8::regconfig, $13::text) AS qry_user,
+    websearch_to_tsquery($8::regconfig, $13::text)
+      || websearch_to_tsquery($9::regconfig, $13::text) AS qry_both
 )
 SELECT
   i.r_fts_header, i.r_fts_body, i.r_sim_header, i.r_sim_body, i.r_ilike_header, i.r_ilike_body, i.r_recency, i.header, i.body, i.header_headline, i.body_headline, i.ns, i.associated_url, i.created, i.updated, i.extra_data,
@@ -66,48 +356,78 @@ SELECT
 FROM (
   SELECT
     ($1::real   * ts_rank(s.fts_header, q.qry))::real AS r_fts_header,
-    ($2::real     * ts_rank(s.fts_body,   q.qry))::real AS r_fts_body,
+    ($2::real     * CASE WHEN v.body_visible THEN ts_rank(s.fts_body, q.qry) ELSE 0 END)::real AS r_fts_body,
     ($3::real   * f.sim_header)::real                 AS r_sim_header,
     ($4::real     * f.sim_body)::real                   AS r_sim_body,
     ($5::real * f.ilike_header)::real               AS r_ilike_header,
     ($6::real   * f.ilike_body)::real                 AS r_ilike_body,
     ($7::real      * f.recency)::real                    AS r_recency,
     COALESCE(s.header, '') AS header,
-    COALESCE(s.body, '') AS body,
-    ts_headline($8::regconfig, s.header, q.qry, 'StartSel=[START],StopSel=[STOP],HighlightAll=true')::text AS header_headline,
-    ts_headline($8::regconfig, s.body,   q.qry, 'StartSel=[START],StopSel=[STOP],MaxFragments=5,MinWords=3,MaxWords=10,FragmentDelimiter=[CUT]')::text AS body_headline,
+    CASE WHEN v.body_visible THEN COALESCE(s.body, '') ELSE '' END AS body,
+    ts_headline(COALESCE(s.lang, $8::regconfig), s.header, q.qry, 'StartSel=[START],StopSel=[STOP],HighlightAll=true')::text AS header_headline,
+    CASE WHEN v.body_visible
+      THEN ts_headline(COALESCE(s.lang, $8::regconfig), s.body, q.qry, 'StartSel=[START],StopSel=[STOP],MaxFragments=5,MinWords=3,MaxWords=10,FragmentDelimiter=[CUT]')
+      ELSE ''
+    END::text AS body_headline,
     s.ns,
     s.associated_url,
     s.created,
     s.updated,
     s.extra_data
   FROM search s
-  CROSS JOIN q
+  CROSS JOIN qs
+  -- The query for the language of the document, used for ranking and headlines
+  CROSS JOIN LATERAL (
+    SELECT CASE WHEN s.lang = $9::regconfig THEN qs.qry_both ELSE qs.qry_user END AS qry
+  ) q
+  LEFT JOIN search_facet sf
+    ON sf.ns = s.ns
+   AND sf.associated_url = s.associated_url
+  CROSS JOIN LATERAL (
+    SELECT search_body_visible(
+      s,
+      sf,
+      $10::boolean,
+      $11::boolean,
+      $12::int[]
+    ) AS body_visible
+  ) v
   CROSS JOIN LATERAL (
     SELECT
-      similarity(lower(s.header), lower($9)) AS sim_header,
-      similarity(lower(s.body),   lower($9)) AS sim_body,
-      CASE WHEN s.header ILIKE ('%' || $9 || '%') THEN 1 ELSE 0 END AS ilike_header,
-      CASE WHEN s.body   ILIKE ('%' || $9 || '%') THEN 1 ELSE 0 END AS ilike_body,
+      similarity(lower(s.header), lower($13)) AS sim_header,
+      CASE WHEN v.body_visible THEN similarity(lower(s.body), lower($13)) ELSE 0 END AS sim_body,
+      CASE WHEN s.header ILIKE ('%' || $13 || '%') THEN 1 ELSE 0 END AS ilike_header,
+      CASE WHEN v.body_visible AND s.body ILIKE ('%' || $13 || '%') THEN 1 ELSE 0 END AS ilike_body,
       exp(
         - GREATEST(0, EXTRACT(EPOCH FROM (now() - s.created))) /
-          ($10::real * 86400.0)
+          ($14::real * 86400.0)
       ) AS recency
   ) f
   WHERE search_match_advanced(
     s,
     q.qry,
-    $9,
-    $11::real,
-    $12::timestamptz,
-    $13::timestamptz,
-    $14::timestamptz,
-    $15::timestamptz
+    $13,
+    $15::real,
+    $16::timestamptz,
+    $17::timestamptz,
+    $18::timestamptz,
+    $19::timestamptz,
+    v.body_visible
   )
+  AND search_match_filters(
+    sf,
+    $20::text[],
+    $21::int[],
+    $22::int[],
+    $23::int[],
+    $24::boolean
+  )
+  AND search_visible(s, $25::int)
+  AND ($26::timestamptz IS NULL OR s.indexed > $26::timestamptz)
 ) i
 ORDER BY rank DESC
-LIMIT $17
-OFFSET $16
+LIMIT $28
+OFFSET $27
 `
 
 type SearchAdvancedParams struct {
@@ -119,6 +439,10 @@ type SearchAdvancedParams struct {
 	WIlikeBody          float32
 	WRecency            float32
 	Lang                string
+	OtherLang           string
+	AllBodies           bool
+	OtherBodies         bool
+	BodyHomeIds         []int32
 	Query               string
 	RecencyHalfLifeDays float32
 	Simthreshold        float32
@@ -126,6 +450,13 @@ type SearchAdvancedParams struct {
 	MaxCreated          pgtype.Timestamptz
 	MinUpdated          pgtype.Timestamptz
 	MaxUpdated          pgtype.Timestamptz
+	Namespaces          []string
+	SpeciesIds          []int32
+	HomeIds             []int32
+	Statuses            []int32
+	Active              pgtype.Bool
+	UserID              int32
+	MinIndexed          pgtype.Timestamptz
 	Offset              int32
 	Limit               int32
 }
@@ -160,6 +491,10 @@ func (q *Queries) SearchAdvanced(ctx context.Context, arg SearchAdvancedParams)
 		arg.WIlikeBody,
 		arg.WRecency,
 		arg.Lang,
+		arg.OtherLang,
+		arg.AllBodies,
+		arg.OtherBodies,
+		arg.BodyHomeIds,
 		arg.Query,
 		arg.RecencyHalfLifeDays,
 		arg.Simthreshold,
@@ -167,6 +502,13 @@ func (q *Queries) SearchAdvanced(ctx context.Context, arg SearchAdvancedParams)
 		arg.MaxCreated,
 		arg.MinUpdated,
 		arg.MaxUpdated,
+		arg.Namespaces,
+		arg.SpeciesIds,
+		arg.HomeIds,
+		arg.Statuses,
+		arg.Active,
+		arg.UserID,
+		arg.MinIndexed,
 		arg.Offset,
 		arg.Limit,
 	)
@@ -207,42 +549,90 @@ func (q *Queries) SearchAdvanced(ctx context.Context, arg SearchAdvancedParams)
 }
 
 const searchAdvancedCount = `-- name: SearchAdvancedCount :one
-WITH q AS (
-  SELECT websearch_to_tsquery($7::regconfig, $1::text) AS qry
+WITH qs AS (
+  SELECT
+    websearch_to_tsquery($17::regconfig, $5::text) AS qry_user,
+    websearch_to_tsquery($17::regconfig, $5::text)
+      || websearch_to_tsquery($1::regconfig, $5::text) AS qry_both
 )
 SELECT COUNT(*)::int AS n
 FROM search s
-CROSS JOIN q
+CROSS JOIN qs
+CROSS JOIN LATERAL (
+  SELECT CASE WHEN s.lang = $1::regconfig THEN qs.qry_both ELSE qs.qry_user END AS qry
+) q
+LEFT JOIN search_facet sf
+  ON sf.ns = s.ns
+ AND sf.associated_url = s.associated_url
+CROSS JOIN LATERAL (
+  SELECT search_body_visible(
+    s,
+    sf,
+    $2::boolean,
+    $3::boolean,
+    $4::int[]
+  ) AS body_visible
+) v
 WHERE search_match_advanced(
   s,
   q.qry,
-  $1,
-  $2::real,
-  $3::timestamptz,
-  $4::timestamptz,
-  $5::timestamptz,
-  $6::timestamptz
+  $5,
+  $6::real,
+  $7::timestamptz,
+  $8::timestamptz,
+  $9::timestamptz,
+  $10::timestamptz,
+  v.body_visible
+)
+AND search_match_filters(
+  sf,
+  $11::text[],
+  $12::int[],
+  $13::int[],
+  $14::int[],
+  $15::boolean
 )
+AND search_visible(s, $16::int)
 `
 
 type SearchAdvancedCountParams struct {
+	OtherLang    string
+	AllBodies    bool
+	OtherBodies  bool
+	BodyHomeIds  []int32
 	Query        string
 	Simthreshold float32
 	MinCreated   pgtype.Timestamptz
 	MaxCreated   pgtype.Timestamptz
 	MinUpdated   pgtype.Timestamptz
 	MaxUpdated   pgtype.Timestamptz
+	Namespaces   []string
+	SpeciesIds   []int32
+	HomeIds      []int32
+	Statuses     []int32
+	Active       pgtype.Bool
+	UserID       int32
 	Lang         string
 }
 
 func (q *Queries) SearchAdvancedCount(ctx context.Context, arg SearchAdvancedCountParams) (int32, error) {
 	row := q.db.QueryRow(ctx, searchAdvancedCount,
+		arg.OtherLang,
+		arg.AllBodies,
+		arg.OtherBodies,
+		arg.BodyHomeIds,
 		arg.Query,
 		arg.Simthreshold,
 		arg.MinCreated,
 		arg.MaxCreated,
 		arg.MinUpdated,
 		arg.MaxUpdated,
+		arg.Namespaces,
+		arg.SpeciesIds,
+		arg.HomeIds,
+		arg.Statuses,
+		arg.Active,
+		arg.UserID,
 		arg.Lang,
 	)
 	var n int32
@@ -250,9 +640,156 @@ func (q *Queries) SearchAdvancedCount(ctx context.Context, arg SearchAdvancedCou
 	return n, err
 }
 
+const searchAdvancedFacets = `-- name: SearchAdvancedFacets :many
+WITH qs AS (
+  SELECT
+    websearch_to_tsquery($1::regconfig, $2::text) AS qry_user,
+    websearch_to_tsquery($1::regconfig, $2::text)
+      || websearch_to_tsquery($3::regconfig, $2::text) AS qry_both
+),
+m AS (
+  SELECT
+    s.ns,
+    s.associated_url,
+    sf.species_id,
+    sf.home_id,
+    sf.status,
+    sf.active
+  FROM search s
+  CROSS JOIN qs
+  -- The query for the language of the document
+  CROSS JOIN LATERAL (
+    SELECT CASE WHEN s.lang = $3::regconfig THEN qs.qry_both ELSE qs.qry_user END AS qry
+  ) q
+  LEFT JOIN search_facet sf
+    ON sf.ns = s.ns
+   AND sf.associated_url = s.associated_url
+  CROSS JOIN LATERAL (
+    SELECT search_body_visible(
+      s,
+      sf,
+      $4::boolean,
+      $5::boolean,
+      $6::int[]
+    ) AS body_visible
+  ) v
+  WHERE search_match_advanced(
+    s,
+    q.qry,
+    $2,
+    $7::real,
+    $8::timestamptz,
+    $9::timestamptz,
+    $10::timestamptz,
+    $11::timestamptz,
+    v.body_visible
+  )
+  AND search_visible(s, $12::int)
+)
+SELECT 'namespace'::text AS facet, m.ns::text AS value, COUNT(*)::int AS n
+FROM m
+WHERE search_match_filters(m, NULL, $13::int[], $14::int[], $15::int[], $16::boolean)
+GROUP BY m.ns
+UNION ALL
+SELECT 'species'::text, m.species_id::text, COUNT(*)::int
+FROM m
+WHERE m.species_id IS NOT NULL
+  AND search_match_filters(m, $17::text[], NULL, $14::int[], $15::int[], $16::boolean)
+GROUP BY m.species_id
+UNION ALL
+SELECT 'home'::text, m.home_id::text, COUNT(*)::int
+FROM m
+WHERE m.home_id IS NOT NULL
+  AND search_match_filters(m, $17::text[], $13::int[], NULL, $15::int[], $16::boolean)
+GROUP BY m.home_id
+UNION ALL
+SELECT 'status'::text, m.status::text, COUNT(*)::int
+FROM m
+WHERE m.status IS NOT NULL
+  AND search_match_filters(m, $17::text[], $13::int[], $14::int[], NULL, $16::boolean)
+GROUP BY m.status
+UNION ALL
+SELECT 'active'::text, m.active::text, COUNT(*)::int
+FROM m
+WHERE m.ns = 'patient'
+  AND search_match_filters(m, $17::text[], $13::int[], $14::int[], $15::int[], NULL)
+GROUP BY m.active
+`
+
+type SearchAdvancedFacetsParams struct {
+	Lang         string
+	Query        string
+	OtherLang    string
+	AllBodies    bool
+	OtherBodies  bool
+	BodyHomeIds  []int32
+	Simthreshold float32
+	MinCreated   pgtype.Timestamptz
+	MaxCreated   pgtype.Timestamptz
+	MinUpdated   pgtype.Timestamptz
+	MaxUpdated   pgtype.Timestamptz
+	UserID       int32
+	SpeciesIds   []int32
+	HomeIds      []int32
+	Statuses     []int32
+	Active       pgtype.Bool
+	Namespaces   []string
+}
+
+type SearchAdvancedFacetsRow struct {
+	Facet string
+	Value string
+	N     int32
+}
+
+// Counts for each facet value among the matches. Each facet is counted with
+// every filter applied except its own, so that the alternatives stay visible.
+// Entries without facets are kept, and only fail to match when a facet
+// filter is set.
+func (q *Queries) SearchAdvancedFacets(ctx context.Context, arg SearchAdvancedFacetsParams) ([]SearchAdvancedFacetsRow, error) {
+	rows, err := q.db.Query(ctx, searchAdvancedFacets,
+		arg.Lang,
+		arg.Query,
+		arg.OtherLang,
+		arg.AllBodies,
+		arg.OtherBodies,
+		arg.BodyHomeIds,
+		arg.Simthreshold,
+		arg.MinCreated,
+		arg.MaxCreated,
+		arg.MinUpdated,
+		arg.MaxUpdated,
+		arg.UserID,
+		arg.SpeciesIds,
+		arg.HomeIds,
+		arg.Statuses,
+		arg.Active,
+		arg.Namespaces,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchAdvancedFacetsRow
+	for rows.Next() {
+		var i SearchAdvancedFacetsRow
+		if err := rows.Scan(&i.Facet, &i.Value, &i.N); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const searchBasic = `-- name: SearchBasic :many
-WITH q AS (
-  SELECT websearch_to_tsquery($3::regconfig, $6::text) AS qry
+WITH qs AS (
+  SELECT
+    websearch_to_tsquery($3::regconfig, $12::text) AS qry_user,
+    websearch_to_tsquery($3::regconfig, $12::text)
+      || websearch_to_tsquery($4::regconfig, $12::text) AS qry_both
 )
 SELECT
   i.r_fts_header, i.r_fts_body, i.header_headline, i.body_headline, i.ns, i.associated_url, i.created, i.updated, i.extra_data,
@@ -263,30 +800,57 @@ SELECT
 FROM (
   SELECT
     ($1::real   * ts_rank(s.fts_header, q.qry))::real AS r_fts_header,
-    ($2::real     * ts_rank(s.fts_body,   q.qry))::real AS r_fts_body,
-    ts_headline($3::regconfig, s.header, q.qry, 'StartSel=[START],StopSel=[STOP],HighlightAll=true')::text AS header_headline,
-    ts_headline($3::regconfig, s.body,   q.qry, 'StartSel=[START],StopSel=[STOP],MaxFragments=5,MinWords=3,MaxWords=10,FragmentDelimiter=[CUT]')::text AS body_headline,
+    ($2::real     * CASE WHEN v.body_visible THEN ts_rank(s.fts_body, q.qry) ELSE 0 END)::real AS r_fts_body,
+    ts_headline(COALESCE(s.lang, $3::regconfig), s.header, q.qry, 'StartSel=[START],StopSel=[STOP],HighlightAll=true')::text AS header_headline,
+    CASE WHEN v.body_visible
+      THEN ts_headline(COALESCE(s.lang, $3::regconfig), s.body, q.qry, 'StartSel=[START],StopSel=[STOP],MaxFragments=5,MinWords=3,MaxWords=10,FragmentDelimiter=[CUT]')
+      ELSE ''
+    END::text AS body_headline,
     s.ns,
     s.associated_url,
     s.created,
     s.updated,
     s.extra_data
   FROM search s
-  CROSS JOIN q
-  WHERE search_match_basic(s, q.qry)
+  CROSS JOIN qs
+  -- The query for the language of the document, used for ranking and headlines
+  CROSS JOIN LATERAL (
+    SELECT CASE WHEN s.lang = $4::regconfig THEN qs.qry_both ELSE qs.qry_user END AS qry
+  ) q
+  LEFT JOIN search_facet sf
+    ON sf.ns = s.ns
+   AND sf.associated_url = s.associated_url
+  CROSS JOIN LATERAL (
+    SELECT search_body_visible(
+      s,
+      sf,
+      $5::boolean,
+      $6::boolean,
+      $7::int[]
+    ) AS body_visible
+  ) v
+  WHERE search_match_basic_lang(s, qs.qry_user, qs.qry_both, $4::regconfig, v.body_visible)
+    AND search_visible(s, $8::int)
+    AND ($9::timestamptz IS NULL OR s.indexed > $9::timestamptz)
 ) i
 ORDER BY rank DESC
-LIMIT $5
-OFFSET $4
+LIMIT $11
+OFFSET $10
 `
 
 type SearchBasicParams struct {
-	WFtsHeader float32
-	WFtsBody   float32
-	Lang       string
-	Offset     int32
-	Limit      int32
-	Query      string
+	WFtsHeader  float32
+	WFtsBody    float32
+	Lang        string
+	OtherLang   string
+	AllBodies   bool
+	OtherBodies bool
+	BodyHomeIds []int32
+	UserID      int32
+	MinIndexed  pgtype.Timestamptz
+	Offset      int32
+	Limit       int32
+	Query       string
 }
 
 type SearchBasicRow struct {
@@ -307,6 +871,12 @@ func (q *Queries) SearchBasic(ctx context.Context, arg SearchBasicParams) ([]Sea
 		arg.WFtsHeader,
 		arg.WFtsBody,
 		arg.Lang,
+		arg.OtherLang,
+		arg.AllBodies,
+		arg.OtherBodies,
+		arg.BodyHomeIds,
+		arg.UserID,
+		arg.MinIndexed,
 		arg.Offset,
 		arg.Limit,
 		arg.Query,
@@ -341,27 +911,131 @@ func (q *Queries) SearchBasic(ctx context.Context, arg SearchBasicParams) ([]Sea
 }
 
 const searchBasicCount = `-- name: SearchBasicCount :one
-WITH q AS (
-  SELECT websearch_to_tsquery($1::regconfig, $2::text) AS qry
+WITH qs AS (
+  SELECT
+    websearch_to_tsquery($6::regconfig, $7::text) AS qry_user,
+    websearch_to_tsquery($6::regconfig, $7::text)
+      || websearch_to_tsquery($4::regconfig, $7::text) AS qry_both
 )
 SELECT COUNT(*)::int AS n
 FROM search s
-CROSS JOIN q
-WHERE search_match_basic(s, q.qry)
+CROSS JOIN qs
+LEFT JOIN search_facet sf
+  ON sf.ns = s.ns
+ AND sf.associated_url = s.associated_url
+CROSS JOIN LATERAL (
+  SELECT search_body_visible(
+    s,
+    sf,
+    $1::boolean,
+    $2::boolean,
+    $3::int[]
+  ) AS body_visible
+) v
+WHERE search_match_basic_lang(s, qs.qry_user, qs.qry_both, $4::regconfig, v.body_visible)
+  AND search_visible(s, $5::int)
 `
 
 type SearchBasicCountParams struct {
-	Lang  string
-	Query string
+	AllBodies   bool
+	OtherBodies bool
+	BodyHomeIds []int32
+	OtherLang   string
+	UserID      int32
+	Lang        string
+	Query       string
 }
 
 func (q *Queries) SearchBasicCount(ctx context.Context, arg SearchBasicCountParams) (int32, error) {
-	row := q.db.QueryRow(ctx, searchBasicCount, arg.Lang, arg.Query)
+	row := q.db.QueryRow(ctx, searchBasicCount,
+		arg.AllBodies,
+		arg.OtherBodies,
+		arg.BodyHomeIds,
+		arg.OtherLang,
+		arg.UserID,
+		arg.Lang,
+		arg.Query,
+	)
 	var n int32
 	err := row.Scan(&n)
 	return n, err
 }
 
+const setSearchFolderSyncError = `-- name: SetSearchFolderSyncError :exec
+INSERT INTO search_folder_sync (folder_id, last_error)
+VALUES ($1, $2)
+ON CONFLICT (folder_id) DO UPDATE SET
+    last_error = EXCLUDED.last_error
+`
+
+type SetSearchFolderSyncErrorParams struct {
+	FolderID  string
+	LastError pgtype.Text
+}
+
+func (q *Queries) SetSearchFolderSyncError(ctx context.Context, arg SetSearchFolderSyncErrorParams) error {
+	_, err := q.db.Exec(ctx, setSearchFolderSyncError, arg.FolderID, arg.LastError)
+	return err
+}
+
+const setSearchFolderSynced = `-- name: SetSearchFolderSynced :exec
+INSERT INTO search_folder_sync (folder_id, folder_name, last_sync, n_files, last_error)
+VALUES ($1, $2, NOW(), $3, NULL)
+ON CONFLICT (folder_id) DO UPDATE SET
+    folder_name = EXCLUDED.folder_name,
+    last_sync   = EXCLUDED.last_sync,
+    n_files     = EXCLUDED.n_files,
+    last_error  = NULL
+`
+
+type SetSearchFolderSyncedParams struct {
+	FolderID   string
+	FolderName string
+	NFiles     int32
+}
+
+func (q *Queries) SetSearchFolderSynced(ctx context.Context, arg SetSearchFolderSyncedParams) error {
+	_, err := q.db.Exec(ctx, setSearchFolderSynced, arg.FolderID, arg.FolderName, arg.NFiles)
+	return err
+}
+
+const setSearchSyncFull = `-- name: SetSearchSyncFull :exec
+INSERT INTO search_sync_state (drive_id, page_token, last_incremental_sync, last_full_sync)
+VALUES ($1, $2, NOW(), NOW())
+ON CONFLICT (drive_id) DO UPDATE SET
+    page_token            = EXCLUDED.page_token,
+    last_incremental_sync = EXCLUDED.last_incremental_sync,
+    last_full_sync        = EXCLUDED.last_full_sync
+`
+
+type SetSearchSyncFullParams struct {
+	DriveID   string
+	PageToken string
+}
+
+func (q *Queries) SetSearchSyncFull(ctx context.Context, arg SetSearchSyncFullParams) error {
+	_, err := q.db.Exec(ctx, setSearchSyncFull, arg.DriveID, arg.PageToken)
+	return err
+}
+
+const setSearchSyncIncremental = `-- name: SetSearchSyncIncremental :exec
+INSERT INTO search_sync_state (drive_id, page_token, last_incremental_sync)
+VALUES ($1, $2, NOW())
+ON CONFLICT (drive_id) DO UPDATE SET
+    page_token            = EXCLUDED.page_token,
+    last_incremental_sync = EXCLUDED.last_incremental_sync
+`
+
+type SetSearchSyncIncrementalParams struct {
+	DriveID   string
+	PageToken string
+}
+
+func (q *Queries) SetSearchSyncIncremental(ctx context.Context, arg SetSearchSyncIncrementalParams) error {
+	_, err := q.db.Exec(ctx, setSearchSyncIncremental, arg.DriveID, arg.PageToken)
+	return err
+}
+
 const updateSearchMetadata = `-- name: UpdateSearchMetadata :execresult
 UPDATE search
 SET
@@ -394,7 +1068,7 @@ func (q *Queries) UpdateSearchMetadata(ctx context.Context, arg UpdateSearchMeta
 }
 
 const upsertSearchEntry = `-- name: UpsertSearchEntry :exec
-INSERT INTO search (ns, associated_url, created, updated, header, body, lang, extra_data, skipped)
+INSERT INTO search (ns, associated_url, created, updated, header, body, lang, extra_data, skipped, owner_id)
 VALUES (
     $1,
     $2,
@@ -404,7 +1078,8 @@ VALUES (
     $6,
     $7,
     $8,
-    FALSE
+    FALSE,
+    $9
 )
 ON CONFLICT (ns, associated_url) DO UPDATE SET
     created        = EXCLUDED.created,
@@ -414,7 +1089,15 @@ ON CONFLICT (ns, associated_url) DO UPDATE SET
     lang           = EXCLUDED.lang,
     associated_url = EXCLUDED.associated_url,
     extra_data     = EXCLUDED.extra_data,
-    skipped        = EXCLUDED.skipped
+    skipped        = EXCLUDED.skipped,
+    skip_reason    = NULL,
+    owner_id       = EXCLUDED.owner_id,
+    indexed        = CASE
+        WHEN search.header IS DISTINCT FROM EXCLUDED.header
+          OR search.body   IS DISTINCT FROM EXCLUDED.body
+        THEN NOW()
+        ELSE search.indexed
+    END
 `
 
 type UpsertSearchEntryParams struct {
@@ -426,6 +1109,7 @@ type UpsertSearchEntryParams struct {
 	Body          pgtype.Text
 	Lang          interface{}
 	ExtraData     pgtype.Text
+	OwnerID       pgtype.Int4
 }
 
 func (q *Queries) UpsertSearchEntry(ctx context.Context, arg UpsertSearchEntryParams) error {
@@ -438,12 +1122,13 @@ func (q *Queries) UpsertSearchEntry(ctx context.Context, arg UpsertSearchEntryPa
 		arg.Body,
 		arg.Lang,
 		arg.ExtraData,
+		arg.OwnerID,
 	)
 	return err
 }
 
 const upsertSkippedSearchEntry = `-- name: UpsertSkippedSearchEntry :exec
-INSERT INTO search (ns, associated_url, created, updated, header, body, lang, extra_data, skipped)
+INSERT INTO search (ns, associated_url, created, updated, header, body, lang, extra_data, skipped, skip_reason, owner_id)
 VALUES (
   $1,
   $2,
@@ -453,7 +1138,9 @@ VALUES (
   $6,
   $7,
   $8,
-  TRUE
+  TRUE,
+  $9,
+  $10
 )
 ON CONFLICT (ns, associated_url) DO UPDATE SET
     created        = EXCLUDED.created,
@@ -463,7 +1150,9 @@ ON CONFLICT (ns, associated_url) DO UPDATE SET
     lang           = EXCLUDED.lang,
     associated_url = EXCLUDED.associated_url,
     extra_data     = EXCLUDED.extra_data,
-    skipped        = EXCLUDED.skipped
+    skipped        = EXCLUDED.skipped,
+    skip_reason    = EXCLUDED.skip_reason,
+    owner_id       = EXCLUDED.owner_id
 `
 
 type UpsertSkippedSearchEntryParams struct {
@@ -475,6 +1164,8 @@ type UpsertSkippedSearchEntryParams struct {
 	Body          pgtype.Text
 	Lang          interface{}
 	ExtraData     pgtype.Text
+	SkipReason    pgtype.Text
+	OwnerID       pgtype.Int4
 }
 
 func (q *Queries) UpsertSkippedSearchEntry(ctx context.Context, arg UpsertSkippedSearchEntryParams) error {
@@ -487,6 +1178,8 @@ func (q *Queries) UpsertSkippedSearchEntry(ctx context.Context, arg UpsertSkippe
 		arg.Body,
 		arg.Lang,
 		arg.ExtraData,
+		arg.SkipReason,
+		arg.OwnerID,
 	)
 	return err
 }
@@ -40,6 +40,18 @@ func (q *Queries) GetNameOfSpecies(ctx context.Context, arg GetNameOfSpeciesPara
 	return name, err
 }
 
+const getScientificNameOfSpecies = `-- name: GetScientificNameOfSpecies :one
+SELECT scientific_name FROM species
+WHERE id = $1
+`
+
+func (q *Queries) GetScientificNameOfSpecies(ctx context.Context, id int32) (string, error) {
+	row := q.db.QueryRow(ctx, getScientificNameOfSpecies, id)
+	var scientific_name string
+	err := row.Scan(&scientific_name)
+	return scientific_name, err
+}
+
 const getSpecies = `-- name: GetSpecies :many
 SELECT id, scientific_name FROM species
 ORDER BY id
@@ -28,3 +28,9 @@ searchForm.querySelector(".search-filter-clear-created").addEventListener("click
     });
     htmx.trigger(searchForm, "submit");
 }, { capture: true });
+
+// The search form updates the URL as the user types, so it has the current parameters
+let saveSearchForm = document.getElementById("save-search-form");
+saveSearchForm.addEventListener("submit", e => {
+    saveSearchForm.querySelector("[name='params']").value = location.search;
+});
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	maxExtractInputSize  = 32 * 1024 * 1024
+	maxExtractOutputSize = 1024 * 1024
+)
+
+// ExtractText pulls searchable text out of an uploaded file. Unsupported
+// formats give an empty string, so that the file can still be found by name.
+func ExtractText(mimeType, fileName string, r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxExtractInputSize))
+	if err != nil {
+		return "", err
+	}
+
+	var text string
+	ext := strings.ToLower(filepath.Ext(fileName))
+	switch {
+	case mimeType == "text/html" || ext == ".html" || ext == ".htm":
+		text, err = extractHTMLText(data)
+	case strings.HasPrefix(mimeType, "text/") || ext == ".md" || ext == ".txt":
+		text = string(data)
+	case mimeType == "application/pdf" || ext == ".pdf":
+		text = extractPDFText(data)
+	case strings.HasPrefix(mimeType, "image/"):
+		text = extractImageMetadata(data)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return sanitizeExtractedText(text), nil
+}
+
+// Make the text safe for storing in Postgres and cap its length.
+func sanitizeExtractedText(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.ReplaceAll(text, "\x00", "")
+	if len(text) > maxExtractOutputSize {
+		text = text[:maxExtractOutputSize]
+		for !utf8.ValidString(text) {
+			text = text[:len(text)-1]
+		}
+	}
+	return text
+}
+
+// ---- HTML
+
+func extractHTMLText(data []byte) (string, error) {
+	var b strings.Builder
+	z := html.NewTokenizer(bytes.NewReader(data))
+	skip := 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return b.String(), nil
+			}
+			return b.String(), z.Err()
+		case html.StartTagToken:
+			if name, _ := z.TagName(); isInvisibleHTMLTag(name) {
+				skip++
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if isInvisibleHTMLTag(name) && skip > 0 {
+				skip--
+			}
+			b.WriteString("\n")
+		case html.TextToken:
+			if skip == 0 {
+				b.Write(bytes.TrimSpace(z.Text()))
+				b.WriteString(" ")
+			}
+		}
+	}
+}
+
+func isInvisibleHTMLTag(name []byte) bool {
+	switch string(name) {
+	case "script", "style", "head", "template":
+		return true
+	}
+	return false
+}
+
+// ---- PDF
+
+var (
+	rePDFStream = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n`)
+	rePDFTextOp = regexp.MustCompile(`(?s)(\((?:\\.|[^\\)])*\)|\[(?:\\.|[^\]])*\])\s*(Tj|TJ|'|")|(T\*|\bTd\b|\bTD\b|\bET\b)`)
+	rePDFString = regexp.MustCompile(`(?s)\((?:\\.|[^\\)])*\)`)
+)
+
+// Best-effort text extraction from PDF content streams. Only handles text
+// drawn with literal strings, which covers most PDFs exported from office
+// software, but not PDFs with embedded CID fonts or scanned pages.
+func extractPDFText(data []byte) string {
+	var b strings.Builder
+	for _, loc := range rePDFStream.FindAllSubmatchIndex(data, -1) {
+		dict := data[loc[2]:loc[3]]
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		stream := data[start : start+end]
+
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			zr, err := zlib.NewReader(bytes.NewReader(stream))
+			if err != nil {
+				continue
+			}
+			stream, err = io.ReadAll(io.LimitReader(zr, maxExtractInputSize))
+			zr.Close()
+			if err != nil && len(stream) == 0 {
+				continue
+			}
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			// Other filters are mostly used for images
+			continue
+		}
+
+		for _, m := range rePDFTextOp.FindAllSubmatch(stream, -1) {
+			if len(m[3]) > 0 {
+				b.WriteString("\n")
+				continue
+			}
+			for _, s := range rePDFString.FindAll(m[1], -1) {
+				b.WriteString(decodePDFString(s[1 : len(s)-1]))
+			}
+			if string(m[2]) != "Tj" && string(m[2]) != "TJ" {
+				b.WriteString("\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+func decodePDFString(s []byte) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			b.WriteRune(rune(c))
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'b', 'f', '\n':
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			v := 0
+			for j := 0; j < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7'; j++ {
+				v = v*8 + int(s[i]-'0')
+				i++
+			}
+			i--
+			b.WriteRune(rune(v))
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// ---- Images
+
+var exifASCIITags = map[uint16]string{
+	0x010E: "ImageDescription",
+	0x010F: "Make",
+	0x0110: "Model",
+	0x0131: "Software",
+	0x0132: "DateTime",
+	0x013B: "Artist",
+	0x8298: "Copyright",
+	0x9003: "DateTimeOriginal",
+	0x9286: "UserComment",
+}
+
+const (
+	exifTagExifIFD     = 0x8769
+	exifTypeASCII      = 2
+	exifTypeLong       = 4
+	exifTypeUndefined  = 7
+	exifUserCommentTag = 0x9286
+)
+
+func extractImageMetadata(data []byte) string {
+	var lines []string
+	if cfg, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		lines = append(lines, fmt.Sprintf("Format: %s", format), fmt.Sprintf("Size: %dx%d", cfg.Width, cfg.Height))
+	}
+	if tiff := findJPEGExif(data); tiff != nil {
+		lines = append(lines, parseExif(tiff)...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returns the TIFF structure inside the Exif APP1 segment of a JPEG, or nil.
+func findJPEGExif(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil
+		}
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			return nil
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i += 2 + length
+	}
+	return nil
+}
+
+func parseExif(tiff []byte) []string {
+	if len(tiff) < 8 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+
+	var lines []string
+	var readIFD func(offset uint32, depth int)
+	readIFD = func(offset uint32, depth int) {
+		if depth > 2 || int(offset)+2 > len(tiff) {
+			return
+		}
+		n := int(order.Uint16(tiff[offset:]))
+		for i := range n {
+			entry := int(offset) + 2 + i*12
+			if entry+12 > len(tiff) {
+				return
+			}
+			tag := order.Uint16(tiff[entry:])
+			typ := order.Uint16(tiff[entry+2:])
+			count := order.Uint32(tiff[entry+4:])
+
+			if tag == exifTagExifIFD && typ == exifTypeLong {
+				readIFD(order.Uint32(tiff[entry+8:]), depth+1)
+				continue
+			}
+
+			name, ok := exifASCIITags[tag]
+			if !ok || (typ != exifTypeASCII && typ != exifTypeUndefined) {
+				continue
+			}
+			var value []byte
+			if count <= 4 {
+				value = tiff[entry+8 : entry+8+int(count)]
+			} else {
+				start := order.Uint32(tiff[entry+8:])
+				if uint64(start)+uint64(count) > uint64(len(tiff)) {
+					continue
+				}
+				value = tiff[start : start+count]
+			}
+			// User comments start with an 8 byte character code
+			if tag == exifUserCommentTag && len(value) >= 8 {
+				value = value[8:]
+			}
+			if s := strings.TrimSpace(strings.Trim(string(value), "\x00")); s != "" {
+				lines = append(lines, fmt.Sprintf("%s: %s", name, s))
+			}
+		}
+	}
+	readIFD(order.Uint32(tiff[4:]), 0)
+	return lines
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtractText(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		mimeType string
+		fileName string
+		// The fixture in testdata, or the contents if empty
+		fixture string
+		content string
+		want    string
+	}{
+		{
+			name:     "pdf with plain and compressed streams",
+			mimeType: "application/pdf",
+			fileName: "journal.pdf",
+			fixture:  "journal.pdf",
+			want:     "\nHedgehog admitted\nWeight (g): 450\n\nFed mealworms\n",
+		},
+		{
+			name:     "pdf by extension",
+			mimeType: "application/octet-stream",
+			fileName: "JOURNAL.PDF",
+			fixture:  "journal.pdf",
+			want:     "\nHedgehog admitted\nWeight (g): 450\n\nFed mealworms\n",
+		},
+		{
+			name:     "jpeg with exif",
+			mimeType: "image/jpeg",
+			fileName: "exif.jpg",
+			fixture:  "exif.jpg",
+			want:     "Format: jpeg\nSize: 4x3\nMake: Canon\nModel: EOS 80D\nDateTimeOriginal: 2025:05:01 12:00:00\nUserComment: Found in garden",
+		},
+		{
+			name:     "unsupported",
+			mimeType: "application/zip",
+			fileName: "archive.zip",
+			content:  "PK\x03\x04",
+			want:     "",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data := []byte(tc.content)
+			if tc.fixture != "" {
+				var err error
+				if data, err = os.ReadFile("testdata/" + tc.fixture); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := ExtractText(tc.mimeType, tc.fileName, bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ExtractText: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodePDFString(t *testing.T) {
+	for in, want := range map[string]string{
+		`plain`:         "plain",
+		`a\(b\)c`:       "a(b)c",
+		`line\nbreak`:   "line\nbreak",
+		`\101\102C`:     "ABC",
+		`\370l`:         "øl",
+		`back\\slash`:   `back\slash`,
+		"split\\\nline": "splitline",
+		`trailing\`:     `trailing\`,
+	} {
+		if got := decodePDFString([]byte(in)); got != want {
+			t.Errorf("decodePDFString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// Damaged files shouldn't make the parsers panic or read out of bounds
+func TestExtractTruncated(t *testing.T) {
+	for _, fixture := range []string{"journal.pdf", "exif.jpg"} {
+		data, err := os.ReadFile("testdata/" + fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := range len(data) {
+			extractPDFText(data[:n])
+			extractImageMetadata(data[:n])
+		}
+	}
+}
+
+func TestParseExifBigEndian(t *testing.T) {
+	// One IFD with a Make that fits in the value field
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8,
+		0, 1,
+		0x01, 0x0F, 0, 2, 0, 0, 0, 4, 'N', 'i', 'k', 0,
+		0, 0, 0, 0,
+	}
+	if got, want := parseExif(tiff), []string{"Make: Nik"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := parseExif([]byte("XX" + strings.Repeat("\x00", 10))); got != nil {
+		t.Errorf("got %q for unknown byte order, want nil", got)
+	}
+}
+
+func TestSanitizeExtractedText(t *testing.T) {
+	if got, want := sanitizeExtractedText("a\x00b\xffc"), "abc"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	long := strings.Repeat("ø", maxExtractOutputSize)
+	if got := sanitizeExtractedText(long); len(got) > maxExtractOutputSize || !strings.HasPrefix(long, got) {
+		t.Errorf("got %d bytes, want at most %d bytes of the input", len(got), maxExtractOutputSize)
+	}
+}
@@ -29,5 +29,15 @@ func (server *Server) getUserHandler(w http.ResponseWriter, r *http.Request) {
 	userView := user.ToUserView()
 	userView.Homes = SliceToSlice(homes, func(h Home) HomeView { return h.ToHomeView() })
 
-	UserPage(ctx, commonData, userView).Render(r.Context(), w)
+	// Saved searches are private
+	var savedSearches []SavedSearch
+	if user.ID == commonData.User.AppuserID {
+		savedSearches, err = server.Queries.GetSavedSearchesForUser(ctx, user.ID)
+		if err != nil {
+			server.renderError(w, r, commonData, err)
+			return
+		}
+	}
+
+	UserPage(ctx, commonData, userView, SliceToSlice(savedSearches, SavedSearch.ToSavedSearchView)).Render(r.Context(), w)
 }
@@ -115,6 +115,11 @@ func (server *Server) userDoScrubOrNukeHandler(w http.ResponseWriter, r *http.Re
 			data.Error(data.User.Language.AdminUserDeletionFailed, err)
 		} else {
 			data.Success(data.User.Language.AdminUserWasDeleted)
+			if server.GDriveWorker != nil {
+				if err := server.GDriveWorker.RevokeRemovedUser(ctx, email); err != nil {
+					LogR(r, "revoking Drive access for deleted user: %v", err)
+				}
+			}
 		}
 	} else {
 		data.Error(data.User.Language.AdminAbortedDueToWrongEmail, nil)
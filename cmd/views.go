@@ -99,6 +99,10 @@ type PatientView struct {
 	JournalURL   string
 	TimeCheckin  time.Time
 	TimeCheckout time.Time
+	// Set while a journal is being created in the background
+	JournalPending bool
+	// Set if creating a journal in the background failed
+	JournalError string
 }
 
 func PatientURL(id int32) string {
@@ -121,6 +125,10 @@ func (pv PatientView) AttachJournalID(prefix string) string {
 	return fmt.Sprintf("%spatient-attach-journal-%d", prefix, pv.ID)
 }
 
+func (pv PatientView) JournalCheckID(prefix string) string {
+	return fmt.Sprintf("%spatient-journal-check-%d", prefix, pv.ID)
+}
+
 func (pv PatientView) CardID(prefix string) string {
 	return fmt.Sprintf("%spatient-card_%d", prefix, pv.ID)
 }
@@ -356,8 +364,55 @@ func (dv DateView) Before(other DateView) bool {
 type PatientPageView struct {
 	Patient PatientView
 	Home    *HomeView
-	Events  []EventView
+	History PatientHistoryView
 	Homes   []HomeView
+	Similar []SimilarPatientView
+	// Registered at check-in, may be empty
+	Finder      string
+	IntakeCause string
+	// Templates to choose from when creating a journal, and the one for the species
+	JournalTemplates  []JournalTemplateView
+	JournalTemplateID int32
+	// Imported from tables in the journal
+	Measurements  []MeasurementView
+	PendingReview bool
+	// From the last check of the journal
+	JournalProblem       JournalProblem
+	JournalProblemDetail string
+	// Nil if there is nothing to show, or the user can't read the journal
+	JournalPreview *JournalPreviewView
+	// PDF copies made at checkout that the user can read
+	JournalArchives []FileView
+}
+
+// A former patient whose journal resembles the journal of another patient.
+type SimilarPatientView struct {
+	Patient     PatientView
+	SameSpecies bool
+	Similarity  float32
+}
+
+// Whole days between check-in and check-out, or -1 if unknown.
+func (spv SimilarPatientView) DaysInCare() int {
+	if spv.Patient.TimeCheckin.IsZero() || spv.Patient.TimeCheckout.IsZero() {
+		return -1
+	}
+	return int(spv.Patient.TimeCheckout.Sub(spv.Patient.TimeCheckin).Hours() / 24)
+}
+
+func (in GetSimilarPatientsRow) ToSimilarPatientView() SimilarPatientView {
+	return SimilarPatientView{
+		Patient: PatientView{
+			ID:           in.ID,
+			Status:       in.Status,
+			Name:         in.Name,
+			Species:      in.Species,
+			TimeCheckin:  in.TimeCheckin.Time,
+			TimeCheckout: in.TimeCheckout.Time,
+		},
+		SameSpecies: in.SameSpecies,
+		Similarity:  in.Similarity,
+	}
 }
 
 // ---- Event
@@ -375,9 +430,57 @@ func (ev *EventView) SetNoteURL() string {
 	return fmt.Sprintf("/event/%d/set-note", ev.Row.ID)
 }
 
+// One or more journal revisions made by the same editor in a short time.
+type JournalEditView struct {
+	Time time.Time
+	// Zero if the email of the editor doesn't belong to a bino user
+	User UserView
+	// Name and email in Google, either may be empty
+	Name  string
+	Email string
+	// Whether the editor is a member of the current home of the patient
+	InHome     bool
+	NRevisions int
+}
+
+func (e *JournalEditView) EditorName() string {
+	if e.User.Valid() {
+		return e.User.Name
+	}
+	if e.Name != "" {
+		return e.Name
+	}
+	return e.Email
+}
+
+// Summary of the edits made to a journal by one editor.
+type JournalEditorView struct {
+	// The most recent edit
+	Last   JournalEditView
+	NEdits int
+}
+
+// An event or a journal edit in the history of a patient.
+type PatientHistoryEntry struct {
+	Time time.Time
+	// Exactly one of these is set
+	Event *EventView
+	Edit  *JournalEditView
+}
+
+type PatientHistoryView struct {
+	Patient PatientView
+	Entries []PatientHistoryEntry
+	// Journal edits are fetched from Google Drive after the page has loaded
+	LoadEdits bool
+	Editors   []JournalEditorView
+	// Set if the journal edits could not be fetched
+	EditsError string
+}
+
 // ---- Match
 
-// ENUM(journal, patient)
+// ENUM(journal, patient, file)
 type MatchType string
 
 type MatchView struct {
@@ -391,6 +494,15 @@ type MatchView struct {
 	ExtraData string
 }
 
+// The header without highlighting.
+func (mv *MatchView) HeaderText() string {
+	var b strings.Builder
+	for _, run := range mv.HeaderRuns {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
 func parseJSON[T any](extraData string) *T {
 	var out T
 	if err := json.Unmarshal([]byte(extraData), &out); err != nil {
@@ -525,8 +637,9 @@ func HighlightFallback(text, query string) []HighlightRun {
 	lowerQuery := strings.ToLower(query)
 	var out []HighlightRun
 
+	// A query of only qualifiers has no text to highlight
 	pos := 0
-	for {
+	for query != "" {
 		i := strings.Index(lowerText[pos:], lowerQuery)
 		if i < 0 {
 			break
@@ -597,6 +710,19 @@ func (in *File) ToFileView() FileView {
 	}
 }
 
+func (in GetPatientJournalArchivesRow) ToFileView() FileView {
+	return FileView{
+		ID:               in.ID,
+		Creator:          in.Creator,
+		Accessibility:    FileAccessibility(in.Accessibility),
+		Created:          in.Created.Time,
+		UUID:             in.Uuid,
+		OriginalFileName: in.Filename,
+		MIMEType:         in.Mimetype,
+		Size:             in.Size,
+	}
+}
+
 func (in *FileView) FileInfo() FileInfo {
 	return FileInfo{
 		FileName: in.OriginalFileName,
@@ -645,3 +771,318 @@ func (in *FileView) FileSizeText() string {
 	s /= 1000
 	return fmt.Sprintf("%d GB", s)
 }
+
+// ---- Saved search
+
+type SavedSearchView struct {
+	ID      int32
+	Name    string
+	Params  string
+	Alert   SearchAlert
+	Created time.Time
+}
+
+func (in SavedSearch) ToSavedSearchView() SavedSearchView {
+	return SavedSearchView{
+		ID:      in.ID,
+		Name:    in.Name,
+		Params:  in.Params,
+		Alert:   SearchAlert(in.Alert),
+		Created: in.Created.Time,
+	}
+}
+
+func (ssv SavedSearchView) URL() string {
+	return "/search?" + ssv.Params
+}
+
+func (ssv SavedSearchView) URLSuffix(suffix string) string {
+	return fmt.Sprintf("/saved-search/%d/%s", ssv.ID, suffix)
+}
+
+type NotificationView struct {
+	ID             int32
+	Header         string
+	URL            string
+	Created        time.Time
+	Seen           bool
+	SavedSearchURL string
+	SavedSearch    string
+}
+
+func (in GetNotificationsForUserRow) ToNotificationView() NotificationView {
+	return NotificationView{
+		ID:             in.ID,
+		Header:         in.Header,
+		URL:            in.Url,
+		Created:        in.Created.Time,
+		Seen:           in.Seen,
+		SavedSearchURL: "/search?" + in.SavedSearchParams,
+		SavedSearch:    in.SavedSearchName,
+	}
+}
+
+// A journal stored in bino by the Markdown journal backend.
+type JournalView struct {
+	ID            int32
+	Title         string
+	Content       string
+	Created       time.Time
+	Updated       time.Time
+	UpdatedByName string
+	Version       int32
+	// Patients the journal is attached to
+	Patients []PatientView
+}
+
+func (in GetMarkdownJournalRow) ToJournalView() JournalView {
+	return JournalView{
+		ID:            in.ID,
+		Title:         in.Title,
+		Content:       in.Content,
+		Created:       in.Created.Time,
+		Updated:       in.Updated.Time,
+		UpdatedByName: in.UpdatedByName,
+		Version:       in.Version,
+	}
+}
+
+func (in GetMarkdownJournalsRow) ToJournalView() JournalView {
+	return JournalView{
+		ID:      in.ID,
+		Title:   in.Title,
+		Created: in.Created.Time,
+		Updated: in.Updated.Time,
+	}
+}
+
+func (jv JournalView) URL() string {
+	return MarkdownJournalURL(jv.ID)
+}
+
+func (jv JournalView) HTML() string {
+	return renderMarkdown(jv.Content)
+}
+
+// A Google Drive task in the durable queue.
+type GDriveTaskView struct {
+	ID          int32
+	Type        GDriveTaskRequestID
+	Status      GDriveTaskStatus
+	Attempts    int32
+	NextAttempt time.Time
+	LastError   string
+	Patient     PatientView
+	User        string
+	Created     time.Time
+	Updated     time.Time
+}
+
+func (in GetGDriveTasksRow) ToGDriveTaskView() GDriveTaskView {
+	return GDriveTaskView{
+		ID:          in.ID,
+		Type:        GDriveTaskRequestID(in.Type),
+		Status:      GDriveTaskStatus(in.Status),
+		Attempts:    in.Attempts,
+		NextAttempt: in.NextAttempt.Time,
+		LastError:   in.LastError.String,
+		Patient: PatientView{
+			ID:   in.PatientID.Int32,
+			Name: in.PatientName,
+		},
+		User:    in.AppuserName,
+		Created: in.Created.Time,
+		Updated: in.Updated.Time,
+	}
+}
+
+func (tv GDriveTaskView) URLSuffix(suffix string) string {
+	return fmt.Sprintf("/gdrive/tasks/%d/%s", tv.ID, suffix)
+}
+
+// How far creating a journal for a patient has come.
+type JournalCreationProgressView struct {
+	Patient PatientView
+	// False if the journal wasn't created by the task queue
+	HasTask     bool
+	Status      GDriveTaskStatus
+	Attempts    int32
+	NextAttempt time.Time
+	LastError   string
+}
+
+func (in GetJournalCreationProgressRow) ToJournalCreationProgressView() JournalCreationProgressView {
+	return JournalCreationProgressView{
+		Patient: PatientView{
+			ID:         in.ID,
+			Name:       in.Name,
+			JournalURL: in.JournalUrl,
+		},
+		HasTask:     in.Status.Valid,
+		Status:      GDriveTaskStatus(in.Status.Int32),
+		Attempts:    in.Attempts.Int32,
+		NextAttempt: in.NextAttempt.Time,
+		LastError:   in.LastError.String,
+	}
+}
+
+func (jcp JournalCreationProgressView) Done() bool {
+	return jcp.Patient.JournalURL != ""
+}
+
+func (jcp JournalCreationProgressView) Pending() bool {
+	return !jcp.Done() && jcp.HasTask && (jcp.Status == GDriveTaskStatusPending || jcp.Status == GDriveTaskStatusRunning)
+}
+
+type JournalTemplateView struct {
+	ID              int32
+	Name            string
+	GDriveFile      string
+	Variables       []string
+	Validated       time.Time
+	ValidationError string
+}
+
+func (in JournalTemplate) ToJournalTemplateView() JournalTemplateView {
+	return JournalTemplateView{
+		ID:              in.ID,
+		Name:            in.Name,
+		GDriveFile:      in.GdriveFile,
+		Variables:       in.Variables,
+		Validated:       in.Validated.Time,
+		ValidationError: in.ValidationError.String,
+	}
+}
+
+func (tv JournalTemplateView) DocumentURL() string {
+	return "https://docs.google.com/document/d/" + tv.GDriveFile
+}
+
+func (tv JournalTemplateView) URLSuffix(suffix string) string {
+	return fmt.Sprintf("/journal-templates/%d/%s", tv.ID, suffix)
+}
+
+type MeasurementView struct {
+	Kind MeasurementKind
+	Date time.Time
+	// Weight in grams
+	Value float64
+	Note  string
+}
+
+func (in PatientMeasurement) ToMeasurementView() MeasurementView {
+	return MeasurementView{
+		Kind:  MeasurementKind(in.Kind),
+		Date:  in.Measured.Time,
+		Value: in.Value,
+		Note:  in.Note,
+	}
+}
+
+func (mv MeasurementView) ValueString() string {
+	if mv.Kind == MeasurementKindWeight {
+		return fmt.Sprintf("%g g", mv.Value)
+	}
+	return mv.Note
+}
+
+type JournalImportReviewView struct {
+	ID        int32
+	Patient   PatientView
+	Kind      MeasurementKind
+	Problem   JournalImportProblem
+	Raw       string
+	SourceURL string
+	Created   time.Time
+}
+
+func (in GetPendingJournalImportReviewsRow) ToJournalImportReviewView() JournalImportReviewView {
+	return JournalImportReviewView{
+		ID: in.ID,
+		Patient: PatientView{
+			ID:   in.PatientID,
+			Name: in.PatientName,
+		},
+		Kind:      MeasurementKind(in.Kind),
+		Problem:   JournalImportProblem(in.Problem),
+		Raw:       in.RawRow,
+		SourceURL: in.SourceUrl,
+		Created:   in.Created.Time,
+	}
+}
+
+func (rv JournalImportReviewView) URLSuffix(suffix string) string {
+	return fmt.Sprintf("/journal-import/review/%d/%s", rv.ID, suffix)
+}
+
+// A journal in the journal folders that isn't attached to any patient.
+type OrphanJournalView struct {
+	URL        string
+	Title      string
+	FolderURL  string
+	FolderName string
+	Created    time.Time
+	Updated    time.Time
+	Hints      JournalTitleHints
+}
+
+func (in GetOrphanJournalsRow) ToOrphanJournalView(species []SpeciesView) OrphanJournalView {
+	var info SearchJournalInfo
+	if in.ExtraData.Valid {
+		_ = json.Unmarshal([]byte(in.ExtraData.String), &info)
+	}
+	return OrphanJournalView{
+		URL:        in.Url,
+		Title:      in.Header,
+		FolderURL:  info.FolderURL,
+		FolderName: info.FolderName,
+		Created:    in.Created.Time,
+		Updated:    in.Updated.Time,
+		Hints:      parseJournalTitle(in.Header, species),
+	}
+}
+
+// A patient whose journal could not be opened when it was last checked.
+type JournalProblemView struct {
+	Patient PatientView
+	Problem JournalProblem
+	Detail  string
+	Checked time.Time
+}
+
+func (in GetPatientsWithJournalProblemsRow) ToJournalProblemView() JournalProblemView {
+	return JournalProblemView{
+		Patient: PatientView{
+			ID:         in.PatientID,
+			Name:       in.Name,
+			JournalURL: in.JournalUrl,
+		},
+		Problem: JournalProblem(in.Problem),
+		Detail:  in.Detail,
+		Checked: in.Checked.Time,
+	}
+}
+
+// A setting from config.json that can be changed from the admin pages.
+type RuntimeSettingView struct {
+	Setting RuntimeSetting
+	Value   string
+	// The value in config.json
+	Default string
+	// Zero if the setting hasn't been changed from config.json
+	Changed   time.Time
+	ChangedBy string
+}
+
+type RuntimeSettingsView struct {
+	Settings []RuntimeSettingView
+	// Whether the Drive settings are in use
+	GDrive bool
+}
+
+// The journal as of the last time it was indexed for search.
+type JournalPreviewView struct {
+	// Sanitised, see renderMarkdown
+	HTML    string
+	Updated time.Time
+}
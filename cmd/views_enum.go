@@ -77,6 +77,8 @@ const (
 	MatchTypeJournal MatchType = "journal"
 	// MatchTypePatient is a MatchType of type patient.
 	MatchTypePatient MatchType = "patient"
+	// MatchTypeFile is a MatchType of type file.
+	MatchTypeFile MatchType = "file"
 )
 
 var ErrInvalidMatchType = errors.New("not a valid MatchType")
@@ -86,6 +88,7 @@ func MatchTypeValues() []MatchType {
 	return []MatchType{
 		MatchTypeJournal,
 		MatchTypePatient,
+		MatchTypeFile,
 	}
 }
 
@@ -104,6 +107,7 @@ func (x MatchType) IsValid() bool {
 var _MatchTypeValue = map[string]MatchType{
 	"journal": MatchTypeJournal,
 	"patient": MatchTypePatient,
+	"file":    MatchTypeFile,
 }
 
 // ParseMatchType attempts to convert a string to a MatchType.
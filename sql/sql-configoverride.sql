@@ -0,0 +1,23 @@
+-- name: GetConfigOverrides :many
+SELECT
+  rs.*,
+  au.display_name AS updated_by_name
+FROM config_override AS rs
+LEFT JOIN appuser AS au
+  ON au.id = rs.updated_by
+ORDER BY rs.key
+;
+
+-- name: SetConfigOverride :exec
+INSERT INTO config_override (key, value, updated_by)
+VALUES (@key, @value, @updated_by)
+ON CONFLICT (key) DO UPDATE
+SET value = EXCLUDED.value,
+    updated = NOW(),
+    updated_by = EXCLUDED.updated_by
+;
+
+-- name: DeleteConfigOverride :exec
+DELETE FROM config_override
+WHERE key = @key
+;
@@ -27,3 +27,35 @@ SELECT *
 FROM file
 WHERE id = @id
 ;
+
+-- name: AddPatientJournalArchive :one
+WITH f AS (
+  INSERT
+  INTO file
+    (uuid, accessibility, creator, created, filename, mimetype, size)
+  VALUES
+    (@uuid, @accessibility, @creator, @created, @filename, @mimetype, @size)
+  RETURNING id
+)
+INSERT
+INTO patient_journal_archive
+  (patient_id, file_id, journal_url, home_id)
+SELECT @patient_id, f.id, @journal_url, sqlc.narg('home_id')
+FROM f
+RETURNING file_id
+;
+
+-- name: GetPatientJournalArchives :many
+SELECT pja.home_id, f.*
+FROM patient_journal_archive AS pja
+JOIN file AS f
+  ON f.id = pja.file_id
+WHERE pja.patient_id = @patient_id
+ORDER BY f.created DESC
+;
+
+-- name: GetPatientJournalArchiveByFile :one
+SELECT *
+FROM patient_journal_archive
+WHERE file_id = @file_id
+;
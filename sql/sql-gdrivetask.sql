@@ -0,0 +1,162 @@
+-- name: EnqueueGDriveTask :one
+INSERT INTO gdrive_task (type, payload, idempotency_key, patient_id, appuser_id)
+VALUES (@type, @payload, @idempotency_key, sqlc.narg('patient_id'), sqlc.narg('appuser_id'))
+ON CONFLICT (idempotency_key) DO UPDATE SET
+    type         = EXCLUDED.type,
+    payload      = EXCLUDED.payload,
+    patient_id   = EXCLUDED.patient_id,
+    appuser_id   = EXCLUDED.appuser_id,
+    -- A running task keeps running, and runs again when it's finished
+    rerun        = gdrive_task.status = 1,
+    status       = CASE WHEN gdrive_task.status = 1 THEN 1 ELSE 0 END,
+    attempts     = 0,
+    next_attempt = NOW(),
+    last_error   = NULL,
+    result       = NULL,
+    created      = NOW(),
+    updated      = NOW()
+  -- Finished tasks can be run again, and running tasks if asked to. Pending
+  -- tasks are left alone.
+  WHERE gdrive_task.status IN (2, 3)
+     OR (@rerun_running::BOOLEAN AND gdrive_task.status = 1)
+RETURNING *
+;
+
+-- name: GetGDriveTaskByKey :one
+SELECT *
+FROM gdrive_task
+WHERE idempotency_key = @idempotency_key
+;
+
+-- name: ClaimGDriveTask :one
+UPDATE gdrive_task
+SET status   = 1,
+    attempts = attempts + 1,
+    updated  = NOW()
+WHERE id = (
+    SELECT id
+    FROM gdrive_task
+    WHERE status = 0
+      AND next_attempt <= NOW()
+    ORDER BY next_attempt, id
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *
+;
+
+-- name: ResetRunningGDriveTasks :exec
+UPDATE gdrive_task
+SET status  = 0,
+    rerun   = FALSE,
+    updated = NOW()
+WHERE status = 1
+;
+
+-- name: SetGDriveTaskDone :exec
+-- Tasks queued again while running go back to pending
+UPDATE gdrive_task
+SET status       = CASE WHEN rerun THEN 0 ELSE 2 END,
+    attempts     = CASE WHEN rerun THEN 0 ELSE attempts END,
+    next_attempt = NOW(),
+    rerun        = FALSE,
+    result       = @result,
+    last_error   = NULL,
+    updated      = NOW()
+WHERE id = @id
+;
+
+-- name: SetGDriveTaskRetry :exec
+UPDATE gdrive_task
+SET status       = 0,
+    rerun        = FALSE,
+    next_attempt = @next_attempt,
+    last_error   = @last_error,
+    updated      = NOW()
+WHERE id = @id
+;
+
+-- name: SetGDriveTaskFailed :exec
+-- Tasks queued again while running get another go with the new payload
+UPDATE gdrive_task
+SET status       = CASE WHEN rerun THEN 0 ELSE 3 END,
+    attempts     = CASE WHEN rerun THEN 0 ELSE attempts END,
+    next_attempt = NOW(),
+    rerun        = FALSE,
+    last_error   = @last_error,
+    updated      = NOW()
+WHERE id = @id
+;
+
+-- name: RetryGDriveTask :execrows
+UPDATE gdrive_task
+SET status       = 0,
+    attempts     = 0,
+    next_attempt = NOW(),
+    updated      = NOW()
+WHERE id = @id
+  AND status = 3
+;
+
+-- name: GetGDriveTasks :many
+SELECT
+  t.*,
+  COALESCE(p.name, '')::TEXT AS patient_name,
+  COALESCE(a.display_name, '')::TEXT AS appuser_name
+FROM gdrive_task AS t
+LEFT JOIN patient AS p ON p.id = t.patient_id
+LEFT JOIN appuser AS a ON a.id = t.appuser_id
+WHERE t.status IN (0, 1, 3)
+   OR t.updated > NOW() - INTERVAL '7 days'
+ORDER BY t.status = 2, t.updated DESC
+LIMIT 500
+;
+
+-- name: GetUnfinishedGDriveTasksForPatients :many
+SELECT
+  patient_id::INT AS patient_id,
+  type,
+  status,
+  last_error
+FROM gdrive_task
+WHERE patient_id = ANY(@patient_ids::INT[])
+  AND status IN (0, 1, 3)
+;
+
+-- name: AddImportBatch :one
+INSERT INTO import_batch (appuser_id)
+VALUES (@appuser_id)
+RETURNING id
+;
+
+-- name: AddImportBatchPatients :exec
+INSERT INTO import_batch_patient (batch_id, patient_id)
+SELECT @batch_id::INT, UNNEST(@patient_ids::INT[])
+;
+
+-- Batches are only shown while the import-request cookie lives, which is an
+-- hour
+-- name: DeleteOldImportBatches :exec
+DELETE FROM import_batch
+WHERE created < NOW() - INTERVAL '1 day'
+;
+
+-- name: GetJournalCreationProgress :many
+SELECT
+  p.id,
+  p.name,
+  COALESCE(p.journal_url, '')::TEXT AS journal_url,
+  t.status,
+  t.attempts,
+  t.next_attempt,
+  t.last_error
+FROM import_batch AS b
+JOIN import_batch_patient AS bp ON bp.batch_id = b.id
+JOIN patient AS p ON p.id = bp.patient_id
+LEFT JOIN gdrive_task AS t
+  ON t.patient_id = p.id
+ AND t.type = @task_type
+WHERE b.id = @batch_id
+  AND b.appuser_id = @appuser_id
+ORDER BY p.id
+;
@@ -0,0 +1,118 @@
+-- name: CreateMarkdownJournal :one
+INSERT INTO journal (title, content)
+VALUES (@title, @content)
+RETURNING *
+;
+
+-- name: GetMarkdownJournal :one
+SELECT
+  j.*,
+  COALESCE(a.display_name, '')::TEXT AS updated_by_name
+FROM journal AS j
+LEFT JOIN appuser AS a ON a.id = j.updated_by
+WHERE j.id = @id
+;
+
+-- name: GetMarkdownJournals :many
+SELECT
+  j.id,
+  j.title,
+  j.created,
+  j.updated
+FROM journal AS j
+ORDER BY j.updated DESC
+;
+
+-- name: GetMarkdownJournalsChangedSince :many
+SELECT *
+FROM journal
+WHERE change_xid >= @since::BIGINT
+ORDER BY change_xid, id
+;
+
+-- name: GetMarkdownJournalChangeCursor :one
+-- Every transaction before this one has committed or aborted
+SELECT pg_snapshot_xmin(pg_current_snapshot())::TEXT::BIGINT AS cursor
+;
+
+-- name: UpdateMarkdownJournal :execrows
+UPDATE journal
+SET title      = @title,
+    content    = @content,
+    updated    = NOW(),
+    updated_by = @updated_by,
+    version    = version + 1,
+    change_xid = pg_current_xact_id()::TEXT::BIGINT
+WHERE id = @id
+  AND version = @version
+;
+
+-- name: SetMarkdownJournalTitle :exec
+UPDATE journal
+SET title      = @title,
+    updated    = NOW(),
+    version    = version + 1,
+    change_xid = pg_current_xact_id()::TEXT::BIGINT
+WHERE id = @id
+  AND title <> @title
+;
+
+-- name: GetOrphanJournals :many
+SELECT
+  s.associated_url::TEXT       AS url,
+  COALESCE(s.header, '')::TEXT AS header,
+  s.created,
+  s.updated,
+  s.extra_data
+FROM search AS s
+WHERE s.ns = 'journal'
+  AND NOT EXISTS (
+    SELECT 1
+    FROM patient AS p
+    WHERE p.journal_url = s.associated_url
+  )
+ORDER BY s.created DESC NULLS LAST, s.associated_url
+;
+
+-- name: GetActivePatientJournals :many
+SELECT
+  p.id,
+  p.journal_url::TEXT AS journal_url
+FROM patient AS p
+WHERE p.curr_home_id IS NOT NULL
+  AND p.journal_url IS NOT NULL
+  AND p.journal_url <> ''
+ORDER BY p.id
+;
+
+-- name: SetPatientJournalCheck :exec
+INSERT INTO patient_journal_check (patient_id, journal_url, problem, detail, checked)
+VALUES (@patient_id, @journal_url, @problem, @detail, NOW())
+ON CONFLICT (patient_id) DO UPDATE SET
+    journal_url = EXCLUDED.journal_url,
+    problem     = EXCLUDED.problem,
+    detail      = EXCLUDED.detail,
+    checked     = EXCLUDED.checked
+;
+
+-- name: GetPatientJournalCheck :one
+SELECT pjc.*
+FROM patient_journal_check AS pjc
+INNER JOIN patient AS p
+  ON p.id = pjc.patient_id
+ AND p.journal_url = pjc.journal_url
+WHERE pjc.patient_id = @patient_id
+;
+
+-- name: GetPatientsWithJournalProblems :many
+SELECT
+  pjc.*,
+  p.name
+FROM patient_journal_check AS pjc
+INNER JOIN patient AS p
+  ON p.id = pjc.patient_id
+ AND p.journal_url = pjc.journal_url
+WHERE pjc.problem <> 0
+  AND p.curr_home_id IS NOT NULL
+ORDER BY pjc.checked DESC
+;
@@ -0,0 +1,56 @@
+-- name: GetJournalTemplates :many
+SELECT *
+FROM journal_template
+ORDER BY name
+;
+
+-- name: GetJournalTemplate :one
+SELECT *
+FROM journal_template
+WHERE id = @id
+;
+
+-- name: AddJournalTemplate :one
+INSERT INTO journal_template (name, gdrive_file)
+VALUES (@name, @gdrive_file)
+RETURNING id
+;
+
+-- name: DeleteJournalTemplate :execrows
+DELETE FROM journal_template
+WHERE id = @id
+;
+
+-- name: SetJournalTemplateValidation :exec
+UPDATE journal_template
+SET variables        = @variables,
+    validated        = NOW(),
+    validation_error = sqlc.narg('validation_error')
+WHERE id = @id
+;
+
+-- name: GetSpeciesJournalTemplates :many
+SELECT *
+FROM species_journal_template
+;
+
+-- name: GetJournalTemplateForSpecies :one
+-- Templates that failed validation are skipped, so the default template is used instead
+SELECT jt.*
+FROM journal_template AS jt
+  INNER JOIN species_journal_template AS sjt ON sjt.template_id = jt.id
+WHERE sjt.species_id = @species_id
+  AND jt.validation_error IS NULL
+;
+
+-- name: SetSpeciesJournalTemplate :exec
+INSERT INTO species_journal_template (species_id, template_id)
+VALUES (@species_id, @template_id)
+ON CONFLICT (species_id) DO UPDATE SET
+    template_id = EXCLUDED.template_id
+;
+
+-- name: DeleteSpeciesJournalTemplate :exec
+DELETE FROM species_journal_template
+WHERE species_id = @species_id
+;
@@ -0,0 +1,86 @@
+-- name: AddPatientMeasurement :exec
+-- Measurements accepted in a review are not replaced by imports
+INSERT INTO patient_measurement (patient_id, kind, measured, value, note, source_url, source_raw, reviewed)
+VALUES (@patient_id, @kind, @measured, @value, @note, @source_url, @source_raw, @reviewed)
+ON CONFLICT (patient_id, source_url, kind, source_raw) DO UPDATE SET
+    measured = EXCLUDED.measured,
+    value    = EXCLUDED.value,
+    note     = EXCLUDED.note,
+    reviewed = EXCLUDED.reviewed
+WHERE EXCLUDED.reviewed
+   OR NOT patient_measurement.reviewed
+;
+
+-- name: DeleteRemovedPatientMeasurements :exec
+-- Imported measurements whose row is no longer in the journal
+DELETE FROM patient_measurement AS pm
+WHERE pm.patient_id = @patient_id
+  AND pm.source_url = @source_url
+  AND NOT pm.reviewed
+  AND NOT EXISTS (
+    SELECT 1
+    FROM (
+      SELECT UNNEST(@kinds::INT[]) AS kind, UNNEST(@raw_rows::TEXT[]) AS raw_row
+    ) AS r
+    WHERE r.kind = pm.kind
+      AND r.raw_row = pm.source_raw
+  )
+;
+
+-- name: GetPatientMeasurements :many
+SELECT *
+FROM patient_measurement
+WHERE patient_id = @patient_id
+ORDER BY measured, kind, id
+;
+
+-- name: AddJournalImportReview :exec
+INSERT INTO journal_import_review (patient_id, kind, problem, raw_row, source_url)
+VALUES (@patient_id, @kind, @problem, @raw_row, @source_url)
+ON CONFLICT (patient_id, kind, raw_row) DO NOTHING
+;
+
+-- name: DeleteRemovedJournalImportReviews :exec
+-- Pending reviews of rows that are no longer in the journal
+DELETE FROM journal_import_review AS jir
+WHERE jir.patient_id = @patient_id
+  AND jir.source_url = @source_url
+  AND jir.status = 0
+  AND NOT EXISTS (
+    SELECT 1
+    FROM (
+      SELECT UNNEST(@kinds::INT[]) AS kind, UNNEST(@raw_rows::TEXT[]) AS raw_row
+    ) AS r
+    WHERE r.kind = jir.kind
+      AND r.raw_row = jir.raw_row
+  )
+;
+
+-- name: GetPendingJournalImportReviews :many
+SELECT jir.*, p.name AS patient_name
+FROM journal_import_review AS jir
+INNER JOIN patient AS p
+  ON p.id = jir.patient_id
+WHERE jir.status = 0
+ORDER BY jir.created, jir.id
+;
+
+-- name: GetJournalImportReview :one
+SELECT *
+FROM journal_import_review
+WHERE id = @id
+;
+
+-- name: CountPendingJournalImportReviewsForPatient :one
+SELECT COUNT(*)
+FROM journal_import_review
+WHERE patient_id = @patient_id
+  AND status = 0
+;
+
+-- name: SetJournalImportReviewStatus :execrows
+UPDATE journal_import_review
+SET status = @status
+WHERE id = @id
+  AND status = 0
+;
@@ -23,6 +23,21 @@ FROM patient AS p
 WHERE p.journal_url LIKE CONCAT('%', @lookup::TEXT, '%')
 ;
 
+-- name: GetPatientsWithJournalURL :many
+SELECT
+  p.id
+FROM patient AS p
+WHERE p.journal_url = @journal_url
+;
+
+-- name: GetPatientHomesByJournalURL :many
+SELECT
+  p.journal_url::TEXT AS journal_url,
+  p.curr_home_id
+FROM patient AS p
+WHERE p.journal_url = ANY(@journal_urls::TEXT[])
+;
+
 -- name: GetFormerPatients :many
 SELECT
   p.id,
@@ -42,8 +57,8 @@ ORDER BY p.id DESC
 ;
 
 -- name: AddPatient :one
-INSERT INTO patient (species_id, name, curr_home_id, status, time_checkin)
-VALUES ($1, $2, $3, $4, NOW())
+INSERT INTO patient (species_id, name, curr_home_id, status, finder, intake_cause, time_checkin)
+VALUES ($1, $2, $3, $4, $5, $6, NOW())
 RETURNING id
 ;
 
@@ -99,11 +114,21 @@ WHERE id = $1
 ;
 
 -- name: SetPatientJournal :execresult
+-- The template is only known for journals created by bino
 UPDATE patient
-SET journal_url = $2
+SET journal_url = $2,
+    journal_template = CASE WHEN journal_url IS DISTINCT FROM $2 THEN NULL ELSE journal_template END
 WHERE id = $1
 ;
 
+-- name: SetPatientJournalIfMissing :execrows
+UPDATE patient
+SET journal_url = @journal_url,
+    journal_template = sqlc.narg('journal_template')
+WHERE id = @id
+  AND (journal_url IS NULL OR journal_url = '')
+;
+
 -- name: UpdatePatientSortOrder :exec
 UPDATE patient as p
 SET sort_order = v.sort_order
@@ -119,3 +144,53 @@ UPDATE patient
 SET time_checkout = $2
 WHERE id = $1
 ;
+
+-- name: SetSimilarityThreshold :exec
+-- The threshold of the % operator for the rest of the transaction
+SELECT set_config('pg_trgm.similarity_threshold', @threshold::text, true)
+;
+
+-- name: GetSimilarPatients :many
+-- Former patients whose journals resemble the journal of the given patient,
+-- with a bonus for being the same species. Journals are compared by their
+-- excerpts, and only those above the similarity threshold set with
+-- SetSimilarityThreshold are found by the trigram index.
+WITH target AS (
+  SELECT p.species_id, s.similarity_excerpt
+  FROM patient AS p
+  JOIN search AS s
+    ON s.patient_id = p.id
+   AND s.ns = 'patient'
+   AND NOT s.skipped
+  WHERE p.id = @patient_id::int
+)
+SELECT
+  i.*,
+  (i.similarity + CASE WHEN i.same_species THEN @species_weight::real ELSE 0 END)::real AS score
+FROM (
+  SELECT
+    p.id,
+    p.name,
+    p.status,
+    p.time_checkin,
+    p.time_checkout,
+    COALESCE(sl.name, '???') AS species,
+    (p.species_id = t.species_id) AS same_species,
+    similarity(s.similarity_excerpt, t.similarity_excerpt)::real AS similarity
+  FROM target AS t
+  JOIN search AS s
+    ON s.ns = 'patient'
+   AND NOT s.skipped
+   AND s.patient_id <> @patient_id::int
+   AND s.similarity_excerpt % t.similarity_excerpt
+  JOIN patient AS p
+    ON p.id = s.patient_id
+  LEFT JOIN species_language AS sl
+    ON sl.species_id = p.species_id
+   AND sl.language_id = @language_id
+  WHERE p.curr_home_id IS NULL
+    AND p.status <> ALL(@excluded_statuses::int[])
+) i
+ORDER BY score DESC
+LIMIT @result_limit::int
+;
@@ -0,0 +1,114 @@
+-- name: CreateSavedSearch :one
+INSERT INTO saved_search (appuser_id, name, params, alert)
+VALUES (@appuser_id, @name, @params, @alert)
+RETURNING id
+;
+
+-- name: GetSavedSearchesForUser :many
+SELECT *
+FROM saved_search
+WHERE appuser_id = @appuser_id
+ORDER BY name
+;
+
+-- name: SetSavedSearchAlert :exec
+-- Matches indexed before the alert was turned on are not reported
+UPDATE saved_search
+SET alert = @alert,
+    last_checked = CASE WHEN alert = 0 THEN NOW() ELSE last_checked END
+WHERE id = @id
+  AND appuser_id = @appuser_id
+;
+
+-- name: DeleteSavedSearch :exec
+DELETE FROM saved_search
+WHERE id = @id
+  AND appuser_id = @appuser_id
+;
+
+-- name: GetSavedSearchesWithAlerts :many
+SELECT
+  ss.*,
+  au.display_name,
+  au.email,
+  au.access_level,
+  COALESCE(al.language_id, 1)::int AS language_id
+FROM saved_search AS ss
+JOIN appuser AS au
+  ON au.id = ss.appuser_id
+LEFT JOIN appuser_language AS al
+  ON al.appuser_id = au.id
+WHERE ss.alert > 0
+ORDER BY ss.id
+;
+
+-- name: SetSavedSearchChecked :exec
+UPDATE saved_search
+SET last_checked = @last_checked
+WHERE id = @id
+;
+
+-- name: UpsertNotification :exec
+INSERT INTO notification (appuser_id, saved_search_id, header, url)
+VALUES (@appuser_id, @saved_search_id, @header, @url)
+ON CONFLICT (saved_search_id, url) DO UPDATE SET
+    header  = EXCLUDED.header,
+    created = NOW(),
+    seen    = FALSE,
+    emailed = FALSE
+;
+
+-- name: GetNotificationsForUser :many
+SELECT n.*, ss.name AS saved_search_name, ss.params AS saved_search_params
+FROM notification AS n
+JOIN saved_search AS ss
+  ON ss.id = n.saved_search_id
+WHERE n.appuser_id = @appuser_id
+ORDER BY n.created DESC
+LIMIT 200
+;
+
+-- name: CountUnseenNotifications :one
+SELECT COUNT(*)::int
+FROM notification
+WHERE appuser_id = @appuser_id
+  AND NOT seen
+;
+
+-- name: MarkNotificationsSeen :exec
+UPDATE notification
+SET seen = TRUE
+WHERE appuser_id = @appuser_id
+  AND NOT seen
+;
+
+-- name: DeleteNotificationsForUser :exec
+DELETE FROM notification
+WHERE appuser_id = @appuser_id
+;
+
+-- name: GetNotificationsForDigest :many
+-- Unseen notifications that have not been emailed, for searches with email alerts
+SELECT
+  n.*,
+  ss.name AS saved_search_name,
+  au.email,
+  COALESCE(al.language_id, 1)::int AS language_id
+FROM notification AS n
+JOIN saved_search AS ss
+  ON ss.id = n.saved_search_id
+JOIN appuser AS au
+  ON au.id = n.appuser_id
+LEFT JOIN appuser_language AS al
+  ON al.appuser_id = au.id
+WHERE ss.alert = 2
+  AND NOT n.emailed
+  AND NOT n.seen
+ORDER BY n.appuser_id, ss.name, n.created
+;
+
+-- name: SetNotificationsEmailed :exec
+UPDATE notification
+SET emailed = TRUE
+WHERE id = ANY(@ids::int[])
+;
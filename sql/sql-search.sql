@@ -1,5 +1,5 @@
 -- name: UpsertSearchEntry :exec
-INSERT INTO search (ns, associated_url, created, updated, header, body, lang, extra_data, skipped)
+INSERT INTO search (ns, associated_url, created, updated, header, body, lang, extra_data, skipped, owner_id)
 VALUES (
     @namespace,
     @associated_url,
@@ -9,7 +9,8 @@ VALUES (
     @body,
     @lang,
     @extra_data,
-    FALSE
+    FALSE,
+    sqlc.narg('owner_id')
 )
 ON CONFLICT (ns, associated_url) DO UPDATE SET
     created        = EXCLUDED.created,
@@ -19,11 +20,19 @@ ON CONFLICT (ns, associated_url) DO UPDATE SET
     lang           = EXCLUDED.lang,
     associated_url = EXCLUDED.associated_url,
     extra_data     = EXCLUDED.extra_data,
-    skipped        = EXCLUDED.skipped
+    skipped        = EXCLUDED.skipped,
+    skip_reason    = NULL,
+    owner_id       = EXCLUDED.owner_id,
+    indexed        = CASE
+        WHEN search.header IS DISTINCT FROM EXCLUDED.header
+          OR search.body   IS DISTINCT FROM EXCLUDED.body
+        THEN NOW()
+        ELSE search.indexed
+    END
 ;
 
 -- name: UpsertSkippedSearchEntry :exec
-INSERT INTO search (ns, associated_url, created, updated, header, body, lang, extra_data, skipped)
+INSERT INTO search (ns, associated_url, created, updated, header, body, lang, extra_data, skipped, skip_reason, owner_id)
 VALUES (
   @namespace,
   @associated_url,
@@ -33,7 +42,9 @@ VALUES (
   @body,
   @lang,
   @extra_data,
-  TRUE
+  TRUE,
+  @skip_reason,
+  sqlc.narg('owner_id')
 )
 ON CONFLICT (ns, associated_url) DO UPDATE SET
     created        = EXCLUDED.created,
@@ -43,7 +54,9 @@ ON CONFLICT (ns, associated_url) DO UPDATE SET
     lang           = EXCLUDED.lang,
     associated_url = EXCLUDED.associated_url,
     extra_data     = EXCLUDED.extra_data,
-    skipped        = EXCLUDED.skipped
+    skipped        = EXCLUDED.skipped,
+    skip_reason    = EXCLUDED.skip_reason,
+    owner_id       = EXCLUDED.owner_id
 ;
 
 -- name: UpdateSearchMetadata :execresult
@@ -72,8 +85,11 @@ WHERE ns = @namespace
 ;
 
 -- name: SearchBasic :many
-WITH q AS (
-  SELECT websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text) AS qry
+WITH qs AS (
+  SELECT
+    websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text) AS qry_user,
+    websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text)
+      || websearch_to_tsquery(sqlc.arg('other_lang')::regconfig, sqlc.arg('query')::text) AS qry_both
 )
 SELECT
   i.*,
@@ -84,17 +100,38 @@ SELECT
 FROM (
   SELECT
     (sqlc.arg('w_fts_header')::real   * ts_rank(s.fts_header, q.qry))::real AS r_fts_header,
-    (sqlc.arg('w_fts_body')::real     * ts_rank(s.fts_body,   q.qry))::real AS r_fts_body,
-    ts_headline(sqlc.arg('lang')::regconfig, s.header, q.qry, 'StartSel=[START],StopSel=[STOP],HighlightAll=true')::text AS header_headline,
-    ts_headline(sqlc.arg('lang')::regconfig, s.body,   q.qry, 'StartSel=[START],StopSel=[STOP],MaxFragments=5,MinWords=3,MaxWords=10,FragmentDelimiter=[CUT]')::text AS body_headline,
+    (sqlc.arg('w_fts_body')::real     * CASE WHEN v.body_visible THEN ts_rank(s.fts_body, q.qry) ELSE 0 END)::real AS r_fts_body,
+    ts_headline(COALESCE(s.lang, sqlc.arg('lang')::regconfig), s.header, q.qry, 'StartSel=[START],StopSel=[STOP],HighlightAll=true')::text AS header_headline,
+    CASE WHEN v.body_visible
+      THEN ts_headline(COALESCE(s.lang, sqlc.arg('lang')::regconfig), s.body, q.qry, 'StartSel=[START],StopSel=[STOP],MaxFragments=5,MinWords=3,MaxWords=10,FragmentDelimiter=[CUT]')
+      ELSE ''
+    END::text AS body_headline,
     s.ns,
     s.associated_url,
     s.created,
     s.updated,
     s.extra_data
   FROM search s
-  CROSS JOIN q
-  WHERE search_match_basic(s, q.qry)
+  CROSS JOIN qs
+  -- The query for the language of the document, used for ranking and headlines
+  CROSS JOIN LATERAL (
+    SELECT CASE WHEN s.lang = sqlc.arg('other_lang')::regconfig THEN qs.qry_both ELSE qs.qry_user END AS qry
+  ) q
+  LEFT JOIN search_facet sf
+    ON sf.ns = s.ns
+   AND sf.associated_url = s.associated_url
+  CROSS JOIN LATERAL (
+    SELECT search_body_visible(
+      s,
+      sf,
+      sqlc.arg('all_bodies')::boolean,
+      sqlc.arg('other_bodies')::boolean,
+      sqlc.narg('body_home_ids')::int[]
+    ) AS body_visible
+  ) v
+  WHERE search_match_basic_lang(s, qs.qry_user, qs.qry_both, sqlc.arg('other_lang')::regconfig, v.body_visible)
+    AND search_visible(s, sqlc.arg('user_id')::int)
+    AND (sqlc.narg('min_indexed')::timestamptz IS NULL OR s.indexed > sqlc.narg('min_indexed')::timestamptz)
 ) i
 ORDER BY rank DESC
 LIMIT sqlc.arg('limit')
@@ -102,18 +139,37 @@ OFFSET sqlc.arg('offset')
 ;
 
 -- name: SearchBasicCount :one
-WITH q AS (
-  SELECT websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text) AS qry
+WITH qs AS (
+  SELECT
+    websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text) AS qry_user,
+    websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text)
+      || websearch_to_tsquery(sqlc.arg('other_lang')::regconfig, sqlc.arg('query')::text) AS qry_both
 )
 SELECT COUNT(*)::int AS n
 FROM search s
-CROSS JOIN q
-WHERE search_match_basic(s, q.qry)
+CROSS JOIN qs
+LEFT JOIN search_facet sf
+  ON sf.ns = s.ns
+ AND sf.associated_url = s.associated_url
+CROSS JOIN LATERAL (
+  SELECT search_body_visible(
+    s,
+    sf,
+    sqlc.arg('all_bodies')::boolean,
+    sqlc.arg('other_bodies')::boolean,
+    sqlc.narg('body_home_ids')::int[]
+  ) AS body_visible
+) v
+WHERE search_match_basic_lang(s, qs.qry_user, qs.qry_both, sqlc.arg('other_lang')::regconfig, v.body_visible)
+  AND search_visible(s, sqlc.arg('user_id')::int)
 ;
 
 -- name: SearchAdvanced :many
-WITH q AS (
-  SELECT websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text) AS qry
+WITH qs AS (
+  SELECT
+    websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text) AS qry_user,
+    websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text)
+      || websearch_to_tsquery(sqlc.arg('other_lang')::regconfig, sqlc.arg('query')::text) AS qry_both
 )
 SELECT
   i.*,
@@ -129,29 +185,48 @@ SELECT
 FROM (
   SELECT
     (sqlc.arg('w_fts_header')::real   * ts_rank(s.fts_header, q.qry))::real AS r_fts_header,
-    (sqlc.arg('w_fts_body')::real     * ts_rank(s.fts_body,   q.qry))::real AS r_fts_body,
+    (sqlc.arg('w_fts_body')::real     * CASE WHEN v.body_visible THEN ts_rank(s.fts_body, q.qry) ELSE 0 END)::real AS r_fts_body,
     (sqlc.arg('w_sim_header')::real   * f.sim_header)::real                 AS r_sim_header,
     (sqlc.arg('w_sim_body')::real     * f.sim_body)::real                   AS r_sim_body,
     (sqlc.arg('w_ilike_header')::real * f.ilike_header)::real               AS r_ilike_header,
     (sqlc.arg('w_ilike_body')::real   * f.ilike_body)::real                 AS r_ilike_body,
     (sqlc.arg('w_recency')::real      * f.recency)::real                    AS r_recency,
     COALESCE(s.header, '') AS header,
-    COALESCE(s.body, '') AS body,
-    ts_headline(sqlc.arg('lang')::regconfig, s.header, q.qry, 'StartSel=[START],StopSel=[STOP],HighlightAll=true')::text AS header_headline,
-    ts_headline(sqlc.arg('lang')::regconfig, s.body,   q.qry, 'StartSel=[START],StopSel=[STOP],MaxFragments=5,MinWords=3,MaxWords=10,FragmentDelimiter=[CUT]')::text AS body_headline,
+    CASE WHEN v.body_visible THEN COALESCE(s.body, '') ELSE '' END AS body,
+    ts_headline(COALESCE(s.lang, sqlc.arg('lang')::regconfig), s.header, q.qry, 'StartSel=[START],StopSel=[STOP],HighlightAll=true')::text AS header_headline,
+    CASE WHEN v.body_visible
+      THEN ts_headline(COALESCE(s.lang, sqlc.arg('lang')::regconfig), s.body, q.qry, 'StartSel=[START],StopSel=[STOP],MaxFragments=5,MinWords=3,MaxWords=10,FragmentDelimiter=[CUT]')
+      ELSE ''
+    END::text AS body_headline,
     s.ns,
     s.associated_url,
     s.created,
     s.updated,
     s.extra_data
   FROM search s
-  CROSS JOIN q
+  CROSS JOIN qs
+  -- The query for the language of the document, used for ranking and headlines
+  CROSS JOIN LATERAL (
+    SELECT CASE WHEN s.lang = sqlc.arg('other_lang')::regconfig THEN qs.qry_both ELSE qs.qry_user END AS qry
+  ) q
+  LEFT JOIN search_facet sf
+    ON sf.ns = s.ns
+   AND sf.associated_url = s.associated_url
+  CROSS JOIN LATERAL (
+    SELECT search_body_visible(
+      s,
+      sf,
+      sqlc.arg('all_bodies')::boolean,
+      sqlc.arg('other_bodies')::boolean,
+      sqlc.narg('body_home_ids')::int[]
+    ) AS body_visible
+  ) v
   CROSS JOIN LATERAL (
     SELECT
       similarity(lower(s.header), lower(sqlc.arg('query'))) AS sim_header,
-      similarity(lower(s.body),   lower(sqlc.arg('query'))) AS sim_body,
+      CASE WHEN v.body_visible THEN similarity(lower(s.body), lower(sqlc.arg('query'))) ELSE 0 END AS sim_body,
       CASE WHEN s.header ILIKE ('%' || sqlc.arg('query') || '%') THEN 1 ELSE 0 END AS ilike_header,
-      CASE WHEN s.body   ILIKE ('%' || sqlc.arg('query') || '%') THEN 1 ELSE 0 END AS ilike_body,
+      CASE WHEN v.body_visible AND s.body ILIKE ('%' || sqlc.arg('query') || '%') THEN 1 ELSE 0 END AS ilike_body,
       exp(
         - GREATEST(0, EXTRACT(EPOCH FROM (now() - s.created))) /
           (sqlc.arg('recency_half_life_days')::real * 86400.0)
@@ -165,8 +240,19 @@ FROM (
     sqlc.narg('min_created')::timestamptz,
     sqlc.narg('max_created')::timestamptz,
     sqlc.narg('min_updated')::timestamptz,
-    sqlc.narg('max_updated')::timestamptz
+    sqlc.narg('max_updated')::timestamptz,
+    v.body_visible
+  )
+  AND search_match_filters(
+    sf,
+    sqlc.narg('namespaces')::text[],
+    sqlc.narg('species_ids')::int[],
+    sqlc.narg('home_ids')::int[],
+    sqlc.narg('statuses')::int[],
+    sqlc.narg('active')::boolean
   )
+  AND search_visible(s, sqlc.arg('user_id')::int)
+  AND (sqlc.narg('min_indexed')::timestamptz IS NULL OR s.indexed > sqlc.narg('min_indexed')::timestamptz)
 ) i
 ORDER BY rank DESC
 LIMIT sqlc.arg('limit')
@@ -174,12 +260,30 @@ OFFSET sqlc.arg('offset')
 ;
 
 -- name: SearchAdvancedCount :one
-WITH q AS (
-  SELECT websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text) AS qry
+WITH qs AS (
+  SELECT
+    websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text) AS qry_user,
+    websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text)
+      || websearch_to_tsquery(sqlc.arg('other_lang')::regconfig, sqlc.arg('query')::text) AS qry_both
 )
 SELECT COUNT(*)::int AS n
 FROM search s
-CROSS JOIN q
+CROSS JOIN qs
+CROSS JOIN LATERAL (
+  SELECT CASE WHEN s.lang = sqlc.arg('other_lang')::regconfig THEN qs.qry_both ELSE qs.qry_user END AS qry
+) q
+LEFT JOIN search_facet sf
+  ON sf.ns = s.ns
+ AND sf.associated_url = s.associated_url
+CROSS JOIN LATERAL (
+  SELECT search_body_visible(
+    s,
+    sf,
+    sqlc.arg('all_bodies')::boolean,
+    sqlc.arg('other_bodies')::boolean,
+    sqlc.narg('body_home_ids')::int[]
+  ) AS body_visible
+) v
 WHERE search_match_advanced(
   s,
   q.qry,
@@ -188,5 +292,209 @@ WHERE search_match_advanced(
   sqlc.narg('min_created')::timestamptz,
   sqlc.narg('max_created')::timestamptz,
   sqlc.narg('min_updated')::timestamptz,
-  sqlc.narg('max_updated')::timestamptz
-);
+  sqlc.narg('max_updated')::timestamptz,
+  v.body_visible
+)
+AND search_match_filters(
+  sf,
+  sqlc.narg('namespaces')::text[],
+  sqlc.narg('species_ids')::int[],
+  sqlc.narg('home_ids')::int[],
+  sqlc.narg('statuses')::int[],
+  sqlc.narg('active')::boolean
+)
+AND search_visible(s, sqlc.arg('user_id')::int);
+
+-- name: SearchAdvancedFacets :many
+-- Counts for each facet value among the matches. Each facet is counted with
+-- every filter applied except its own, so that the alternatives stay visible.
+-- Entries without facets are kept, and only fail to match when a facet
+-- filter is set.
+WITH qs AS (
+  SELECT
+    websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text) AS qry_user,
+    websearch_to_tsquery(sqlc.arg('lang')::regconfig, sqlc.arg('query')::text)
+      || websearch_to_tsquery(sqlc.arg('other_lang')::regconfig, sqlc.arg('query')::text) AS qry_both
+),
+m AS (
+  SELECT
+    s.ns,
+    s.associated_url,
+    sf.species_id,
+    sf.home_id,
+    sf.status,
+    sf.active
+  FROM search s
+  CROSS JOIN qs
+  -- The query for the language of the document
+  CROSS JOIN LATERAL (
+    SELECT CASE WHEN s.lang = sqlc.arg('other_lang')::regconfig THEN qs.qry_both ELSE qs.qry_user END AS qry
+  ) q
+  LEFT JOIN search_facet sf
+    ON sf.ns = s.ns
+   AND sf.associated_url = s.associated_url
+  CROSS JOIN LATERAL (
+    SELECT search_body_visible(
+      s,
+      sf,
+      sqlc.arg('all_bodies')::boolean,
+      sqlc.arg('other_bodies')::boolean,
+      sqlc.narg('body_home_ids')::int[]
+    ) AS body_visible
+  ) v
+  WHERE search_match_advanced(
+    s,
+    q.qry,
+    sqlc.arg('query'),
+    sqlc.arg('simthreshold')::real,
+    sqlc.narg('min_created')::timestamptz,
+    sqlc.narg('max_created')::timestamptz,
+    sqlc.narg('min_updated')::timestamptz,
+    sqlc.narg('max_updated')::timestamptz,
+    v.body_visible
+  )
+  AND search_visible(s, sqlc.arg('user_id')::int)
+)
+SELECT 'namespace'::text AS facet, m.ns::text AS value, COUNT(*)::int AS n
+FROM m
+WHERE search_match_filters(m, NULL, sqlc.narg('species_ids')::int[], sqlc.narg('home_ids')::int[], sqlc.narg('statuses')::int[], sqlc.narg('active')::boolean)
+GROUP BY m.ns
+UNION ALL
+SELECT 'species'::text, m.species_id::text, COUNT(*)::int
+FROM m
+WHERE m.species_id IS NOT NULL
+  AND search_match_filters(m, sqlc.narg('namespaces')::text[], NULL, sqlc.narg('home_ids')::int[], sqlc.narg('statuses')::int[], sqlc.narg('active')::boolean)
+GROUP BY m.species_id
+UNION ALL
+SELECT 'home'::text, m.home_id::text, COUNT(*)::int
+FROM m
+WHERE m.home_id IS NOT NULL
+  AND search_match_filters(m, sqlc.narg('namespaces')::text[], sqlc.narg('species_ids')::int[], NULL, sqlc.narg('statuses')::int[], sqlc.narg('active')::boolean)
+GROUP BY m.home_id
+UNION ALL
+SELECT 'status'::text, m.status::text, COUNT(*)::int
+FROM m
+WHERE m.status IS NOT NULL
+  AND search_match_filters(m, sqlc.narg('namespaces')::text[], sqlc.narg('species_ids')::int[], sqlc.narg('home_ids')::int[], NULL, sqlc.narg('active')::boolean)
+GROUP BY m.status
+UNION ALL
+SELECT 'active'::text, m.active::text, COUNT(*)::int
+FROM m
+WHERE m.ns = 'patient'
+  AND search_match_filters(m, sqlc.narg('namespaces')::text[], sqlc.narg('species_ids')::int[], sqlc.narg('home_ids')::int[], sqlc.narg('statuses')::int[], NULL)
+GROUP BY m.active
+;
+
+-- name: GetSearchSyncState :one
+SELECT *
+FROM search_sync_state
+WHERE drive_id = @drive_id
+;
+
+-- name: SetSearchSyncIncremental :exec
+INSERT INTO search_sync_state (drive_id, page_token, last_incremental_sync)
+VALUES (@drive_id, @page_token, NOW())
+ON CONFLICT (drive_id) DO UPDATE SET
+    page_token            = EXCLUDED.page_token,
+    last_incremental_sync = EXCLUDED.last_incremental_sync
+;
+
+-- name: SetSearchSyncFull :exec
+INSERT INTO search_sync_state (drive_id, page_token, last_incremental_sync, last_full_sync)
+VALUES (@drive_id, @page_token, NOW(), NOW())
+ON CONFLICT (drive_id) DO UPDATE SET
+    page_token            = EXCLUDED.page_token,
+    last_incremental_sync = EXCLUDED.last_incremental_sync,
+    last_full_sync        = EXCLUDED.last_full_sync
+;
+
+-- name: ResetSearchSyncPageToken :exec
+UPDATE search_sync_state
+SET page_token = ''
+WHERE drive_id = @drive_id
+;
+
+-- name: GetSearchIndexStats :many
+SELECT
+  ns,
+  COUNT(*)::int                               AS n,
+  (COUNT(*) FILTER (WHERE skipped))::int      AS n_skipped,
+  MAX(updated)::timestamptz                   AS last_updated
+FROM search
+GROUP BY ns
+ORDER BY ns
+;
+
+-- name: GetSkippedSearchEntries :many
+SELECT
+  ns,
+  associated_url,
+  COALESCE(header, '')::text      AS header,
+  updated,
+  COALESCE(skip_reason, '')::text AS skip_reason,
+  extra_data
+FROM search
+WHERE skipped
+ORDER BY updated DESC
+;
+
+-- name: GetSearchFolderSyncs :many
+SELECT *
+FROM search_folder_sync
+;
+
+-- name: SetSearchFolderSynced :exec
+INSERT INTO search_folder_sync (folder_id, folder_name, last_sync, n_files, last_error)
+VALUES (@folder_id, @folder_name, NOW(), @n_files, NULL)
+ON CONFLICT (folder_id) DO UPDATE SET
+    folder_name = EXCLUDED.folder_name,
+    last_sync   = EXCLUDED.last_sync,
+    n_files     = EXCLUDED.n_files,
+    last_error  = NULL
+;
+
+-- name: SetSearchFolderSyncError :exec
+INSERT INTO search_folder_sync (folder_id, last_error)
+VALUES (@folder_id, @last_error)
+ON CONFLICT (folder_id) DO UPDATE SET
+    last_error = EXCLUDED.last_error
+;
+
+-- name: GetUploadedFiles :many
+-- Files uploaded by users, without the journal archives, which only users
+-- allowed to read the journal can see
+SELECT f.*
+FROM file AS f
+WHERE NOT EXISTS (
+  SELECT 1
+  FROM patient_journal_archive AS pja
+  WHERE pja.file_id = f.id
+)
+ORDER BY f.id
+;
+
+-- name: GetJournalArchiveFiles :many
+SELECT f.*
+FROM file AS f
+JOIN patient_journal_archive AS pja
+  ON pja.file_id = f.id
+ORDER BY f.id
+;
+
+-- name: GetSearchURLs :many
+SELECT associated_url::TEXT
+FROM search
+WHERE ns = @namespace
+  AND associated_url IS NOT NULL
+;
+
+-- name: GetSearchEntryBody :one
+SELECT
+  COALESCE(body, '')::TEXT AS body,
+  updated,
+  extra_data
+FROM search
+WHERE ns = @namespace
+  AND associated_url = @associated_url
+  AND NOT skipped
+;
@@ -12,6 +12,11 @@ WHERE species_id = $1
   AND language_id = $2
 ;
 
+-- name: GetScientificNameOfSpecies :one
+SELECT scientific_name FROM species
+WHERE id = $1
+;
+
 -- name: AddSpecies :one
 INSERT INTO species (scientific_name)
 VALUES ($1)